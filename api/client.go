@@ -26,9 +26,10 @@ type CallerIdResponse struct {
 	CallerId string `json:"caller_id"`
 }
 type DomainResponse struct {
-	Id            int    `json:"id"`
-	WorkspaceId   int    `json:"workspace_id"`
-	WorkspaceName string `json:"workspace_name"`
+	Id                int    `json:"id"`
+	WorkspaceId       int    `json:"workspace_id"`
+	WorkspaceName     string `json:"workspace_name"`
+	WorkspaceTimezone string `json:"workspace_timezone"`
 }
 
 type FlowResponse struct {
@@ -65,6 +66,21 @@ type ConferenceResponse struct {
 	Id string `json:"id"`
 }
 
+// LookupTableResponse is a table uploaded to a workspace, as CSV with a
+// header row or as a JSON list of objects.
+type LookupTableResponse struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Data   string `json:"data"`
+}
+
+// MailboxGreetingResponse is the greeting recorded for a mailbox, which has
+// an empty url when none was recorded.
+type MailboxGreetingResponse struct {
+	MailboxId string `json:"mailbox_id"`
+	UrlAudio  string `json:"url_audio"`
+}
+
 type SettingsResponse struct {
 	AwsAccessKeyId           string `json:"aws_access_key_id"`
 	AwsSecretAccessKey       string `json:"aws_secret_access_key"`
@@ -86,6 +102,11 @@ var baseUrl string = "https://internals." + os.Getenv("DEPLOYMENT_DOMAIN")
 var customHeader string = "myvalue"
 var contentType string = "application/json"
 
+// SetBaseUrl points the client at another internals API, e.g. a local fake.
+func SetBaseUrl(url string) {
+	baseUrl = url
+}
+
 func SendHttpRequest(path string, payload []byte) (*APIResponse, error) {
 	url := baseUrl + path
 
@@ -372,6 +393,69 @@ func FetchCall(callId string) (*CallResponse, error) {
 	return &data, nil
 }
 
+func SaveFlowTrace(trace *types.FlowTrace) error {
+	body, err := json.Marshal(trace)
+	if err != nil {
+		return err
+	}
+
+	_, err = SendHttpRequest("/call/saveFlowTrace", body)
+	return err
+}
+
+func FetchFlowTrace(callId string) (*types.FlowTrace, error) {
+	params := make(map[string]string)
+	params["call_id"] = callId
+	res, err := SendGetRequest("/call/getFlowTrace", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var data types.FlowTrace
+	err = json.Unmarshal([]byte(res), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func GetLookupTable(workspace string, name string) (*LookupTableResponse, error) {
+	params := make(map[string]string)
+	params["workspace"] = workspace
+	params["name"] = name
+	res, err := SendGetRequest("/user/getLookupTable", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var data LookupTableResponse
+	err = json.Unmarshal([]byte(res), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func GetMailboxGreeting(workspace string, mailbox string) (*MailboxGreetingResponse, error) {
+	params := make(map[string]string)
+	params["workspace"] = workspace
+	params["mailbox"] = mailbox
+	res, err := SendGetRequest("/user/getMailboxGreeting", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var data MailboxGreetingResponse
+	err = json.Unmarshal([]byte(res), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
 func CreateConference(workspaceId int, name string) (*ConferenceResponse, error) {
 	fmt.Println("creating conference...")
 	params := ConfParams{
@@ -2923,6 +2923,629 @@ func (*RecordingReply) Descriptor() ([]byte, []int) {
 	return file_lineblocs_proto_rawDescGZIP(), []int{58}
 }
 
+type FlowTraceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CallId string `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+}
+
+func (x *FlowTraceRequest) Reset() {
+	*x = FlowTraceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[59]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowTraceRequest) ProtoMessage() {}
+
+func (x *FlowTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[59]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowTraceRequest.ProtoReflect.Descriptor instead.
+func (*FlowTraceRequest) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *FlowTraceRequest) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+type FlowTraceEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CellId     string            `protobuf:"bytes,1,opt,name=cell_id,json=cellId,proto3" json:"cell_id,omitempty"`
+	CellName   string            `protobuf:"bytes,2,opt,name=cell_name,json=cellName,proto3" json:"cell_name,omitempty"`
+	CellType   string            `protobuf:"bytes,3,opt,name=cell_type,json=cellType,proto3" json:"cell_type,omitempty"`
+	EnteredAt  int64             `protobuf:"varint,4,opt,name=entered_at,json=enteredAt,proto3" json:"entered_at,omitempty"`
+	ExitedAt   int64             `protobuf:"varint,5,opt,name=exited_at,json=exitedAt,proto3" json:"exited_at,omitempty"`
+	DurationMs int64             `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Port       string            `protobuf:"bytes,7,opt,name=port,proto3" json:"port,omitempty"`
+	NextCell   string            `protobuf:"bytes,8,opt,name=next_cell,json=nextCell,proto3" json:"next_cell,omitempty"`
+	Vars       map[string]string `protobuf:"bytes,9,rep,name=vars,proto3" json:"vars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Error      string            `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *FlowTraceEntry) Reset() {
+	*x = FlowTraceEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[60]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowTraceEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowTraceEntry) ProtoMessage() {}
+
+func (x *FlowTraceEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[60]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowTraceEntry.ProtoReflect.Descriptor instead.
+func (*FlowTraceEntry) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *FlowTraceEntry) GetCellId() string {
+	if x != nil {
+		return x.CellId
+	}
+	return ""
+}
+
+func (x *FlowTraceEntry) GetCellName() string {
+	if x != nil {
+		return x.CellName
+	}
+	return ""
+}
+
+func (x *FlowTraceEntry) GetCellType() string {
+	if x != nil {
+		return x.CellType
+	}
+	return ""
+}
+
+func (x *FlowTraceEntry) GetEnteredAt() int64 {
+	if x != nil {
+		return x.EnteredAt
+	}
+	return 0
+}
+
+func (x *FlowTraceEntry) GetExitedAt() int64 {
+	if x != nil {
+		return x.ExitedAt
+	}
+	return 0
+}
+
+func (x *FlowTraceEntry) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *FlowTraceEntry) GetPort() string {
+	if x != nil {
+		return x.Port
+	}
+	return ""
+}
+
+func (x *FlowTraceEntry) GetNextCell() string {
+	if x != nil {
+		return x.NextCell
+	}
+	return ""
+}
+
+func (x *FlowTraceEntry) GetVars() map[string]string {
+	if x != nil {
+		return x.Vars
+	}
+	return nil
+}
+
+func (x *FlowTraceEntry) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type FlowTraceReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CallId    string            `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
+	ChannelId string            `protobuf:"bytes,2,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	FlowId    int32             `protobuf:"varint,3,opt,name=flow_id,json=flowId,proto3" json:"flow_id,omitempty"`
+	StartedAt int64             `protobuf:"varint,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	EndedAt   int64             `protobuf:"varint,5,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
+	Entries   []*FlowTraceEntry `protobuf:"bytes,6,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *FlowTraceReply) Reset() {
+	*x = FlowTraceReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[61]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowTraceReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowTraceReply) ProtoMessage() {}
+
+func (x *FlowTraceReply) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[61]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowTraceReply.ProtoReflect.Descriptor instead.
+func (*FlowTraceReply) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *FlowTraceReply) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *FlowTraceReply) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *FlowTraceReply) GetFlowId() int32 {
+	if x != nil {
+		return x.FlowId
+	}
+	return 0
+}
+
+func (x *FlowTraceReply) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *FlowTraceReply) GetEndedAt() int64 {
+	if x != nil {
+		return x.EndedAt
+	}
+	return 0
+}
+
+func (x *FlowTraceReply) GetEntries() []*FlowTraceEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type CallerMemoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkspaceId int32  `protobuf:"varint,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Key         string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *CallerMemoryRequest) Reset() {
+	*x = CallerMemoryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[62]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CallerMemoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallerMemoryRequest) ProtoMessage() {}
+
+func (x *CallerMemoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[62]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallerMemoryRequest.ProtoReflect.Descriptor instead.
+func (*CallerMemoryRequest) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *CallerMemoryRequest) GetWorkspaceId() int32 {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return 0
+}
+
+func (x *CallerMemoryRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type CallerMemoryUpdateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkspaceId int32             `protobuf:"varint,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Key         string            `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Values      map[string]string `protobuf:"bytes,3,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	TtlSeconds  int64             `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+}
+
+func (x *CallerMemoryUpdateRequest) Reset() {
+	*x = CallerMemoryUpdateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[63]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CallerMemoryUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallerMemoryUpdateRequest) ProtoMessage() {}
+
+func (x *CallerMemoryUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[63]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallerMemoryUpdateRequest.ProtoReflect.Descriptor instead.
+func (*CallerMemoryUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *CallerMemoryUpdateRequest) GetWorkspaceId() int32 {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return 0
+}
+
+func (x *CallerMemoryUpdateRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CallerMemoryUpdateRequest) GetValues() map[string]string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *CallerMemoryUpdateRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type CallerMemoryDeleteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkspaceId int32    `protobuf:"varint,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Key         string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Names       []string `protobuf:"bytes,3,rep,name=names,proto3" json:"names,omitempty"`
+}
+
+func (x *CallerMemoryDeleteRequest) Reset() {
+	*x = CallerMemoryDeleteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[64]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CallerMemoryDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallerMemoryDeleteRequest) ProtoMessage() {}
+
+func (x *CallerMemoryDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[64]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallerMemoryDeleteRequest.ProtoReflect.Descriptor instead.
+func (*CallerMemoryDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *CallerMemoryDeleteRequest) GetWorkspaceId() int32 {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return 0
+}
+
+func (x *CallerMemoryDeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CallerMemoryDeleteRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type CallerMemoryReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key    string            `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Values map[string]string `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *CallerMemoryReply) Reset() {
+	*x = CallerMemoryReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[65]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CallerMemoryReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallerMemoryReply) ProtoMessage() {}
+
+func (x *CallerMemoryReply) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[65]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallerMemoryReply.ProtoReflect.Descriptor instead.
+func (*CallerMemoryReply) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *CallerMemoryReply) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CallerMemoryReply) GetValues() map[string]string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type TimeOverrideRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WorkspaceId int32  `protobuf:"varint,1,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	Name        string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Mode        string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	TtlSeconds  int64  `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+}
+
+func (x *TimeOverrideRequest) Reset() {
+	*x = TimeOverrideRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[66]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TimeOverrideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeOverrideRequest) ProtoMessage() {}
+
+func (x *TimeOverrideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[66]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeOverrideRequest.ProtoReflect.Descriptor instead.
+func (*TimeOverrideRequest) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *TimeOverrideRequest) GetWorkspaceId() int32 {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return 0
+}
+
+func (x *TimeOverrideRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TimeOverrideRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *TimeOverrideRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type TimeOverrideReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Mode string `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+}
+
+func (x *TimeOverrideReply) Reset() {
+	*x = TimeOverrideReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lineblocs_proto_msgTypes[67]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TimeOverrideReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeOverrideReply) ProtoMessage() {}
+
+func (x *TimeOverrideReply) ProtoReflect() protoreflect.Message {
+	mi := &file_lineblocs_proto_msgTypes[67]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeOverrideReply.ProtoReflect.Descriptor instead.
+func (*TimeOverrideReply) Descriptor() ([]byte, []int) {
+	return file_lineblocs_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *TimeOverrideReply) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TimeOverrideReply) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
 var File_lineblocs_proto protoreflect.FileDescriptor
 
 var file_lineblocs_proto_rawDesc = []byte{
@@ -3165,182 +3788,298 @@ var file_lineblocs_proto_rawDesc = []byte{
 	0x21, 0x0a, 0x0c, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x64, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67,
 	0x49, 0x64, 0x22, 0x10, 0x0a, 0x0e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x32, 0xe8, 0x15, 0x0a, 0x09, 0x4c, 0x69, 0x6e, 0x65, 0x62, 0x6c, 0x6f,
-	0x63, 0x73, 0x12, 0x38, 0x0a, 0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x72, 0x69, 0x64,
-	0x67, 0x65, 0x12, 0x13, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42,
-	0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x0a,
-	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x43, 0x61, 0x6c, 0x6c, 0x12, 0x11, 0x2e, 0x67, 0x72, 0x70,
-	0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00,
-	0x12, 0x38, 0x0a, 0x0a, 0x61, 0x64, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x14,
-	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e,
-	0x6e, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0d, 0x70, 0x6c,
-	0x61, 0x79, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x50, 0x6c, 0x61, 0x79,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0a, 0x67, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65,
-	0x6c, 0x12, 0x19, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
-	0x46, 0x65, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x2b, 0x0a, 0x10, 0x46, 0x6c, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x61, 0x6c, 0x6c,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x61, 0x6c, 0x6c, 0x49,
+	0x64, 0x22, 0xf4, 0x02, 0x0a, 0x0e, 0x46, 0x6c, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x65, 0x6c, 0x6c, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x65, 0x6c, 0x6c, 0x49, 0x64, 0x12, 0x1b, 0x0a,
+	0x09, 0x63, 0x65, 0x6c, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x63, 0x65, 0x6c, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x65,
+	0x6c, 0x6c, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63,
+	0x65, 0x6c, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x74, 0x65, 0x72,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x6e, 0x74,
+	0x65, 0x72, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78, 0x69, 0x74, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74, 0x65,
+	0x64, 0x41, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x6d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x4d, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x65, 0x78, 0x74,
+	0x5f, 0x63, 0x65, 0x6c, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x65, 0x78,
+	0x74, 0x43, 0x65, 0x6c, 0x6c, 0x12, 0x32, 0x0a, 0x04, 0x76, 0x61, 0x72, 0x73, 0x18, 0x09, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x54,
+	0x72, 0x61, 0x63, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x04, 0x76, 0x61, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x1a,
+	0x37, 0x0a, 0x09, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xcb, 0x01, 0x0a, 0x0e, 0x46, 0x6c, 0x6f,
+	0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x17, 0x0a, 0x07, 0x63,
+	0x61, 0x6c, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x61,
+	0x6c, 0x6c, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65,
+	0x6c, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x66, 0x6c, 0x6f, 0x77, 0x5f, 0x69, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x6c, 0x6f, 0x77, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x41, 0x74, 0x12, 0x2e, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65,
+	0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x46,
+	0x6c, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65,
+	0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x4a, 0x0a, 0x13, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72,
+	0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0b, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x22, 0xf1, 0x01, 0x0a, 0x19, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d,
+	0x6f, 0x72, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x21, 0x0a, 0x0c, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x43, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c,
+	0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x74,
+	0x6c, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0a, 0x74, 0x74, 0x6c, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x66, 0x0a, 0x19, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72,
+	0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x77, 0x6f, 0x72, 0x6b, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6d, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x9d,
+	0x01, 0x0a, 0x11, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x3b, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61,
+	0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x2e,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x81,
+	0x01, 0x0a, 0x13, 0x54, 0x69, 0x6d, 0x65, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x77, 0x6f,
+	0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64,
+	0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x74, 0x6c, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x74, 0x6c, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x22, 0x3b, 0x0a, 0x11, 0x54, 0x69, 0x6d, 0x65, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69,
+	0x64, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d,
+	0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x32,
+	0xa7, 0x19, 0x0a, 0x09, 0x4c, 0x69, 0x6e, 0x65, 0x62, 0x6c, 0x6f, 0x63, 0x73, 0x12, 0x38, 0x0a,
+	0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x12, 0x13, 0x2e,
+	0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x11, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x43, 0x61, 0x6c, 0x6c, 0x12, 0x11, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
+	0x43, 0x61, 0x6c, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x0a, 0x61,
+	0x64, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x12, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x69, 0x6e, 0x67, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x42,
+	0x0a, 0x0a, 0x67, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x19, 0x2e, 0x67,
 	0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x46, 0x65, 0x74, 0x63, 0x68,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x10, 0x63, 0x72, 0x65, 0x61, 0x74,
-	0x65, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x17, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66,
-	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x51, 0x0a,
-	0x11, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x67, 0x65, 0x74, 0x42, 0x72, 0x69, 0x64,
-	0x67, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65,
-	0x6c, 0x47, 0x65, 0x74, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
-	0x47, 0x65, 0x74, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00,
-	0x12, 0x5e, 0x0a, 0x18, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x72, 0x65, 0x6d, 0x6f,
-	0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x12, 0x20, 0x2e, 0x67,
-	0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76,
-	0x65, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
-	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x6d,
-	0x6f, 0x76, 0x65, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00,
-	0x12, 0x43, 0x0a, 0x0f, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x70, 0x6c, 0x61, 0x79,
-	0x54, 0x54, 0x53, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e,
-	0x65, 0x6c, 0x54, 0x54, 0x53, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x67,
-	0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x54, 0x54, 0x53, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x1b, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
-	0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6e, 0x67, 0x49,
-	0x6e, 0x70, 0x75, 0x74, 0x12, 0x19, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e,
-	0x6e, 0x65, 0x6c, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x49, 0x6e,
-	0x70, 0x75, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x1b, 0x63, 0x68,
-	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x54, 0x4d, 0x46,
-	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x54,
-	0x4d, 0x46, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x54,
-	0x4d, 0x46, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x51, 0x0a, 0x1a, 0x63, 0x68, 0x61,
-	0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x43, 0x61, 0x6c,
-	0x6c, 0x48, 0x61, 0x6e, 0x67, 0x75, 0x70, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47,
-	0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71,
-	0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x58, 0x0a, 0x16,
-	0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x67, 0x6f, 0x74, 0x6f, 0x46, 0x6c, 0x6f, 0x77,
-	0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68,
-	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x46, 0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68,
-	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x46, 0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x11, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65,
-	0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x46, 0x6c, 0x6f, 0x77, 0x12, 0x23, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x46,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43,
+	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x46, 0x65, 0x74, 0x63, 0x68, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x22, 0x00, 0x12, 0x44, 0x0a, 0x10, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6e, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f,
+	0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x15, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x0c, 0x67, 0x65, 0x74, 0x46,
+	0x6c, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
+	0x46, 0x6c, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63,
+	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0f, 0x67, 0x65, 0x74, 0x43,
+	0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x12, 0x19, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61,
+	0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
+	0x00, 0x12, 0x50, 0x0a, 0x12, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x61, 0x6c, 0x6c, 0x65,
+	0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x12, 0x1f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43,
+	0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
+	0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x50, 0x0a, 0x12, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x61, 0x6c,
+	0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x12, 0x1f, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0f, 0x67, 0x65, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x12, 0x19, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x4f,
+	0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47,
+	0x0a, 0x0f, 0x73, 0x65, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64,
+	0x65, 0x12, 0x19, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x4f, 0x76, 0x65,
+	0x72, 0x72, 0x69, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x51, 0x0a, 0x11, 0x63, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x5f, 0x67, 0x65, 0x74, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x12, 0x1d, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x47, 0x65, 0x74, 0x42, 0x72,
+	0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x47, 0x65, 0x74, 0x42, 0x72, 0x69,
+	0x64, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5e, 0x0a, 0x18, 0x63, 0x68,
+	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d,
+	0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x12, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68,
+	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x42, 0x72, 0x69, 0x64, 0x67,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x42, 0x72, 0x69,
+	0x64, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x0f, 0x63, 0x68,
+	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x70, 0x6c, 0x61, 0x79, 0x54, 0x54, 0x53, 0x12, 0x17, 0x2e,
+	0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x54, 0x54, 0x53, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68,
+	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x54, 0x54, 0x53, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x53, 0x0a, 0x1b, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x19,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x49, 0x6e, 0x70,
+	0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x1b, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x54, 0x4d, 0x46, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e,
+	0x65, 0x72, 0x73, 0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x54, 0x4d, 0x46, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x54, 0x4d, 0x46, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x51, 0x0a, 0x1a, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x61,
+	0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x43, 0x61, 0x6c, 0x6c, 0x48, 0x61, 0x6e, 0x67, 0x75,
+	0x70, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x58, 0x0a, 0x16, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65,
+	0x6c, 0x5f, 0x67, 0x6f, 0x74, 0x6f, 0x46, 0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74,
+	0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x46,
 	0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x21, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x53,
-	0x74, 0x61, 0x72, 0x74, 0x46, 0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x14, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
-	0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x52, 0x69, 0x6e, 0x67, 0x69, 0x6e, 0x67, 0x12, 0x17, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e,
-	0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65,
-	0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70,
-	0x22, 0x00, 0x12, 0x4a, 0x0a, 0x13, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x73, 0x74,
-	0x6f, 0x70, 0x52, 0x69, 0x6e, 0x67, 0x69, 0x6e, 0x67, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52,
-	0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69,
-	0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x45,
-	0x0a, 0x0e, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64,
-	0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52,
-	0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x45, 0x0a, 0x0e, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
-	0x5f, 0x68, 0x61, 0x6e, 0x67, 0x75, 0x70, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47,
+	0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x46,
+	0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00,
+	0x12, 0x5d, 0x0a, 0x11, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x46, 0x6c, 0x6f, 0x77, 0x12, 0x23, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x61,
+	0x6e, 0x6e, 0x65, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x46, 0x6c, 0x6f, 0x77, 0x57, 0x69, 0x64,
+	0x67, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x46, 0x6c,
+	0x6f, 0x77, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x4b, 0x0a, 0x14, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x52, 0x69, 0x6e, 0x67, 0x69, 0x6e, 0x67, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47,
 	0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71,
 	0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x11,
-	0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65,
-	0x6c, 0x12, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e,
-	0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x12, 0x62, 0x72, 0x69,
-	0x64, 0x67, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x12,
-	0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61,
-	0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x67,
-	0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65,
-	0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x14, 0x62, 0x72, 0x69,
-	0x64, 0x67, 0x65, 0x5f, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65,
-	0x6c, 0x12, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e,
-	0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0e, 0x62, 0x72, 0x69,
-	0x64, 0x67, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x79, 0x54, 0x54, 0x53, 0x12, 0x16, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x54, 0x54, 0x53, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67,
-	0x65, 0x54, 0x54, 0x53, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x19, 0x62,
-	0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65,
-	0x67, 0x41, 0x48, 0x61, 0x6e, 0x67, 0x75, 0x70, 0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
-	0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x41, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65,
-	0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
-	0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x41, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65,
-	0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x19, 0x62, 0x72, 0x69, 0x64,
-	0x67, 0x65, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x42, 0x48,
-	0x61, 0x6e, 0x67, 0x75, 0x70, 0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69,
-	0x64, 0x67, 0x65, 0x41, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69,
-	0x64, 0x67, 0x65, 0x41, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x14, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f,
-	0x68, 0x61, 0x6e, 0x67, 0x75, 0x70, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x1a, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e,
-	0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65,
-	0x73, 0x70, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x18, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x68,
-	0x61, 0x6e, 0x67, 0x75, 0x70, 0x41, 0x6c, 0x6c, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73,
-	0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42,
-	0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
-	0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x73,
-	0x70, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x12, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x67, 0x65,
-	0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63,
-	0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65,
-	0x71, 0x1a, 0x19, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x43,
-	0x0a, 0x0e, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x64, 0x65, 0x73, 0x74, 0x72, 0x6f, 0x79,
-	0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42,
-	0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e,
-	0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x73,
-	0x70, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0d, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x72, 0x65,
-	0x63, 0x6f, 0x72, 0x64, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65,
-	0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x17, 0x2e, 0x67,
-	0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67,
-	0x65, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x50, 0x0a, 0x1a, 0x62, 0x72, 0x69, 0x64, 0x67,
-	0x65, 0x5f, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73,
-	0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69,
-	0x64, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x45, 0x76, 0x65,
-	0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x6a, 0x0a, 0x20, 0x63, 0x6f, 0x6e,
-	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x57, 0x61, 0x69, 0x74, 0x69,
-	0x6e, 0x67, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x12, 0x22, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x50,
-	0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65,
-	0x6e, 0x63, 0x65, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x63, 0x0a, 0x19, 0x63, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65,
-	0x6e, 0x63, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61,
-	0x6e, 0x74, 0x12, 0x22, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72,
-	0x65, 0x6e, 0x63, 0x65, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f,
-	0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70,
-	0x61, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x63, 0x0a, 0x1d, 0x63, 0x6f,
-	0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x73, 0x65, 0x74, 0x4d, 0x6f, 0x64, 0x65,
-	0x72, 0x61, 0x74, 0x6f, 0x72, 0x49, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x12, 0x20, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4d, 0x6f, 0x64,
-	0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e,
-	0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4d,
-	0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
-	0x5c, 0x0a, 0x1e, 0x63, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x61, 0x74,
-	0x74, 0x61, 0x63, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65,
-	0x72, 0x12, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65,
-	0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
-	0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a,
-	0x0e, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x74, 0x6f, 0x70, 0x12,
-	0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42,
-	0x04, 0x5a, 0x02, 0x2e, 0x2f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x13,
+	0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x73, 0x74, 0x6f, 0x70, 0x52, 0x69, 0x6e, 0x67,
+	0x69, 0x6e, 0x67, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x45, 0x0a, 0x0e, 0x63, 0x68, 0x61, 0x6e,
+	0x6e, 0x65, 0x6c, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12,
+	0x45, 0x0a, 0x0e, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x5f, 0x68, 0x61, 0x6e, 0x67, 0x75,
+	0x70, 0x12, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x11, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65,
+	0x5f, 0x61, 0x64, 0x64, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x1a, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42,
+	0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x12, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x61, 0x64,
+	0x64, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x12, 0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72,
+	0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x14, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x72, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x1a, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42,
+	0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0e, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x70, 0x6c,
+	0x61, 0x79, 0x54, 0x54, 0x53, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69,
+	0x64, 0x67, 0x65, 0x54, 0x54, 0x53, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
+	0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x54, 0x54, 0x53, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x19, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f,
+	0x61, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x41, 0x48, 0x61, 0x6e, 0x67,
+	0x75, 0x70, 0x12, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65,
+	0x41, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65,
+	0x41, 0x75, 0x74, 0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x22, 0x00, 0x12, 0x5b, 0x0a, 0x19, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x61, 0x75, 0x74,
+	0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x42, 0x48, 0x61, 0x6e, 0x67, 0x75, 0x70, 0x12,
+	0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x41, 0x75, 0x74,
+	0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x41, 0x75, 0x74,
+	0x6f, 0x6d, 0x61, 0x74, 0x65, 0x4c, 0x65, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x4d, 0x0a, 0x14, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x6e, 0x67, 0x75, 0x70,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42,
+	0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x4d,
+	0x0a, 0x18, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x6e, 0x67, 0x75, 0x70, 0x41,
+	0x6c, 0x6c, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52,
+	0x65, 0x71, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69,
+	0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x49, 0x0a,
+	0x12, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x67, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x19, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x0e, 0x62, 0x72, 0x69, 0x64,
+	0x67, 0x65, 0x5f, 0x64, 0x65, 0x73, 0x74, 0x72, 0x6f, 0x79, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52,
+	0x65, 0x71, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69,
+	0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x22, 0x00, 0x12, 0x42, 0x0a,
+	0x0d, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x16,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69,
+	0x64, 0x67, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x47, 0x65,
+	0x6e, 0x65, 0x72, 0x69, 0x63, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x22,
+	0x00, 0x12, 0x50, 0x0a, 0x1a, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x5f, 0x61, 0x74, 0x74, 0x61,
+	0x63, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x12,
+	0x18, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x6a, 0x0a, 0x20, 0x63, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x5f, 0x61, 0x64, 0x64, 0x57, 0x61, 0x69, 0x74, 0x69, 0x6e, 0x67, 0x50, 0x61, 0x72, 0x74,
+	0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x12, 0x22, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43,
+	0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69,
+	0x70, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x50, 0x61, 0x72,
+	0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x63, 0x0a, 0x19, 0x63, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x61, 0x64,
+	0x64, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x12, 0x22, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x50, 0x61,
+	0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x12, 0x63, 0x0a, 0x1d, 0x63, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x5f, 0x73, 0x65, 0x74, 0x4d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x49,
+	0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x12, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x43,
+	0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74,
+	0x6f, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x1e, 0x63, 0x6f, 0x6e,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x1c, 0x2e, 0x67, 0x72,
+	0x70, 0x63, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0e, 0x72, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x74, 0x6f, 0x70, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x14, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x04, 0x5a, 0x02, 0x2e, 0x2f, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -3355,7 +4094,7 @@ func file_lineblocs_proto_rawDescGZIP() []byte {
 	return file_lineblocs_proto_rawDescData
 }
 
-var file_lineblocs_proto_msgTypes = make([]protoimpl.MessageInfo, 61)
+var file_lineblocs_proto_msgTypes = make([]protoimpl.MessageInfo, 73)
 var file_lineblocs_proto_goTypes = []interface{}{
 	(*BridgeRequest)(nil),                 // 0: grpc.BridgeRequest
 	(*BridgeReply)(nil),                   // 1: grpc.BridgeReply
@@ -3416,90 +4155,118 @@ var file_lineblocs_proto_goTypes = []interface{}{
 	(*ConferenceEventReply)(nil),          // 56: grpc.ConferenceEventReply
 	(*RecordingRequest)(nil),              // 57: grpc.RecordingRequest
 	(*RecordingReply)(nil),                // 58: grpc.RecordingReply
-	nil,                                   // 59: grpc.ChannelFlowWidgetRequest.EventVarsEntry
-	nil,                                   // 60: grpc.ChannelStartFlowWidgetRequest.EventVarsEntry
+	(*FlowTraceRequest)(nil),              // 59: grpc.FlowTraceRequest
+	(*FlowTraceEntry)(nil),                // 60: grpc.FlowTraceEntry
+	(*FlowTraceReply)(nil),                // 61: grpc.FlowTraceReply
+	(*CallerMemoryRequest)(nil),           // 62: grpc.CallerMemoryRequest
+	(*CallerMemoryUpdateRequest)(nil),     // 63: grpc.CallerMemoryUpdateRequest
+	(*CallerMemoryDeleteRequest)(nil),     // 64: grpc.CallerMemoryDeleteRequest
+	(*CallerMemoryReply)(nil),             // 65: grpc.CallerMemoryReply
+	(*TimeOverrideRequest)(nil),           // 66: grpc.TimeOverrideRequest
+	(*TimeOverrideReply)(nil),             // 67: grpc.TimeOverrideReply
+	nil,                                   // 68: grpc.ChannelFlowWidgetRequest.EventVarsEntry
+	nil,                                   // 69: grpc.ChannelStartFlowWidgetRequest.EventVarsEntry
+	nil,                                   // 70: grpc.FlowTraceEntry.VarsEntry
+	nil,                                   // 71: grpc.CallerMemoryUpdateRequest.ValuesEntry
+	nil,                                   // 72: grpc.CallerMemoryReply.ValuesEntry
 }
 var file_lineblocs_proto_depIdxs = []int32{
 	8,  // 0: grpc.ChannelFetchReply.channel:type_name -> grpc.Channel
-	59, // 1: grpc.ChannelFlowWidgetRequest.event_vars:type_name -> grpc.ChannelFlowWidgetRequest.EventVarsEntry
-	60, // 2: grpc.ChannelStartFlowWidgetRequest.event_vars:type_name -> grpc.ChannelStartFlowWidgetRequest.EventVarsEntry
+	68, // 1: grpc.ChannelFlowWidgetRequest.event_vars:type_name -> grpc.ChannelFlowWidgetRequest.EventVarsEntry
+	69, // 2: grpc.ChannelStartFlowWidgetRequest.event_vars:type_name -> grpc.ChannelStartFlowWidgetRequest.EventVarsEntry
 	47, // 3: grpc.SessionRecordingsReply.recordings:type_name -> grpc.Recording
 	50, // 4: grpc.ConferenceParticipantRequest.participants:type_name -> grpc.Participant
-	0,  // 5: grpc.Lineblocs.createBridge:input_type -> grpc.BridgeRequest
-	2,  // 6: grpc.Lineblocs.createCall:input_type -> grpc.CallRequest
-	4,  // 7: grpc.Lineblocs.addChannel:input_type -> grpc.ChannelRequest
-	6,  // 8: grpc.Lineblocs.playRecording:input_type -> grpc.RecordingPlayRequest
-	9,  // 9: grpc.Lineblocs.getChannel:input_type -> grpc.ChannelFetchRequest
-	11, // 10: grpc.Lineblocs.createConference:input_type -> grpc.ConferenceRequest
-	13, // 11: grpc.Lineblocs.channel_getBridge:input_type -> grpc.ChannelGetBridgeRequest
-	15, // 12: grpc.Lineblocs.channel_removeFromBridge:input_type -> grpc.ChannelRemoveBridgeRequest
-	17, // 13: grpc.Lineblocs.channel_playTTS:input_type -> grpc.ChannelTTSRequest
-	19, // 14: grpc.Lineblocs.channel_startAcceptingInput:input_type -> grpc.ChannelInputRequest
-	21, // 15: grpc.Lineblocs.channel_removeDTMFListeners:input_type -> grpc.ChannelRemoveDTMFRequest
-	23, // 16: grpc.Lineblocs.channel_automateCallHangup:input_type -> grpc.GenericChannelReq
-	25, // 17: grpc.Lineblocs.channel_gotoFlowWidget:input_type -> grpc.ChannelFlowWidgetRequest
-	27, // 18: grpc.Lineblocs.channel_startFlow:input_type -> grpc.ChannelStartFlowWidgetRequest
-	23, // 19: grpc.Lineblocs.channel_startRinging:input_type -> grpc.GenericChannelReq
-	23, // 20: grpc.Lineblocs.channel_stopRinging:input_type -> grpc.GenericChannelReq
-	23, // 21: grpc.Lineblocs.channel_record:input_type -> grpc.GenericChannelReq
-	23, // 22: grpc.Lineblocs.channel_hangup:input_type -> grpc.GenericChannelReq
-	29, // 23: grpc.Lineblocs.bridge_addChannel:input_type -> grpc.BridgeChannelRequest
-	32, // 24: grpc.Lineblocs.bridge_addChannels:input_type -> grpc.BridgeChannelsRequest
-	29, // 25: grpc.Lineblocs.bridge_removeChannel:input_type -> grpc.BridgeChannelRequest
-	33, // 26: grpc.Lineblocs.bridge_playTTS:input_type -> grpc.BridgeTTSRequest
-	37, // 27: grpc.Lineblocs.bridge_automateLegAHangup:input_type -> grpc.BridgeAutomateLegRequest
-	37, // 28: grpc.Lineblocs.bridge_automateLegBHangup:input_type -> grpc.BridgeAutomateLegRequest
-	29, // 29: grpc.Lineblocs.bridge_hangupChannel:input_type -> grpc.BridgeChannelRequest
-	34, // 30: grpc.Lineblocs.bridge_hangupAllChannels:input_type -> grpc.GenericBridgeReq
-	34, // 31: grpc.Lineblocs.bridge_getChannels:input_type -> grpc.GenericBridgeReq
-	34, // 32: grpc.Lineblocs.bridge_destroy:input_type -> grpc.GenericBridgeReq
-	34, // 33: grpc.Lineblocs.bridge_record:input_type -> grpc.GenericBridgeReq
-	39, // 34: grpc.Lineblocs.bridge_attachEventListener:input_type -> grpc.BridgeEventRequest
-	51, // 35: grpc.Lineblocs.conference_addWaitingParticipant:input_type -> grpc.ConferenceParticipantRequest
-	51, // 36: grpc.Lineblocs.conference_addParticipant:input_type -> grpc.ConferenceParticipantRequest
-	53, // 37: grpc.Lineblocs.conference_setModeratorInConf:input_type -> grpc.ConferenceModeratorRequest
-	55, // 38: grpc.Lineblocs.conference_attachEventListener:input_type -> grpc.ConferenceEventRequest
-	57, // 39: grpc.Lineblocs.recording_stop:input_type -> grpc.RecordingRequest
-	1,  // 40: grpc.Lineblocs.createBridge:output_type -> grpc.BridgeReply
-	3,  // 41: grpc.Lineblocs.createCall:output_type -> grpc.CallReply
-	5,  // 42: grpc.Lineblocs.addChannel:output_type -> grpc.ChannelReply
-	7,  // 43: grpc.Lineblocs.playRecording:output_type -> grpc.RecordingPlayReply
-	10, // 44: grpc.Lineblocs.getChannel:output_type -> grpc.ChannelFetchReply
-	12, // 45: grpc.Lineblocs.createConference:output_type -> grpc.ConferenceReply
-	14, // 46: grpc.Lineblocs.channel_getBridge:output_type -> grpc.ChannelGetBridgeReply
-	16, // 47: grpc.Lineblocs.channel_removeFromBridge:output_type -> grpc.ChannelRemoveBridgeReply
-	18, // 48: grpc.Lineblocs.channel_playTTS:output_type -> grpc.ChannelTTSReply
-	20, // 49: grpc.Lineblocs.channel_startAcceptingInput:output_type -> grpc.ChannelInputReply
-	22, // 50: grpc.Lineblocs.channel_removeDTMFListeners:output_type -> grpc.ChannelRemoveDTMFReply
-	24, // 51: grpc.Lineblocs.channel_automateCallHangup:output_type -> grpc.GenericChannelResp
-	26, // 52: grpc.Lineblocs.channel_gotoFlowWidget:output_type -> grpc.ChannelFlowWidgetReply
-	28, // 53: grpc.Lineblocs.channel_startFlow:output_type -> grpc.ChannelStartFlowWidgetReply
-	24, // 54: grpc.Lineblocs.channel_startRinging:output_type -> grpc.GenericChannelResp
-	24, // 55: grpc.Lineblocs.channel_stopRinging:output_type -> grpc.GenericChannelResp
-	24, // 56: grpc.Lineblocs.channel_record:output_type -> grpc.GenericChannelResp
-	24, // 57: grpc.Lineblocs.channel_hangup:output_type -> grpc.GenericChannelResp
-	30, // 58: grpc.Lineblocs.bridge_addChannel:output_type -> grpc.BridgeChannelReply
-	31, // 59: grpc.Lineblocs.bridge_addChannels:output_type -> grpc.BridgeChannelsReply
-	30, // 60: grpc.Lineblocs.bridge_removeChannel:output_type -> grpc.BridgeChannelReply
-	36, // 61: grpc.Lineblocs.bridge_playTTS:output_type -> grpc.BridgeTTSReply
-	38, // 62: grpc.Lineblocs.bridge_automateLegAHangup:output_type -> grpc.BridgeAutomateLegReply
-	38, // 63: grpc.Lineblocs.bridge_automateLegBHangup:output_type -> grpc.BridgeAutomateLegReply
-	35, // 64: grpc.Lineblocs.bridge_hangupChannel:output_type -> grpc.GenericBridgeResp
-	35, // 65: grpc.Lineblocs.bridge_hangupAllChannels:output_type -> grpc.GenericBridgeResp
-	31, // 66: grpc.Lineblocs.bridge_getChannels:output_type -> grpc.BridgeChannelsReply
-	35, // 67: grpc.Lineblocs.bridge_destroy:output_type -> grpc.GenericBridgeResp
-	35, // 68: grpc.Lineblocs.bridge_record:output_type -> grpc.GenericBridgeResp
-	40, // 69: grpc.Lineblocs.bridge_attachEventListener:output_type -> grpc.BridgeEventReply
-	52, // 70: grpc.Lineblocs.conference_addWaitingParticipant:output_type -> grpc.ConferenceParticipantReply
-	52, // 71: grpc.Lineblocs.conference_addParticipant:output_type -> grpc.ConferenceParticipantReply
-	54, // 72: grpc.Lineblocs.conference_setModeratorInConf:output_type -> grpc.ConferenceModeratorReply
-	56, // 73: grpc.Lineblocs.conference_attachEventListener:output_type -> grpc.ConferenceEventReply
-	58, // 74: grpc.Lineblocs.recording_stop:output_type -> grpc.RecordingReply
-	40, // [40:75] is the sub-list for method output_type
-	5,  // [5:40] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	70, // 5: grpc.FlowTraceEntry.vars:type_name -> grpc.FlowTraceEntry.VarsEntry
+	60, // 6: grpc.FlowTraceReply.entries:type_name -> grpc.FlowTraceEntry
+	71, // 7: grpc.CallerMemoryUpdateRequest.values:type_name -> grpc.CallerMemoryUpdateRequest.ValuesEntry
+	72, // 8: grpc.CallerMemoryReply.values:type_name -> grpc.CallerMemoryReply.ValuesEntry
+	0,  // 9: grpc.Lineblocs.createBridge:input_type -> grpc.BridgeRequest
+	2,  // 10: grpc.Lineblocs.createCall:input_type -> grpc.CallRequest
+	4,  // 11: grpc.Lineblocs.addChannel:input_type -> grpc.ChannelRequest
+	6,  // 12: grpc.Lineblocs.playRecording:input_type -> grpc.RecordingPlayRequest
+	9,  // 13: grpc.Lineblocs.getChannel:input_type -> grpc.ChannelFetchRequest
+	11, // 14: grpc.Lineblocs.createConference:input_type -> grpc.ConferenceRequest
+	59, // 15: grpc.Lineblocs.getFlowTrace:input_type -> grpc.FlowTraceRequest
+	62, // 16: grpc.Lineblocs.getCallerMemory:input_type -> grpc.CallerMemoryRequest
+	63, // 17: grpc.Lineblocs.updateCallerMemory:input_type -> grpc.CallerMemoryUpdateRequest
+	64, // 18: grpc.Lineblocs.deleteCallerMemory:input_type -> grpc.CallerMemoryDeleteRequest
+	66, // 19: grpc.Lineblocs.getTimeOverride:input_type -> grpc.TimeOverrideRequest
+	66, // 20: grpc.Lineblocs.setTimeOverride:input_type -> grpc.TimeOverrideRequest
+	13, // 21: grpc.Lineblocs.channel_getBridge:input_type -> grpc.ChannelGetBridgeRequest
+	15, // 22: grpc.Lineblocs.channel_removeFromBridge:input_type -> grpc.ChannelRemoveBridgeRequest
+	17, // 23: grpc.Lineblocs.channel_playTTS:input_type -> grpc.ChannelTTSRequest
+	19, // 24: grpc.Lineblocs.channel_startAcceptingInput:input_type -> grpc.ChannelInputRequest
+	21, // 25: grpc.Lineblocs.channel_removeDTMFListeners:input_type -> grpc.ChannelRemoveDTMFRequest
+	23, // 26: grpc.Lineblocs.channel_automateCallHangup:input_type -> grpc.GenericChannelReq
+	25, // 27: grpc.Lineblocs.channel_gotoFlowWidget:input_type -> grpc.ChannelFlowWidgetRequest
+	27, // 28: grpc.Lineblocs.channel_startFlow:input_type -> grpc.ChannelStartFlowWidgetRequest
+	23, // 29: grpc.Lineblocs.channel_startRinging:input_type -> grpc.GenericChannelReq
+	23, // 30: grpc.Lineblocs.channel_stopRinging:input_type -> grpc.GenericChannelReq
+	23, // 31: grpc.Lineblocs.channel_record:input_type -> grpc.GenericChannelReq
+	23, // 32: grpc.Lineblocs.channel_hangup:input_type -> grpc.GenericChannelReq
+	29, // 33: grpc.Lineblocs.bridge_addChannel:input_type -> grpc.BridgeChannelRequest
+	32, // 34: grpc.Lineblocs.bridge_addChannels:input_type -> grpc.BridgeChannelsRequest
+	29, // 35: grpc.Lineblocs.bridge_removeChannel:input_type -> grpc.BridgeChannelRequest
+	33, // 36: grpc.Lineblocs.bridge_playTTS:input_type -> grpc.BridgeTTSRequest
+	37, // 37: grpc.Lineblocs.bridge_automateLegAHangup:input_type -> grpc.BridgeAutomateLegRequest
+	37, // 38: grpc.Lineblocs.bridge_automateLegBHangup:input_type -> grpc.BridgeAutomateLegRequest
+	29, // 39: grpc.Lineblocs.bridge_hangupChannel:input_type -> grpc.BridgeChannelRequest
+	34, // 40: grpc.Lineblocs.bridge_hangupAllChannels:input_type -> grpc.GenericBridgeReq
+	34, // 41: grpc.Lineblocs.bridge_getChannels:input_type -> grpc.GenericBridgeReq
+	34, // 42: grpc.Lineblocs.bridge_destroy:input_type -> grpc.GenericBridgeReq
+	34, // 43: grpc.Lineblocs.bridge_record:input_type -> grpc.GenericBridgeReq
+	39, // 44: grpc.Lineblocs.bridge_attachEventListener:input_type -> grpc.BridgeEventRequest
+	51, // 45: grpc.Lineblocs.conference_addWaitingParticipant:input_type -> grpc.ConferenceParticipantRequest
+	51, // 46: grpc.Lineblocs.conference_addParticipant:input_type -> grpc.ConferenceParticipantRequest
+	53, // 47: grpc.Lineblocs.conference_setModeratorInConf:input_type -> grpc.ConferenceModeratorRequest
+	55, // 48: grpc.Lineblocs.conference_attachEventListener:input_type -> grpc.ConferenceEventRequest
+	57, // 49: grpc.Lineblocs.recording_stop:input_type -> grpc.RecordingRequest
+	1,  // 50: grpc.Lineblocs.createBridge:output_type -> grpc.BridgeReply
+	3,  // 51: grpc.Lineblocs.createCall:output_type -> grpc.CallReply
+	5,  // 52: grpc.Lineblocs.addChannel:output_type -> grpc.ChannelReply
+	7,  // 53: grpc.Lineblocs.playRecording:output_type -> grpc.RecordingPlayReply
+	10, // 54: grpc.Lineblocs.getChannel:output_type -> grpc.ChannelFetchReply
+	12, // 55: grpc.Lineblocs.createConference:output_type -> grpc.ConferenceReply
+	61, // 56: grpc.Lineblocs.getFlowTrace:output_type -> grpc.FlowTraceReply
+	65, // 57: grpc.Lineblocs.getCallerMemory:output_type -> grpc.CallerMemoryReply
+	65, // 58: grpc.Lineblocs.updateCallerMemory:output_type -> grpc.CallerMemoryReply
+	65, // 59: grpc.Lineblocs.deleteCallerMemory:output_type -> grpc.CallerMemoryReply
+	67, // 60: grpc.Lineblocs.getTimeOverride:output_type -> grpc.TimeOverrideReply
+	67, // 61: grpc.Lineblocs.setTimeOverride:output_type -> grpc.TimeOverrideReply
+	14, // 62: grpc.Lineblocs.channel_getBridge:output_type -> grpc.ChannelGetBridgeReply
+	16, // 63: grpc.Lineblocs.channel_removeFromBridge:output_type -> grpc.ChannelRemoveBridgeReply
+	18, // 64: grpc.Lineblocs.channel_playTTS:output_type -> grpc.ChannelTTSReply
+	20, // 65: grpc.Lineblocs.channel_startAcceptingInput:output_type -> grpc.ChannelInputReply
+	22, // 66: grpc.Lineblocs.channel_removeDTMFListeners:output_type -> grpc.ChannelRemoveDTMFReply
+	24, // 67: grpc.Lineblocs.channel_automateCallHangup:output_type -> grpc.GenericChannelResp
+	26, // 68: grpc.Lineblocs.channel_gotoFlowWidget:output_type -> grpc.ChannelFlowWidgetReply
+	28, // 69: grpc.Lineblocs.channel_startFlow:output_type -> grpc.ChannelStartFlowWidgetReply
+	24, // 70: grpc.Lineblocs.channel_startRinging:output_type -> grpc.GenericChannelResp
+	24, // 71: grpc.Lineblocs.channel_stopRinging:output_type -> grpc.GenericChannelResp
+	24, // 72: grpc.Lineblocs.channel_record:output_type -> grpc.GenericChannelResp
+	24, // 73: grpc.Lineblocs.channel_hangup:output_type -> grpc.GenericChannelResp
+	30, // 74: grpc.Lineblocs.bridge_addChannel:output_type -> grpc.BridgeChannelReply
+	31, // 75: grpc.Lineblocs.bridge_addChannels:output_type -> grpc.BridgeChannelsReply
+	30, // 76: grpc.Lineblocs.bridge_removeChannel:output_type -> grpc.BridgeChannelReply
+	36, // 77: grpc.Lineblocs.bridge_playTTS:output_type -> grpc.BridgeTTSReply
+	38, // 78: grpc.Lineblocs.bridge_automateLegAHangup:output_type -> grpc.BridgeAutomateLegReply
+	38, // 79: grpc.Lineblocs.bridge_automateLegBHangup:output_type -> grpc.BridgeAutomateLegReply
+	35, // 80: grpc.Lineblocs.bridge_hangupChannel:output_type -> grpc.GenericBridgeResp
+	35, // 81: grpc.Lineblocs.bridge_hangupAllChannels:output_type -> grpc.GenericBridgeResp
+	31, // 82: grpc.Lineblocs.bridge_getChannels:output_type -> grpc.BridgeChannelsReply
+	35, // 83: grpc.Lineblocs.bridge_destroy:output_type -> grpc.GenericBridgeResp
+	35, // 84: grpc.Lineblocs.bridge_record:output_type -> grpc.GenericBridgeResp
+	40, // 85: grpc.Lineblocs.bridge_attachEventListener:output_type -> grpc.BridgeEventReply
+	52, // 86: grpc.Lineblocs.conference_addWaitingParticipant:output_type -> grpc.ConferenceParticipantReply
+	52, // 87: grpc.Lineblocs.conference_addParticipant:output_type -> grpc.ConferenceParticipantReply
+	54, // 88: grpc.Lineblocs.conference_setModeratorInConf:output_type -> grpc.ConferenceModeratorReply
+	56, // 89: grpc.Lineblocs.conference_attachEventListener:output_type -> grpc.ConferenceEventReply
+	58, // 90: grpc.Lineblocs.recording_stop:output_type -> grpc.RecordingReply
+	50, // [50:91] is the sub-list for method output_type
+	9,  // [9:50] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_lineblocs_proto_init() }
@@ -4216,6 +4983,114 @@ func file_lineblocs_proto_init() {
 				return nil
 			}
 		}
+		file_lineblocs_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowTraceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowTraceEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowTraceReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallerMemoryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallerMemoryUpdateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallerMemoryDeleteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallerMemoryReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TimeOverrideRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lineblocs_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TimeOverrideReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -4223,7 +5098,7 @@ func file_lineblocs_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_lineblocs_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   61,
+			NumMessages:   73,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -4256,6 +5131,12 @@ type LineblocsClient interface {
 	PlayRecording(ctx context.Context, in *RecordingPlayRequest, opts ...grpc.CallOption) (*RecordingPlayReply, error)
 	GetChannel(ctx context.Context, in *ChannelFetchRequest, opts ...grpc.CallOption) (*ChannelFetchReply, error)
 	CreateConference(ctx context.Context, in *ConferenceRequest, opts ...grpc.CallOption) (*ConferenceReply, error)
+	GetFlowTrace(ctx context.Context, in *FlowTraceRequest, opts ...grpc.CallOption) (*FlowTraceReply, error)
+	GetCallerMemory(ctx context.Context, in *CallerMemoryRequest, opts ...grpc.CallOption) (*CallerMemoryReply, error)
+	UpdateCallerMemory(ctx context.Context, in *CallerMemoryUpdateRequest, opts ...grpc.CallOption) (*CallerMemoryReply, error)
+	DeleteCallerMemory(ctx context.Context, in *CallerMemoryDeleteRequest, opts ...grpc.CallOption) (*CallerMemoryReply, error)
+	GetTimeOverride(ctx context.Context, in *TimeOverrideRequest, opts ...grpc.CallOption) (*TimeOverrideReply, error)
+	SetTimeOverride(ctx context.Context, in *TimeOverrideRequest, opts ...grpc.CallOption) (*TimeOverrideReply, error)
 	// channel functions
 	ChannelGetBridge(ctx context.Context, in *ChannelGetBridgeRequest, opts ...grpc.CallOption) (*ChannelGetBridgeReply, error)
 	ChannelRemoveFromBridge(ctx context.Context, in *ChannelRemoveBridgeRequest, opts ...grpc.CallOption) (*ChannelRemoveBridgeReply, error)
@@ -4353,6 +5234,60 @@ func (c *lineblocsClient) CreateConference(ctx context.Context, in *ConferenceRe
 	return out, nil
 }
 
+func (c *lineblocsClient) GetFlowTrace(ctx context.Context, in *FlowTraceRequest, opts ...grpc.CallOption) (*FlowTraceReply, error) {
+	out := new(FlowTraceReply)
+	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/getFlowTrace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lineblocsClient) GetCallerMemory(ctx context.Context, in *CallerMemoryRequest, opts ...grpc.CallOption) (*CallerMemoryReply, error) {
+	out := new(CallerMemoryReply)
+	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/getCallerMemory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lineblocsClient) UpdateCallerMemory(ctx context.Context, in *CallerMemoryUpdateRequest, opts ...grpc.CallOption) (*CallerMemoryReply, error) {
+	out := new(CallerMemoryReply)
+	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/updateCallerMemory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lineblocsClient) DeleteCallerMemory(ctx context.Context, in *CallerMemoryDeleteRequest, opts ...grpc.CallOption) (*CallerMemoryReply, error) {
+	out := new(CallerMemoryReply)
+	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/deleteCallerMemory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lineblocsClient) GetTimeOverride(ctx context.Context, in *TimeOverrideRequest, opts ...grpc.CallOption) (*TimeOverrideReply, error) {
+	out := new(TimeOverrideReply)
+	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/getTimeOverride", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lineblocsClient) SetTimeOverride(ctx context.Context, in *TimeOverrideRequest, opts ...grpc.CallOption) (*TimeOverrideReply, error) {
+	out := new(TimeOverrideReply)
+	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/setTimeOverride", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lineblocsClient) ChannelGetBridge(ctx context.Context, in *ChannelGetBridgeRequest, opts ...grpc.CallOption) (*ChannelGetBridgeReply, error) {
 	out := new(ChannelGetBridgeReply)
 	err := c.cc.Invoke(ctx, "/grpc.Lineblocs/channel_getBridge", in, out, opts...)
@@ -4623,6 +5558,12 @@ type LineblocsServer interface {
 	PlayRecording(context.Context, *RecordingPlayRequest) (*RecordingPlayReply, error)
 	GetChannel(context.Context, *ChannelFetchRequest) (*ChannelFetchReply, error)
 	CreateConference(context.Context, *ConferenceRequest) (*ConferenceReply, error)
+	GetFlowTrace(context.Context, *FlowTraceRequest) (*FlowTraceReply, error)
+	GetCallerMemory(context.Context, *CallerMemoryRequest) (*CallerMemoryReply, error)
+	UpdateCallerMemory(context.Context, *CallerMemoryUpdateRequest) (*CallerMemoryReply, error)
+	DeleteCallerMemory(context.Context, *CallerMemoryDeleteRequest) (*CallerMemoryReply, error)
+	GetTimeOverride(context.Context, *TimeOverrideRequest) (*TimeOverrideReply, error)
+	SetTimeOverride(context.Context, *TimeOverrideRequest) (*TimeOverrideReply, error)
 	// channel functions
 	ChannelGetBridge(context.Context, *ChannelGetBridgeRequest) (*ChannelGetBridgeReply, error)
 	ChannelRemoveFromBridge(context.Context, *ChannelRemoveBridgeRequest) (*ChannelRemoveBridgeReply, error)
@@ -4680,6 +5621,24 @@ func (*UnimplementedLineblocsServer) GetChannel(context.Context, *ChannelFetchRe
 func (*UnimplementedLineblocsServer) CreateConference(context.Context, *ConferenceRequest) (*ConferenceReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateConference not implemented")
 }
+func (*UnimplementedLineblocsServer) GetFlowTrace(context.Context, *FlowTraceRequest) (*FlowTraceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlowTrace not implemented")
+}
+func (*UnimplementedLineblocsServer) GetCallerMemory(context.Context, *CallerMemoryRequest) (*CallerMemoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCallerMemory not implemented")
+}
+func (*UnimplementedLineblocsServer) UpdateCallerMemory(context.Context, *CallerMemoryUpdateRequest) (*CallerMemoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCallerMemory not implemented")
+}
+func (*UnimplementedLineblocsServer) DeleteCallerMemory(context.Context, *CallerMemoryDeleteRequest) (*CallerMemoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCallerMemory not implemented")
+}
+func (*UnimplementedLineblocsServer) GetTimeOverride(context.Context, *TimeOverrideRequest) (*TimeOverrideReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTimeOverride not implemented")
+}
+func (*UnimplementedLineblocsServer) SetTimeOverride(context.Context, *TimeOverrideRequest) (*TimeOverrideReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTimeOverride not implemented")
+}
 func (*UnimplementedLineblocsServer) ChannelGetBridge(context.Context, *ChannelGetBridgeRequest) (*ChannelGetBridgeReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ChannelGetBridge not implemented")
 }
@@ -4880,6 +5839,114 @@ func _Lineblocs_CreateConference_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Lineblocs_GetFlowTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlowTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LineblocsServer).GetFlowTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Lineblocs/GetFlowTrace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LineblocsServer).GetFlowTrace(ctx, req.(*FlowTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lineblocs_GetCallerMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallerMemoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LineblocsServer).GetCallerMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Lineblocs/GetCallerMemory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LineblocsServer).GetCallerMemory(ctx, req.(*CallerMemoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lineblocs_UpdateCallerMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallerMemoryUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LineblocsServer).UpdateCallerMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Lineblocs/UpdateCallerMemory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LineblocsServer).UpdateCallerMemory(ctx, req.(*CallerMemoryUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lineblocs_DeleteCallerMemory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallerMemoryDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LineblocsServer).DeleteCallerMemory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Lineblocs/DeleteCallerMemory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LineblocsServer).DeleteCallerMemory(ctx, req.(*CallerMemoryDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lineblocs_GetTimeOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeOverrideRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LineblocsServer).GetTimeOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Lineblocs/GetTimeOverride",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LineblocsServer).GetTimeOverride(ctx, req.(*TimeOverrideRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lineblocs_SetTimeOverride_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeOverrideRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LineblocsServer).SetTimeOverride(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc.Lineblocs/SetTimeOverride",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LineblocsServer).SetTimeOverride(ctx, req.(*TimeOverrideRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Lineblocs_ChannelGetBridge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ChannelGetBridgeRequest)
 	if err := dec(in); err != nil {
@@ -5430,6 +6497,30 @@ var _Lineblocs_serviceDesc = grpc.ServiceDesc{
 			MethodName: "createConference",
 			Handler:    _Lineblocs_CreateConference_Handler,
 		},
+		{
+			MethodName: "getFlowTrace",
+			Handler:    _Lineblocs_GetFlowTrace_Handler,
+		},
+		{
+			MethodName: "getCallerMemory",
+			Handler:    _Lineblocs_GetCallerMemory_Handler,
+		},
+		{
+			MethodName: "updateCallerMemory",
+			Handler:    _Lineblocs_UpdateCallerMemory_Handler,
+		},
+		{
+			MethodName: "deleteCallerMemory",
+			Handler:    _Lineblocs_DeleteCallerMemory_Handler,
+		},
+		{
+			MethodName: "getTimeOverride",
+			Handler:    _Lineblocs_GetTimeOverride_Handler,
+		},
+		{
+			MethodName: "setTimeOverride",
+			Handler:    _Lineblocs_SetTimeOverride_Handler,
+		},
 		{
 			MethodName: "channel_getBridge",
 			Handler:    _Lineblocs_ChannelGetBridge_Handler,
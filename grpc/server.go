@@ -551,8 +551,131 @@ func (s *Server) ChannelRemoveDTMFListeners(ctx context.Context, req *ChannelRem
 func (*Server) ChannelAutomateCallHangup(context.Context, *GenericChannelReq) (*GenericChannelResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ChannelAutomateCallHangup not implemented")
 }
-func (*Server) ChannelGotoFlowWidget(context.Context, *ChannelFlowWidgetRequest) (*ChannelFlowWidgetReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ChannelGotoFlowWidget not implemented")
+func (s *Server) ChannelGotoFlowWidget(ctx context.Context, req *ChannelFlowWidgetRequest) (*ChannelFlowWidgetReply, error) {
+	if err := mngrs.GotoCell(req.ChannelId, req.Widget, req.EventVars); err != nil {
+		return nil, status.Errorf(codes.NotFound, "could not move channel %s to %s: %s", req.ChannelId, req.Widget, err.Error())
+	}
+	return &ChannelFlowWidgetReply{}, nil
+}
+
+func (s *Server) GetFlowTrace(ctx context.Context, req *FlowTraceRequest) (*FlowTraceReply, error) {
+	trace, err := mngrs.LookupTrace(req.CallId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "no flow trace for call %s: %s", req.CallId, err.Error())
+	}
+	resp := FlowTraceReply{
+		CallId:    trace.CallId,
+		ChannelId: trace.ChannelId,
+		FlowId:    int32(trace.FlowId),
+		StartedAt: trace.StartedAt.UnixMilli(),
+		Entries:   make([]*FlowTraceEntry, 0, len(trace.Entries))}
+	if !trace.EndedAt.IsZero() {
+		resp.EndedAt = trace.EndedAt.UnixMilli()
+	}
+	for _, entry := range trace.Entries {
+		item := FlowTraceEntry{
+			CellId:     entry.CellId,
+			CellName:   entry.CellName,
+			CellType:   entry.CellType,
+			EnteredAt:  entry.EnteredAt.UnixMilli(),
+			DurationMs: entry.DurationMs,
+			Port:       entry.Port,
+			NextCell:   entry.NextCell,
+			Vars:       entry.Vars,
+			Error:      entry.Error}
+		if !entry.ExitedAt.IsZero() {
+			item.ExitedAt = entry.ExitedAt.UnixMilli()
+		}
+		resp.Entries = append(resp.Entries, &item)
+	}
+	return &resp, nil
+}
+
+func (s *Server) callerMemoryReply(workspaceId int32, key string) (*CallerMemoryReply, error) {
+	values, err := mngrs.GetCallerMemory(int(workspaceId), key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not read caller memory: %s", err.Error())
+	}
+	return &CallerMemoryReply{Key: key, Values: values}, nil
+}
+
+// GetCallerMemory returns the values flows remember about a caller.
+func (s *Server) GetCallerMemory(ctx context.Context, req *CallerMemoryRequest) (*CallerMemoryReply, error) {
+	if req.Key == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "key is required")
+	}
+	return s.callerMemoryReply(req.WorkspaceId, req.Key)
+}
+
+// UpdateCallerMemory seeds values for a caller before they call, e.g. from a
+// CRM.
+func (s *Server) UpdateCallerMemory(ctx context.Context, req *CallerMemoryUpdateRequest) (*CallerMemoryReply, error) {
+	if req.Key == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "key is required")
+	}
+	if req.TtlSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "ttl_seconds can not be negative")
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if err := mngrs.UpdateCallerMemory(int(req.WorkspaceId), req.Key, req.Values, ttl); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not update caller memory: %s", err.Error())
+	}
+	return s.callerMemoryReply(req.WorkspaceId, req.Key)
+}
+
+// DeleteCallerMemory forgets values of a caller, or all of them when no names
+// are given.
+func (s *Server) DeleteCallerMemory(ctx context.Context, req *CallerMemoryDeleteRequest) (*CallerMemoryReply, error) {
+	if req.Key == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "key is required")
+	}
+	if err := mngrs.DeleteCallerMemory(int(req.WorkspaceId), req.Key, req.Names); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not delete caller memory: %s", err.Error())
+	}
+	return s.callerMemoryReply(req.WorkspaceId, req.Key)
+}
+
+// GetTimeOverride returns whether the time conditions following an override
+// are forced open or closed.
+func (s *Server) GetTimeOverride(ctx context.Context, req *TimeOverrideRequest) (*TimeOverrideReply, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+	mode, err := mngrs.GetTimeOverride(int(req.WorkspaceId), req.Name)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not read time override: %s", err.Error())
+	}
+	return &TimeOverrideReply{Name: req.Name, Mode: mode}, nil
+}
+
+// SetTimeOverride forces the time conditions following an override open or
+// closed, e.g. on a snow day. An empty mode goes back to their schedules.
+func (s *Server) SetTimeOverride(ctx context.Context, req *TimeOverrideRequest) (*TimeOverrideReply, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+	if req.Mode != "" && req.Mode != mngrs.TIME_OVERRIDE_OPEN && req.Mode != mngrs.TIME_OVERRIDE_CLOSED {
+		return nil, status.Errorf(codes.InvalidArgument, "mode must be open, closed or empty")
+	}
+	if req.TtlSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "ttl_seconds can not be negative")
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if err := mngrs.SetTimeOverride(int(req.WorkspaceId), req.Name, req.Mode, ttl); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not set time override: %s", err.Error())
+	}
+	return &TimeOverrideReply{Name: req.Name, Mode: req.Mode}, nil
+}
+
+// endFlowOnHangup ends a flow started over gRPC once its channel leaves the
+// application.
+func (s *Server) endFlowOnHangup(flow *types.Flow, channel *types.LineChannel) {
+	endSub := channel.Channel.Subscribe(ari.Events.StasisEnd)
+	defer endSub.Cancel()
+	<-endSub.Events()
+	if err := mngrs.EndFlow(flow); err != nil {
+		fmt.Println("could not save flow trace: " + err.Error())
+	}
 }
 
 func (s *Server) ChannelStartFlow(ctx context.Context, req *ChannelStartFlowWidgetRequest) (*ChannelStartFlowWidgetReply, error) {
@@ -577,35 +700,39 @@ func (s *Server) ChannelStartFlow(ctx context.Context, req *ChannelStartFlowWidg
 		return nil, err
 	}
 	info := flowData.Vars
-	//user := types.NewUser(data.CreatorId, data.WorkspaceId, data.WorkspaceName)
-
-	vals := make(map[string]string)
-	vals["workspace"] = workspaceId
-	body, err := api.SendGetRequest("/user/getWorkspaceMacros", vals)
-
+	userIdInt, err := strconv.Atoi(userId)
 	if err != nil {
 		fmt.Println("startExecution err " + err.Error())
 		return nil, err
 	}
-	var macros []*types.WorkspaceMacro
-	err = json.Unmarshal([]byte(body), &macros)
+	workspace, err := strconv.Atoi(workspaceId)
 	if err != nil {
 		fmt.Println("startExecution err " + err.Error())
 		return nil, err
 	}
+	user := types.NewUser(userIdInt, workspace, workspaceName)
+
+	if err := mngrs.ValidateFlow(info).Err(); err != nil {
+		fmt.Println("error starting new flow: " + err.Error())
+		go mngrs.RouteToFallback(s.Client, user, channel, "", err.Error())
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	vals := make(map[string]string)
+	vals["workspace"] = workspaceId
+	body, err := api.SendGetRequest("/user/getWorkspaceMacros", vals)
 
-	userIdInt, err := strconv.Atoi(userId)
 	if err != nil {
 		fmt.Println("startExecution err " + err.Error())
 		return nil, err
 	}
-	workspace, err := strconv.Atoi(workspaceId)
+	var macros []*types.WorkspaceMacro
+	err = json.Unmarshal([]byte(body), &macros)
 	if err != nil {
 		fmt.Println("startExecution err " + err.Error())
 		return nil, err
 	}
 
-	user := types.NewUser(userIdInt, workspace, workspaceName)
 	flow := types.NewFlow(
 		flowData.FlowId,
 		user,
@@ -615,7 +742,9 @@ func (s *Server) ChannelStartFlow(ctx context.Context, req *ChannelStartFlowWidg
 		s.Client)
 
 	vars := make(map[string]string)
-	flowCtx, _ := context.WithCancel(context.Background())
+	// the request context ends with the request, the flow ends with the call
+	flowCtx := mngrs.NewCallContext(context.Background(), channel)
+	go s.endFlowOnHangup(flow, channel)
 	go mngrs.ProcessFlow(s.Client, flowCtx, flow, channel, vars, flow.Cells[0])
 	resp := ChannelStartFlowWidgetReply{}
 	return &resp, nil
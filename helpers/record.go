@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/CyCoreSystems/ari/v5"
 	"github.com/google/uuid"
@@ -20,8 +21,15 @@ type Record struct {
 	Handle  *ari.LiveRecordingHandle
 	Trim    bool
 	Ctx     context.Context
+	// Tag and MailboxId are sent along when the recording is created, e.g.
+	// "voicemail" and the mailbox a message was left in.
+	Tag       string
+	MailboxId string
 }
 
+// VOICEMAIL_TAG is the tag of recordings of voicemail messages.
+const VOICEMAIL_TAG = "voicemail"
+
 type RecordingParams struct {
 	UserId          int    `json:"user_id"`
 	CallId          *int   `json:"call_id"`
@@ -31,6 +39,8 @@ type RecordingParams struct {
 	StorageId       string `json:"storage_id"`
 	StorageServerIp string `json:"storage_server_ip"`
 	Trim            bool   `json:"trim"`
+	MailboxId       string `json:"mailbox_id,omitempty"`
+	Duration        int    `json:"duration,omitempty"`
 }
 
 func NewRecording(ctx context.Context, user *types.User, callId *int, trim bool) *Record {
@@ -42,9 +52,19 @@ func NewRecording(ctx context.Context, user *types.User, callId *int, trim bool)
 	}
 }
 
+// NewVoicemail creates the recording of a message left in a mailbox.
+func NewVoicemail(ctx context.Context, user *types.User, callId *int, mailboxId string, trim bool) *Record {
+	return &Record{
+		User:      user,
+		CallId:    callId,
+		Trim:      trim,
+		Ctx:       ctx,
+		Tag:       VOICEMAIL_TAG,
+		MailboxId: mailboxId,
+	}
+}
+
 func (r *Record) createAPIResource() (string, error) {
-	user := r.User
-	callId := r.CallId
 	uniq, err := uuid.NewUUID()
 	if err != nil {
 		fmt.Printf("recording fail to create UUID. err: %s\r\n", err.Error())
@@ -52,15 +72,27 @@ func (r *Record) createAPIResource() (string, error) {
 	}
 
 	id := uniq.String()
+	if _, err := r.saveAPIResource(id, "started", 0); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// saveAPIResource creates the recording with the storage id in the API and
+// returns the id the API gave it.
+func (r *Record) saveAPIResource(id string, status string, duration time.Duration) (string, error) {
+	user := r.User
 	params := RecordingParams{
 		UserId:          user.Id,
-		CallId:          callId,
-		Tag:             "",
-		Status:          "started",
+		CallId:          r.CallId,
+		Tag:             r.Tag,
+		Status:          status,
 		WorkspaceId:     user.Workspace.Id,
 		Trim:            r.Trim,
 		StorageId:       id,
-		StorageServerIp: utils.GetARIHost()}
+		StorageServerIp: utils.GetARIHost(),
+		MailboxId:       r.MailboxId,
+		Duration:        int(duration / time.Second)}
 
 	body, err := json.Marshal(params)
 	if err != nil {
@@ -74,8 +106,7 @@ func (r *Record) createAPIResource() (string, error) {
 		fmt.Printf("error occurred: %s\r\n", err.Error())
 		return "", err
 	}
-	_ = resp.Headers.Get("x-recording-id")
-	return id, nil
+	return resp.Headers.Get("x-recording-id"), nil
 }
 
 func (r *Record) InitiateRecordingForBridge(bridge *types.LineBridge) (string, error) {
@@ -124,6 +155,32 @@ func (r *Record) InitiateRecordingForChannel(channel *types.LineChannel) (string
 	return id, nil
 }
 
+// RecordChannel starts recording the channel with the options. Unlike
+// InitiateRecordingForChannel the recording is only created in the API by
+// Save, once it finished and is worth keeping.
+func (r *Record) RecordChannel(channel *types.LineChannel, opts *ari.RecordingOptions) (string, error) {
+	r.Channel = channel
+	uniq, err := uuid.NewUUID()
+	if err != nil {
+		fmt.Printf("recording fail to create UUID. err: %s\r\n", err.Error())
+		return "", err
+	}
+
+	id := uniq.String()
+	hndl, err := channel.Channel.Record(id, opts)
+	if err != nil {
+		fmt.Printf("failed to record. err: %s\r\n", err.Error())
+		return "", err
+	}
+	r.Handle = hndl
+	return id, nil
+}
+
+// Save creates the finished recording in the API and returns its id.
+func (r *Record) Save(id string, duration time.Duration) (string, error) {
+	return r.saveAPIResource(id, "completed", duration)
+}
+
 func (r *Record) Stop() {
 	r.Handle.Stop()
 }
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +16,56 @@ type Config struct {
 	Username    string
 	Password    string
 	Application string
+	// FallbackSound is played to callers whose flow cannot be executed.
+	FallbackSound string
+	// FallbackNumber, when set, receives calls whose flow cannot be executed
+	// instead of hanging up after the fallback announcement.
+	FallbackNumber string
+	// TraceStore is where flow traces are saved when calls end, either
+	// "api", "file" or "redis".
+	TraceStore string
+	// TraceTTL is how long the "redis" trace store keeps traces.
+	TraceTTL time.Duration
+	// TraceFile is the JSONL file used by the "file" trace store, which is
+	// rotated once it reaches TraceFileMaxSize bytes.
+	TraceFile        string
+	TraceFileMaxSize int64
+	// MaxSubflowDepth is how deep Subflow cells can be nested, which stops
+	// subflows that call themselves.
+	MaxSubflowDepth int
+	// MaxHops, MaxDuration and MaxCellVisits stop flows that loop: the number
+	// of cells a call can execute, how long the flow of a call can run and how
+	// many times a call can enter the same cell. Zero disables a limit.
+	MaxHops       int
+	MaxDuration   time.Duration
+	MaxCellVisits int
+	// LimitSound is played before hanging up a call that exceeded a limit.
+	LimitSound string
+	// CellTimeout is how long a cell can run before it fails, unless its cell
+	// type sets its own timeout. Zero disables it.
+	CellTimeout time.Duration
+	// Checkpoints saves the position of every call in Redis so that another
+	// instance can resume the call when this one goes away.
+	Checkpoints bool
+	// InstanceId identifies this instance in checkpoints.
+	InstanceId string
+	// CallerMemoryTTL is how long the values a flow remembers about a caller
+	// are kept after they were last written.
+	CallerMemoryTTL time.Duration
+	// LookupTableTTL is how long lookup tables are cached before they are
+	// loaded again.
+	LookupTableTTL time.Duration
+	// QueuePollInterval is how often callers waiting in a queue check for
+	// their turn and for idle agents.
+	QueuePollInterval time.Duration
+	// RedisAddr, RedisPassword and RedisDB are the Redis server used for
+	// conferences, queues, caller memory and checkpoints.
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	// MetricsAddr is where the counters of the processor are served under
+	// /debug/vars. Empty turns the listener off.
+	MetricsAddr string
 }
 
 func NewConfig() *Config {
@@ -30,13 +82,90 @@ func NewConfig() *Config {
 		Username:    os.Getenv("ARI_USERNAME"),
 		Password:    os.Getenv("ARI_PASSWORD"),
 		Application: "lineblocs",
+
+		FallbackSound:  getEnvOrDefault("FLOW_FALLBACK_SOUND", "sound:an-error-has-occured"),
+		FallbackNumber: os.Getenv("FLOW_FALLBACK_NUMBER"),
+
+		TraceStore:       getEnvOrDefault("FLOW_TRACE_STORE", "api"),
+		TraceTTL:         getEnvDurationOrDefault("FLOW_TRACE_TTL", 7*24*time.Hour),
+		TraceFile:        getEnvOrDefault("FLOW_TRACE_FILE", filepath.Join(os.TempDir(), "lineblocs_flow_traces.jsonl")),
+		TraceFileMaxSize: int64(getEnvIntOrDefault("FLOW_TRACE_FILE_MAX_SIZE", 100*1024*1024)),
+
+		MaxSubflowDepth: getEnvIntOrDefault("FLOW_MAX_SUBFLOW_DEPTH", 5),
+
+		MaxHops:       getEnvIntOrDefault("FLOW_MAX_HOPS", 1000),
+		MaxDuration:   getEnvDurationOrDefault("FLOW_MAX_DURATION", 4*time.Hour),
+		MaxCellVisits: getEnvIntOrDefault("FLOW_MAX_CELL_VISITS", 100),
+		LimitSound:    getEnvOrDefault("FLOW_LIMIT_SOUND", "sound:an-error-has-occured"),
+		CellTimeout:   getEnvDurationOrDefault("FLOW_CELL_TIMEOUT", 10*time.Minute),
+
+		Checkpoints: getEnvBool("FLOW_CHECKPOINTS"),
+		InstanceId:  getEnvOrDefault("PROCESSOR_INSTANCE_ID", hostname()),
+
+		CallerMemoryTTL: getEnvDurationOrDefault("CALLER_MEMORY_TTL", 90*24*time.Hour),
+
+		LookupTableTTL: getEnvDurationOrDefault("LOOKUP_TABLE_TTL", 5*time.Minute),
+
+		QueuePollInterval: getEnvDurationOrDefault("QUEUE_POLL_INTERVAL", time.Second),
+
+		RedisAddr:     getEnvOrDefault("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+		RedisDB:       getEnvIntOrDefault("REDIS_DB", 0),
+
+		MetricsAddr: getEnvOrUnset("METRICS_ADDR", ":9101"),
 	}
 }
 
-func useProxy() bool {
-	useProxy, err := strconv.ParseBool(os.Getenv("ARI_USE_PROXY"))
+func getEnvOrDefault(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvOrUnset returns the default only when the variable is not set, so
+// that it can be set to an empty value.
+func getEnvOrUnset(key string, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
 	if err != nil {
 		return false
 	}
-	return useProxy
+	return value
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "processor"
+	}
+	return name
+}
+
+func useProxy() bool {
+	return getEnvBool("ARI_USE_PROXY")
 }
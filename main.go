@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"expvar"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
@@ -33,6 +35,9 @@ type bridgeManager struct {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		os.Exit(runSimulate(os.Args[2:]))
+	}
 
 	if err := zaplog.InitGlobalLogger(zap.NewProductionConfig()); err != nil {
 		panic(err.Error())
@@ -56,6 +61,17 @@ func main() {
 	// Start the GRPC listener in a goroutine
 	go grpc.StartListener(cl)
 
+	// Serve the counters of expvar, e.g. recovered panics
+	go serveMetrics(cfg.MetricsAddr)
+
+	// Continue the calls of instances that went away
+	go mngrs.StartCheckpointHeartbeat(ctx)
+	go mngrs.WatchOrphanedFlows(cl, ctx, func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel) {
+		callChannel := make(chan *types.Call, 1)
+		callChannel <- flow.RootCall
+		go attachChannelLifeCycleListeners(flow, lineChannel, ctx, callCtx, callChannel)
+	})
+
 	// Log the startup messages
 	zaplog.InfoWithContext(context.Background(), "Connected to ARI")
 	zaplog.InfoWithContext(context.Background(), "Starting listener app")
@@ -103,27 +119,42 @@ func createARIConnection(ctx context.Context, cfg *config.Config) (ari.Client, e
 	})
 }
 
+func serveMetrics(addr string) {
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		helpers.Log(logrus.ErrorLevel, "metrics listener stopped: "+err.Error())
+	}
+}
+
 func createCall() (types.Call, error) {
 	return types.Call{}, nil
 }
 func createCallDebit(user *types.User, call *types.Call, direction string) error {
 	return nil
 }
-func attachChannelLifeCycleListeners(flow *types.Flow, channel *types.LineChannel, ctx context.Context, callChannel chan *types.Call) {
-
-	endSub := channel.Channel.Subscribe(ari.Events.StasisEnd)
-	defer endSub.Cancel()
 
+// attachChannelLifeCycleListeners ends the call once its context is done
+// because the caller hung up. When the processor shuts down instead, the call
+// is left for another instance to resume.
+func attachChannelLifeCycleListeners(flow *types.Flow, channel *types.LineChannel, ctx context.Context, callCtx context.Context, callChannel chan *types.Call) {
 	call := &types.Call{}
 
 	for {
 
 		select {
-		case <-ctx.Done():
-			return
-		case <-endSub.Events():
+		case <-callCtx.Done():
+			if ctx.Err() != nil {
+				return
+			}
 			zaplog.DebugWithContext(ctx, "received stasis end event")
 			call.Ended = time.Now()
+			if err := mngrs.EndFlow(flow); err != nil {
+				zaplog.ErrorWithContext(ctx, "could not save flow trace: "+err.Error())
+			}
 			body, err := json.Marshal(types.StatusParams{
 				CallId: call.CallId,
 				Ip:     utils.GetPublicIp(),
@@ -131,19 +162,19 @@ func attachChannelLifeCycleListeners(flow *types.Flow, channel *types.LineChanne
 			})
 			if err != nil {
 				zaplog.DebugWithContext(ctx, err.Error())
-				continue
+				return
 			}
 
 			_, err = api.SendHttpRequest("/call/updateCall", body)
 			if err != nil {
 				zaplog.DebugWithContext(ctx, err.Error())
-				continue
+				return
 			}
 			err = createCallDebit(flow.User, call, "incoming")
 			if err != nil {
 				zaplog.DebugWithContext(ctx, "HTTP error: "+err.Error())
-				continue
 			}
+			return
 
 		case call = <-callChannel:
 			zaplog.DebugWithContext(ctx, "received setup call")
@@ -152,22 +183,11 @@ func attachChannelLifeCycleListeners(flow *types.Flow, channel *types.LineChanne
 	}
 }
 
-func attachDTMFListeners(channel *types.LineChannel, ctx context.Context) {
-	dtmfSub := channel.Channel.Subscribe(ari.Events.ChannelDtmfReceived)
-	defer dtmfSub.Cancel()
-
-	select {
-	case <-ctx.Done():
-		return
-	case <-dtmfSub.Events():
-		zaplog.DebugWithContext(ctx, "received DTMF event")
-	}
-}
-
 func processIncomingCall(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, exten string, callerId string) {
-	go attachDTMFListeners(lineChannel, ctx)
-	callChannel := make(chan *types.Call)
-	go attachChannelLifeCycleListeners(flow, lineChannel, ctx, callChannel)
+	defer mngrs.RecoverCall(cl, lineChannel)
+	callCtx := mngrs.NewCallContext(ctx, lineChannel)
+	callChannel := make(chan *types.Call, 1)
+	go attachChannelLifeCycleListeners(flow, lineChannel, ctx, callCtx, callChannel)
 
 	zaplog.DebugWithContext(ctx, "Processing incoming call")
 	zaplog.DebugWithContext(ctx, "Exten is:"+exten)
@@ -216,17 +236,15 @@ func processIncomingCall(cl ari.Client, ctx context.Context, flow *types.Flow, l
 	lineChannel.Answer()
 
 	vars := make(map[string]string)
-	go mngrs.ProcessFlow(cl, ctx, flow, lineChannel, vars, flow.Cells[0])
+	go mngrs.ProcessFlow(cl, callCtx, flow, lineChannel, vars, flow.Cells[0])
 
 	callChannel <- &call
 
-	select {
-	case <-ctx.Done():
-		return
-	}
+	<-callCtx.Done()
 }
 
 func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart, h *ari.ChannelHandle) {
+	defer mngrs.RecoverCall(cl, &types.LineChannel{Channel: h})
 	helpers.Log(logrus.InfoLevel, "running app"+" channel "+h.Key().ID)
 
 	action := event.Args[0]
@@ -265,6 +283,7 @@ func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart,
 		}
 		helpers.Log(logrus.DebugLevel, "workspace ID= "+strconv.Itoa(resp.WorkspaceId))
 		user := types.NewUser(resp.Id, resp.WorkspaceId, resp.WorkspaceName)
+		user.Workspace.Timezone = resp.WorkspaceTimezone
 		err = utils.ProcessSIPTrunkCall(cl, lineChannel.Channel.Key(), user, &lineChannel, callerId, exten, trunkAddr)
 		if err != nil {
 			helpers.Log(logrus.DebugLevel, "could not create bridge. error: "+err.Error())
@@ -300,6 +319,18 @@ func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart,
 			return
 		}
 
+		problems := mngrs.ValidateFlow(&flowJson)
+		for _, problem := range problems {
+			helpers.Log(logrus.InfoLevel, "flow "+strconv.Itoa(data.FlowId)+" "+problem.String())
+		}
+		if err := problems.Err(); err != nil {
+			helpers.Log(logrus.ErrorLevel, "startExecution err "+err.Error())
+			user := types.NewUser(data.CreatorId, data.WorkspaceId, data.WorkspaceName)
+			user.Workspace.Timezone = data.WorkspaceTimezone
+			mngrs.RouteToFallback(cl, user, &types.LineChannel{Channel: h}, event.Args[2], err.Error())
+			return
+		}
+
 		body, err = api.SendGetRequest("/user/getWorkspaceMacros", vals)
 
 		if err != nil {
@@ -316,6 +347,7 @@ func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart,
 		lineChannel := types.LineChannel{
 			Channel: h}
 		user := types.NewUser(data.CreatorId, data.WorkspaceId, data.WorkspaceName)
+		user.Workspace.Timezone = data.WorkspaceTimezone
 		flow := types.NewFlow(
 			data.FlowId,
 			user,
@@ -346,6 +378,7 @@ func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart,
 		}
 		helpers.Log(logrus.DebugLevel, "workspace ID= "+strconv.Itoa(resp.WorkspaceId))
 		user := types.NewUser(resp.Id, resp.WorkspaceId, resp.WorkspaceName)
+		user.Workspace.Timezone = resp.WorkspaceTimezone
 
 		fmt.Printf("Received call from %s, domain: %s\r\n", callerId, domain)
 		fmt.Printf("Calling %s\r\n", exten)
@@ -373,6 +406,7 @@ func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart,
 		}
 		helpers.Log(logrus.DebugLevel, "workspace ID= "+strconv.Itoa(resp.WorkspaceId))
 		user := types.NewUser(resp.Id, resp.WorkspaceId, resp.WorkspaceName)
+		user.Workspace.Timezone = resp.WorkspaceTimezone
 
 		fmt.Printf("Received call from %s, domain: %s\r\n", callerId, domain)
 
@@ -408,6 +442,7 @@ func startExecution(ctx context.Context, cl ari.Client, event *ari.StasisStart,
 		}
 		helpers.Log(logrus.DebugLevel, "workspace ID= "+strconv.Itoa(resp.WorkspaceId))
 		user := types.NewUser(resp.Id, resp.WorkspaceId, resp.WorkspaceName)
+		user.Workspace.Timezone = resp.WorkspaceTimezone
 
 		fmt.Printf("Received call from %s, domain: %s\r\n", callerId, resp.WorkspaceName)
 		fmt.Printf("setup caller id: " + callerId)
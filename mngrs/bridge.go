@@ -1,13 +1,12 @@
 package mngrs
 
 import (
-	//"context"
-
+	"context"
 	"encoding/json"
 	"errors"
 	"strconv"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/CyCoreSystems/ari/v5"
 	"github.com/CyCoreSystems/ari/v5/rid"
@@ -22,9 +21,38 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+// BridgeConfig is the config of Bridge cells.
+type BridgeConfig struct {
+	CallConfig
+	QueueConfig
+	FollowMeConfig
+	// ExtraCallIds are calls that are added to the bridge as well.
+	ExtraCallIds []string `cell:"extra_call_ids"`
+}
+
+func (conf *BridgeConfig) Validate() error {
+	switch conf.CallType {
+	case "Extension", "Phone Number", "ExtensionFlow", "Follow Me", "Queue", "Merge Calls":
+	default:
+		return &CellConfigError{Field: "call_type", Message: "has unknown call type " + conf.CallType}
+	}
+	switch conf.CallType {
+	case "Queue":
+		if err := conf.QueueConfig.Validate(); err != nil {
+			return err
+		}
+	case "Follow Me":
+		if err := conf.FollowMeConfig.Validate(); err != nil {
+			return err
+		}
+	}
+	return conf.CallConfig.Validate()
+}
+
 type BridgeManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
+	config         BridgeConfig
 }
 
 func (man *BridgeManager) ensureBridge(src *ari.Key, callType string) error {
@@ -41,11 +69,17 @@ func (man *BridgeManager) ensureBridge(src *ari.Key, callType string) error {
 	}
 
 	lineBridge := types.NewBridge(bridge)
+	record, err := man.recordBridge(lineBridge)
+	if err != nil {
+		return err
+	}
 	helpers.Log(logrus.InfoLevel, "channel added to bridge")
 
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
-	go man.manageBridge(lineBridge, wg, callType)
+	goCell(man.ManagerContext, func() {
+		man.manageBridge(lineBridge, record, wg, callType)
+	})
 	wg.Wait()
 	if err := bridge.AddChannel(ctx.Channel.Channel.Key().ID); err != nil {
 		helpers.Log(logrus.ErrorLevel, "failed to add channel to bridge, error:"+err.Error())
@@ -54,54 +88,52 @@ func (man *BridgeManager) ensureBridge(src *ari.Key, callType string) error {
 
 	helpers.Log(logrus.InfoLevel, "channel added to bridge")
 	man.addAllRequestedCalls(lineBridge)
-	go man.startOutboundCall(lineBridge, callType)
+	goCell(man.ManagerContext, func() {
+		man.startOutboundCall(lineBridge, callType)
+	})
 
 	return nil
 }
 
 func (man *BridgeManager) addAllRequestedCalls(bridge *types.LineBridge) {
 	ctx := man.ManagerContext
-	cell := ctx.Cell
-	data := cell.Model.Data
-	extras := data["extra_call_ids"]
-	if extras == nil {
-		return
-	}
-	callIds := extras.(types.ModelDataStr)
 	helpers.Log(logrus.DebugLevel, "looking up requested channels")
-	if callIds.Value != "" {
-		ids := strings.Split(callIds.Value, ",")
-		for _, id := range ids {
-			helpers.Log(logrus.DebugLevel, "adding requested channel: "+id)
-			call, err := api.FetchCall(id)
-			if err != nil {
-				helpers.Log(logrus.DebugLevel, "error fetching requested channel: "+err.Error())
-				continue
-			}
-
-			key := ari.NewKey(ari.ChannelKey, call.ChannelId)
-			channel := ctx.Client.Channel().Get(key)
-			reqChannel := types.LineChannel{Channel: channel}
-			bridge.AddChannel(&reqChannel)
+	for _, id := range man.config.ExtraCallIds {
+		helpers.Log(logrus.DebugLevel, "adding requested channel: "+id)
+		call, err := api.FetchCall(id)
+		if err != nil {
+			helpers.Log(logrus.DebugLevel, "error fetching requested channel: "+err.Error())
+			continue
 		}
+
+		key := ari.NewKey(ari.ChannelKey, call.ChannelId)
+		channel := ctx.Client.Channel().Get(key)
+		reqChannel := types.LineChannel{Channel: channel}
+		bridge.AddChannel(&reqChannel)
 	}
 }
-func (man *BridgeManager) manageBridge(bridge *types.LineBridge, wg *sync.WaitGroup, callType string) {
-	h := bridge.Bridge
+
+// recordBridge starts the recording of a bridge. The bridge is deleted when
+// the recording cannot be started.
+func (man *BridgeManager) recordBridge(bridge *types.LineBridge) (*processor_helpers.Record, error) {
 	ctx := man.ManagerContext
 	flow := ctx.Flow
+	record := processor_helpers.NewRecording(ctx.Context, flow.User, &flow.RootCall.CallId, false)
+	if _, err := record.InitiateRecordingForBridge(bridge); err != nil {
+		helpers.Log(logrus.ErrorLevel, "error starting recording: "+err.Error())
+		bridge.Bridge.Delete()
+		return nil, err
+	}
+	return record, nil
+}
+
+func (man *BridgeManager) manageBridge(bridge *types.LineBridge, record *processor_helpers.Record, wg *sync.WaitGroup, callType string) {
+	h := bridge.Bridge
+	ctx := man.ManagerContext
 	cell := ctx.Cell
 	channel := ctx.Channel
-	record := processor_helpers.NewRecording(ctx.Context, flow.User, &flow.RootCall.CallId, false)
-	//_,recordErr:=record.InitiateRecordingForBridge(bridge)
-	_, recordErr := record.InitiateRecordingForBridge(bridge)
 	next, _ := utils.FindLinkByName(cell.TargetLinks, "source", "Connected Call Ended")
 
-	if recordErr != nil {
-		helpers.Log(logrus.ErrorLevel, "error starting recording: "+recordErr.Error())
-		return
-	}
-
 	helpers.Log(logrus.DebugLevel, "manageBridge called..")
 	// Delete the bridge when we exit
 	defer h.Delete()
@@ -120,6 +152,9 @@ func (man *BridgeManager) manageBridge(bridge *types.LineBridge, wg *sync.WaitGr
 	for {
 		select {
 		case <-ctx.Context.Done():
+			helpers.Log(logrus.DebugLevel, "bridge cancelled")
+			record.Stop()
+			man.hangupOtherChannels(bridge)
 			return
 		case <-destroySub.Events():
 			helpers.Log(logrus.DebugLevel, "bridge destroyed")
@@ -144,8 +179,36 @@ func (man *BridgeManager) manageBridge(bridge *types.LineBridge, wg *sync.WaitGr
 			resp := types.ManagerResponse{
 				Channel: channel,
 				Link:    next}
-			man.ManagerContext.RecvChannel <- &resp
+			// every channel leaves when the call ends, and only the first is
+			// waited for
+			select {
+			case man.ManagerContext.RecvChannel <- &resp:
+			default:
+				select {
+				case man.ManagerContext.RecvChannel <- &resp:
+				case <-ctx.Context.Done():
+					helpers.Log(logrus.DebugLevel, "bridge cancelled")
+					return
+				}
+			}
+		}
+	}
+}
+
+// hangupOtherChannels hangs up the channels in the bridge other than the
+// channel of the flow, which goes on with the next cell.
+func (man *BridgeManager) hangupOtherChannels(bridge *types.LineBridge) {
+	data, err := bridge.Bridge.Data()
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not get bridge data: "+err.Error())
+		return
+	}
+	for _, id := range data.ChannelIDs {
+		if id == man.ManagerContext.Channel.Channel.ID() {
+			continue
 		}
+		other := types.LineChannel{Channel: man.ManagerContext.Client.Channel().Get(ari.NewKey(ari.ChannelKey, id))}
+		other.SafeHangup()
 	}
 }
 
@@ -195,55 +258,50 @@ func (man *BridgeManager) manageOutboundCallLeg(outboundChannel *types.LineChann
 				Link:    next}
 			man.ManagerContext.RecvChannel <- &resp
 			return
-
+		case <-ctx.Context.Done():
+			helpers.Log(logrus.DebugLevel, "bridge cancelled, hanging up outbound call..")
+			outboundChannel.SafeHangup()
+			return
 		}
 	}
 }
 
-func (man *BridgeManager) startOutboundCall(bridge *types.LineBridge, callType string) {
-	ctx := man.ManagerContext
-	channel := ctx.Channel
-	cell := ctx.Cell
-	model := cell.Model
-	flow := ctx.Flow
-	user := flow.User
-	helpers.Log(logrus.DebugLevel, "startOutboundCall called..")
-	callerId := utils.DetermineCallerId(flow.RootCall, model.Data["caller_id"])
-	helpers.Log(logrus.DebugLevel, "caller ID was set to: "+callerId)
-
+// verifyCallerId fails when the workspace may not call with the caller id.
+func (man *BridgeManager) verifyCallerId(callerId string) error {
+	user := man.ManagerContext.Flow.User
 	valid, err := api.VerifyCallerId(strconv.Itoa(user.Workspace.Id), callerId)
 	if err != nil {
 		helpers.Log(logrus.DebugLevel, "verify error: "+err.Error())
-		return
+		return err
 	}
 	if !valid {
 		helpers.Log(logrus.DebugLevel, "caller id was invalid. user provided: "+callerId)
-		return
+		return errors.New("caller id " + callerId + " is not verified")
 	}
+	return nil
+}
 
-	numberToCall, err := utils.DetermineNumberToCall(model.Data)
-	if err != nil {
-		helpers.Log(logrus.DebugLevel, "verify error: "+err.Error())
-		return
-	}
-	//key := src.New(ari.ChannelKey, rid.New(rid.Channel))
-
+// originate places a call to an extension or a number. The channel is
+// returned before the call is answered.
+func (man *BridgeManager) originate(callerId string, numberToCall string, callType string) (*types.LineChannel, error) {
+	ctx := man.ManagerContext
+	flow := ctx.Flow
+	user := flow.User
 	helpers.Log(logrus.DebugLevel, "Calling: "+numberToCall)
 
-	timeout := utils.ParseRingTimeout(model.Data["timeout"])
 	outChannel := types.LineChannel{}
 	outboundChannel, err := ctx.Client.Channel().Create(nil, utils.CreateChannelRequest(numberToCall))
 
 	if err != nil {
 		helpers.Log(logrus.DebugLevel, "error creating outbound channel: "+err.Error())
-		return
+		return nil, err
 	}
 
 	domain := user.Workspace.Domain
 
 	var mappedCallType string
 	switch callType {
-	case "Extension":
+	case "Extension", "Queue":
 		mappedCallType = "extension"
 	case "Phone Number":
 		mappedCallType = "pstn"
@@ -260,7 +318,7 @@ func (man *BridgeManager) startOutboundCall(bridge *types.LineBridge, callType s
 	body, err := json.Marshal(params)
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
-		return
+		return nil, err
 	}
 
 	helpers.Log(logrus.InfoLevel, "creating outbound call...")
@@ -268,13 +326,13 @@ func (man *BridgeManager) startOutboundCall(bridge *types.LineBridge, callType s
 
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
-		return
+		return nil, err
 	}
 	outCall, err := outChannel.CreateCall(resp.Headers.Get("x-call-id"), &params)
 
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
-		return
+		return nil, err
 	}
 
 	apiCallId := strconv.Itoa(outCall.CallId)
@@ -283,24 +341,167 @@ func (man *BridgeManager) startOutboundCall(bridge *types.LineBridge, callType s
 
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
-		return
+		return nil, err
 	}
 	outChannel.Channel = outboundChannel
+	return &outChannel, nil
+}
 
-	stopChannel := make(chan bool)
+// ringDestination is an extension or a number a bridge cell rings.
+type ringDestination struct {
+	number   string
+	callType string
+}
+
+// ringOffer is the outcome of ringing a destination.
+type ringOffer struct {
+	destination ringDestination
+	leg         *types.LineChannel
+	answered    bool
+}
+
+// ringDestinations calls the destinations at once until one of them answers,
+// which hangs up the others, or until the timeout elapses. When accept is set
+// it decides whether an answered call is taken, e.g. once the callee
+// confirmed it, and calls that are not accepted are hung up. ended is called
+// for every destination that does not get the call.
+func (man *BridgeManager) ringDestinations(callerId string, destinations []ringDestination, timeout time.Duration, accept func(ringCtx context.Context, leg *types.LineChannel) bool, ended func(number string)) *ringOffer {
+	ctx := man.ManagerContext
+	ringCtx, cancel := context.WithCancel(ctx.Context)
+	defer cancel()
+
+	offers := make(chan *ringOffer, len(destinations))
+	legs := make(map[string]*ringOffer)
+	for _, destination := range destinations {
+		leg, err := man.originate(callerId, destination.number, destination.callType)
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not call "+destination.number+": "+err.Error())
+			ended(destination.number)
+			continue
+		}
+		offer := &ringOffer{destination: destination, leg: leg}
+		legs[leg.Channel.ID()] = offer
+		startSub := leg.Channel.Subscribe(ari.Events.StasisStart)
+		endSub := leg.Channel.Subscribe(ari.Events.StasisEnd)
+		ctx.Flow.Go(func() {
+			defer startSub.Cancel()
+			defer endSub.Cancel()
+			select {
+			case <-startSub.Events():
+				answered := accept == nil || accept(ringCtx, offer.leg)
+				offers <- &ringOffer{destination: offer.destination, leg: offer.leg, answered: answered}
+			case <-endSub.Events():
+				offers <- &ringOffer{destination: offer.destination, leg: offer.leg}
+			case <-ringCtx.Done():
+			}
+		})
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var answered *ringOffer
+	ringing := true
+	for ringing && answered == nil && len(legs) > 0 {
+		select {
+		case offer := <-offers:
+			if offer.answered {
+				answered = offer
+				continue
+			}
+			helpers.Log(logrus.DebugLevel, offer.destination.number+" did not take the call")
+			offer.leg.SafeHangup()
+			delete(legs, offer.leg.Channel.ID())
+			ended(offer.destination.number)
+		case <-timer.C:
+			helpers.Log(logrus.DebugLevel, "no destination answered in time")
+			ringing = false
+		case <-ctx.Context.Done():
+			ringing = false
+		}
+	}
+	for id, offer := range legs {
+		if answered != nil && id == answered.leg.Channel.ID() {
+			continue
+		}
+		offer.leg.SafeHangup()
+		ended(offer.destination.number)
+	}
+	return answered
+}
+
+// bridgeWith bridges the caller with a call that was answered. The call
+// continues like a call to an extension.
+func (man *BridgeManager) bridgeWith(leg *types.LineChannel, callType string) {
+	ctx := man.ManagerContext
+	key := ctx.Channel.Channel.Key().New(ari.BridgeKey, rid.New(rid.Bridge))
+	bridge, err := ctx.Client.Bridge().Create(key, "mixing", key.ID)
+	if err != nil {
+		leg.SafeHangup()
+		man.fail(eris.Wrap(err, "failed to create bridge"))
+		return
+	}
+	lineBridge := types.NewBridge(bridge)
+	record, err := man.recordBridge(lineBridge)
+	if err != nil {
+		leg.SafeHangup()
+		man.fail(err)
+		return
+	}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	goCell(ctx, func() {
+		man.manageBridge(lineBridge, record, wg, callType)
+	})
+	wg.Wait()
+
+	lineBridge.AddChannel(ctx.Channel)
+	lineBridge.AddChannel(leg)
+	for _, item := range []*types.LineChannel{ctx.Channel, leg} {
+		if err := bridge.AddChannel(item.Channel.ID()); err != nil {
+			helpers.Log(logrus.ErrorLevel, "failed to add channel to bridge, error:"+err.Error())
+			man.fail(errors.New("failed to add channel to bridge"))
+			return
+		}
+	}
+}
+
+func (man *BridgeManager) startOutboundCall(bridge *types.LineBridge, callType string) {
+	ctx := man.ManagerContext
+	channel := ctx.Channel
+	flow := ctx.Flow
+	helpers.Log(logrus.DebugLevel, "startOutboundCall called..")
+	callerId := man.config.callerId(flow.RootCall)
+	helpers.Log(logrus.DebugLevel, "caller ID was set to: "+callerId)
+
+	if err := man.verifyCallerId(callerId); err != nil {
+		man.fail(err)
+		return
+	}
+
+	numberToCall := man.config.numberToCall()
+	timeout := man.config.Timeout
+	outChannel, err := man.originate(callerId, numberToCall, callType)
+	if err != nil {
+		man.fail(err)
+		return
+	}
+
+	stopChannel := make(chan bool, 1)
 	channel.Channel.Ring()
 
 	wg1 := new(sync.WaitGroup)
 	wg1.Add(1)
 	bridge.AddChannel(channel)
-	bridge.AddChannel(&outChannel)
-	go man.manageOutboundCallLeg(&outChannel, bridge, wg1, stopChannel)
+	bridge.AddChannel(outChannel)
+	goCell(man.ManagerContext, func() {
+		man.manageOutboundCallLeg(outChannel, bridge, wg1, stopChannel)
+	})
 
 	wg1.Wait()
 
 	wg2 := new(sync.WaitGroup)
 	wg2.Add(1)
-	go bridge.StartWaitingForRingTimeout(timeout, wg2, stopChannel)
+	go bridge.StartWaitingForRingTimeout(ctx.Context, timeout, wg2, stopChannel)
 	wg2.Wait()
 }
 
@@ -312,42 +513,52 @@ func NewBridgeManager(mngrCtx *types.Context, flow *types.Flow) *BridgeManager {
 	return &item
 }
 func (man *BridgeManager) StartProcessing() {
+	goCell(man.ManagerContext, man.startBridge)
+}
+
+// fail resolves the cell with an error. The cell is cancelled first, which
+// deletes its bridge and hangs up the calls it placed.
+func (man *BridgeManager) fail(err error) {
+	man.ManagerContext.Cancel()
+	failCell(man.ManagerContext, err)
+}
+
+func (man *BridgeManager) startBridge() {
 	helpers.Log(logrus.DebugLevel, "Creating bridge... ")
-	cell := man.ManagerContext.Cell
 	flow := man.ManagerContext.Flow
 	user := flow.User
-	data := cell.Model.Data
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Connected Call Ended")
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Caller Hung Up")
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Declined")
+	if err := loadConfig(man.ManagerContext, &man.config); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid bridge cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
 
 	// create the bridge
 
-	callType := data["call_type"].(types.ModelDataStr)
-
-	helpers.Log(logrus.DebugLevel, "processing call type: "+callType.Value)
-	if callType.Value == "Extension" || callType.Value == "Phone Number" {
-		man.startSimpleCall(callType.Value)
+	callType := man.config.CallType
 
-	} else if callType.Value == "ExtensionFlow" {
-		extension := data["extension"].(types.ModelDataStr).Value
-		man.initiateExtFlow(user, extension)
-	} else if callType.Value == "Follow Me" {
-	} else if callType.Value == "Queue" {
-	} else if callType.Value == "Merge Calls" {
-		man.startCallMerge(callType.Value)
-	}
-
-	for {
-		select {
-		case <-man.ManagerContext.Context.Done():
-			return
-		}
+	helpers.Log(logrus.DebugLevel, "processing call type: "+callType)
+	switch callType {
+	case "Extension", "Phone Number":
+		man.startSimpleCall(callType)
+	case "ExtensionFlow":
+		man.initiateExtFlow(user, man.config.Extension)
+	case "Merge Calls":
+		man.startCallMerge(callType)
+	case "Queue":
+		man.startQueue()
+	case "Follow Me":
+		man.startFollowMe()
+	default:
+		failCell(man.ManagerContext, errors.New("call type "+callType+" is not supported"))
 	}
 }
 func (man *BridgeManager) startSimpleCall(callType string) {
 	helpers.Log(logrus.DebugLevel, "Starting simple call..")
-	man.ensureBridge(man.ManagerContext.Channel.Channel.Key(), callType)
+	if err := man.ensureBridge(man.ManagerContext.Channel.Channel.Key(), callType); err != nil {
+		helpers.Log(logrus.ErrorLevel, "error creating bridge: "+err.Error())
+		man.fail(err)
+	}
 }
 
 func (man *BridgeManager) initiateExtFlow(user *types.User, extension string) {
@@ -361,6 +572,7 @@ func (man *BridgeManager) initiateExtFlow(user *types.User, extension string) {
 	subFlow, err := api.GetExtensionFlowInfo(strconv.Itoa(workspace), extension)
 	if err != nil {
 		helpers.Log(logrus.DebugLevel, "error starting new flow: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 	info := subFlow.Vars
@@ -373,8 +585,15 @@ func (man *BridgeManager) initiateExtFlow(user *types.User, extension string) {
 		coreFlow.WorkspaceFns,
 		client)
 
+	flow.Routines = coreFlow.Routines
 	vars := make(map[string]string)
-	go ProcessFlow(client, man.ManagerContext.Context, flow, channel, vars, flow.Cells[0])
+	flow.Go(func() {
+		ProcessFlow(client, man.ManagerContext.Context, flow, channel, vars, flow.Cells[0])
+	})
+	// the extension flow takes over the call
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: channel,
+		Exit:    true}
 }
 
 func (man *BridgeManager) startCallMerge(callType string) {
@@ -388,17 +607,24 @@ func (man *BridgeManager) startCallMerge(callType string) {
 	key := ari.NewKey(ari.BridgeKey, bridgeKey)
 	bridge, err := ctx.Client.Bridge().Create(key, "mixing", key.ID)
 	if err != nil {
-		bridge = nil
 		helpers.Log(logrus.DebugLevel, "failed to create bridge")
+		failCell(ctx, eris.Wrap(err, "failed to create bridge"))
 		return
 	}
 
 	lineBridge := types.NewBridge(bridge)
+	record, err := man.recordBridge(lineBridge)
+	if err != nil {
+		failCell(ctx, err)
+		return
+	}
 	helpers.Log(logrus.InfoLevel, "channel added to bridge")
 
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
-	go man.manageBridge(lineBridge, wg, callType)
+	goCell(man.ManagerContext, func() {
+		man.manageBridge(lineBridge, record, wg, callType)
+	})
 	wg.Wait()
 
 	man.addAllRequestedCalls(lineBridge)
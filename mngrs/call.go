@@ -0,0 +1,64 @@
+package mngrs
+
+import (
+	"context"
+	"expvar"
+	"runtime"
+
+	"github.com/CyCoreSystems/ari/v5"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+)
+
+// activeCalls is the number of calls whose context is not done yet. Together
+// with the goroutine count it shows whether calls leave anything running
+// after they end.
+var activeCalls = expvar.NewInt("active_calls")
+
+// callWatcherKey holds the channel that is closed once the goroutine
+// watching the root channel of a call returned.
+type callWatcherKey struct{}
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+}
+
+// NewCallContext returns the context of a call, which is cancelled when the
+// root channel of the call leaves the application or is destroyed, or when
+// ctx is done. Every runner, cell, playback and subscription of the call
+// stops with it.
+func NewCallContext(ctx context.Context, channel *types.LineChannel) context.Context {
+	callCtx, cancel := context.WithCancel(ctx)
+	sub := channel.Channel.Subscribe(ari.Events.StasisEnd, ari.Events.ChannelDestroyed)
+	activeCalls.Add(1)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		defer activeCalls.Add(-1)
+		defer sub.Cancel()
+		defer cancel()
+		select {
+		case <-callCtx.Done():
+		case e := <-sub.Events():
+			if e != nil {
+				helpers.Log(logrus.DebugLevel, "call ended with "+e.GetType()+" of channel "+channel.Channel.ID())
+			}
+		}
+	}()
+	return context.WithValue(callCtx, callWatcherKey{}, watched)
+}
+
+// WaitForCall blocks until nothing of a call runs anymore: the goroutine
+// watching the context from NewCallContext, the runners of the flow and the
+// goroutines of its cells. The context must be done or about to be.
+func WaitForCall(ctx context.Context, flow *types.Flow) {
+	if watched, ok := ctx.Value(callWatcherKey{}).(chan struct{}); ok {
+		<-watched
+	}
+	if flow != nil {
+		flow.Wait()
+	}
+}
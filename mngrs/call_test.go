@@ -0,0 +1,51 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestCallContextEndsOnHangup(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	channel := newLiveChannel("hangup-1")
+	lineChannel := &types.LineChannel{Channel: channel.Get(ari.NewKey(ari.ChannelKey, "hangup-1"))}
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal([]byte(waitFlow), &vars))
+	flow := types.NewFlow(4, types.NewUser(1, 2, "test"), &vars, lineChannel, nil, nil)
+
+	active := activeCalls.Value()
+	callCtx := NewCallContext(context.Background(), lineChannel)
+	require.Equal(t, active+1, activeCalls.Value())
+	done := make(chan struct{})
+	go func() {
+		ProcessFlow(nil, callCtx, flow, lineChannel, make(map[string]string), flow.Cells[0])
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		_, ok := flow.GetVariable("language")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	channel.bus.Send(&ari.StasisEnd{
+		EventData: ari.EventData{Type: ari.Events.StasisEnd},
+		Channel:   ari.ChannelData{ID: "hangup-1"}})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the flow kept running after the hangup")
+	}
+	require.Error(t, callCtx.Err())
+	require.Eventually(t, func() bool {
+		return activeCalls.Value() == active
+	}, time.Second, 10*time.Millisecond)
+	entries := flow.Trace.Snapshot().Entries
+	require.Equal(t, "Wait1", entries[len(entries)-1].CellName)
+	require.Equal(t, "cancelled", entries[len(entries)-1].Error)
+}
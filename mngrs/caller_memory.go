@@ -0,0 +1,241 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// CallerMemoryStore keeps values about callers between their calls, e.g. the
+// language they chose or their open ticket. Entries are scoped to a workspace
+// and keyed by the number of the caller or by a custom key.
+type CallerMemoryStore interface {
+	Get(workspaceId int, key string) (map[string]string, error)
+	// Set adds values to an entry, which expires ttl after it was last set.
+	Set(workspaceId int, key string, values map[string]string, ttl time.Duration) error
+	// Delete removes values from an entry, or the whole entry when no names
+	// are given.
+	Delete(workspaceId int, key string, names []string) error
+}
+
+// RedisCallerMemoryStore keeps each entry in a Redis hash.
+type RedisCallerMemoryStore struct {
+	Client *redis.Client
+}
+
+func callerMemoryKey(workspaceId int, key string) string {
+	return "caller_memory:" + strconv.Itoa(workspaceId) + ":" + key
+}
+
+func (store *RedisCallerMemoryStore) Get(workspaceId int, key string) (map[string]string, error) {
+	return store.Client.HGetAll(context.Background(), callerMemoryKey(workspaceId, key)).Result()
+}
+
+func (store *RedisCallerMemoryStore) Set(workspaceId int, key string, values map[string]string, ttl time.Duration) error {
+	ctx := context.Background()
+	_, err := store.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, callerMemoryKey(workspaceId, key), values)
+		if ttl > 0 {
+			pipe.Expire(ctx, callerMemoryKey(workspaceId, key), ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (store *RedisCallerMemoryStore) Delete(workspaceId int, key string, names []string) error {
+	if len(names) == 0 {
+		return store.Client.Del(context.Background(), callerMemoryKey(workspaceId, key)).Err()
+	}
+	return store.Client.HDel(context.Background(), callerMemoryKey(workspaceId, key), names...).Err()
+}
+
+var (
+	callerMemoryMu    sync.Mutex
+	callerMemoryStore CallerMemoryStore
+)
+
+// SetCallerMemoryStore replaces the store used for caller memory.
+func SetCallerMemoryStore(store CallerMemoryStore) {
+	callerMemoryMu.Lock()
+	defer callerMemoryMu.Unlock()
+	callerMemoryStore = store
+}
+
+func getCallerMemoryStore() CallerMemoryStore {
+	callerMemoryMu.Lock()
+	defer callerMemoryMu.Unlock()
+	if callerMemoryStore == nil {
+		callerMemoryStore = &RedisCallerMemoryStore{Client: utils.CreateRDB()}
+	}
+	return callerMemoryStore
+}
+
+// GetCallerMemory returns the values remembered under a key of a workspace.
+func GetCallerMemory(workspaceId int, key string) (map[string]string, error) {
+	if strings.TrimSpace(key) == "" {
+		return nil, errors.New("caller memory key is empty")
+	}
+	return getCallerMemoryStore().Get(workspaceId, key)
+}
+
+// UpdateCallerMemory remembers values under a key of a workspace. A ttl of
+// zero keeps them for CALLER_MEMORY_TTL.
+func UpdateCallerMemory(workspaceId int, key string, values map[string]string, ttl time.Duration) error {
+	if strings.TrimSpace(key) == "" {
+		return errors.New("caller memory key is empty")
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if ttl == 0 {
+		ttl = config.NewConfig().CallerMemoryTTL
+	}
+	return getCallerMemoryStore().Set(workspaceId, key, values, ttl)
+}
+
+// DeleteCallerMemory forgets values remembered under a key of a workspace, or
+// the whole entry when no names are given.
+func DeleteCallerMemory(workspaceId int, key string, names []string) error {
+	if strings.TrimSpace(key) == "" {
+		return errors.New("caller memory key is empty")
+	}
+	return getCallerMemoryStore().Delete(workspaceId, key, names)
+}
+
+// callerKey returns the key of the caller of a flow: the custom key set by a
+// Caller Memory cell, or the number of the caller.
+func callerKey(flow *types.Flow) string {
+	if key := flow.CallerKey(); key != "" {
+		return key
+	}
+	if flow.RootCall != nil && flow.RootCall.Params != nil {
+		return flow.RootCall.Params.From
+	}
+	return ""
+}
+
+// resolveCallerVariable resolves {{caller.<name>}} from the memory of the
+// caller of the flow, which is read once and again after a Caller Memory cell
+// wrote to it.
+func resolveCallerVariable(flow *types.Flow, path string) (string, bool) {
+	key := callerKey(flow)
+	if key == "" || flow.User == nil {
+		return "", false
+	}
+	values, err := flow.CallerMemory(func() (map[string]string, error) {
+		return GetCallerMemory(flow.User.Workspace.Id, key)
+	})
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not read caller memory: "+err.Error())
+		return "", false
+	}
+	value, ok := values[path]
+	return value, ok
+}
+
+func init() {
+	types.RegisterVariableNamespace("caller", resolveCallerVariable)
+}
+
+// CallerMemoryConfig is the config of Caller Memory cells.
+type CallerMemoryConfig struct {
+	Key    string              `cell:"key"`
+	Values []map[string]string `cell:"values"`
+	Forget []string            `cell:"forget"`
+	TTL    int                 `cell:"ttl"`
+}
+
+func (conf *CallerMemoryConfig) Validate() error {
+	if conf.TTL < 0 {
+		return &CellConfigError{Field: "ttl", Message: "can not be negative"}
+	}
+	for _, entry := range conf.Values {
+		if strings.TrimSpace(entry["name"]) == "" {
+			return &CellConfigError{Field: "values", Message: "every value needs a name"}
+		}
+	}
+	return nil
+}
+
+// CallerMemoryManager writes to the memory of the caller, e.g.
+// {"values": [{"name": "lang", "value": "{{Input1.digits}}"}], "ttl": 86400}
+// or {"forget": ["ticket"]}. A "key" remembers under a custom key instead of
+// the number of the caller, which {{caller.<name>}} reads for the rest of
+// the call.
+type CallerMemoryManager struct {
+	ManagerContext *types.Context
+	Flow           *types.Flow
+}
+
+func NewCallerMemoryManager(mngrCtx *types.Context, flow *types.Flow) *CallerMemoryManager {
+	item := CallerMemoryManager{
+		ManagerContext: mngrCtx,
+		Flow:           flow}
+	return &item
+}
+
+func (man *CallerMemoryManager) StartProcessing() {
+	goCell(man.ManagerContext, man.remember)
+}
+
+func (man *CallerMemoryManager) remember() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+	completed, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Completed")
+
+	var conf CallerMemoryConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid caller memory cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+	key := strings.TrimSpace(conf.Key)
+	if key != "" {
+		flow.SetCallerKey(key)
+	} else {
+		key = callerKey(flow)
+	}
+	if key == "" || flow.User == nil {
+		failCell(ctx, errors.New("the call has no caller number to remember values for"))
+		return
+	}
+	workspaceId := flow.User.Workspace.Id
+
+	values := make(map[string]string)
+	for _, entry := range conf.Values {
+		values[strings.TrimSpace(entry["name"])] = entry["value"]
+	}
+	ttl := time.Duration(conf.TTL) * time.Second
+	err := UpdateCallerMemory(workspaceId, key, values, ttl)
+	flow.ForgetCallerMemory()
+	if err != nil {
+		failCell(ctx, errors.New("could not remember values: "+err.Error()))
+		return
+	}
+	if len(conf.Forget) > 0 {
+		err := DeleteCallerMemory(workspaceId, key, conf.Forget)
+		flow.ForgetCallerMemory()
+		if err != nil {
+			failCell(ctx, errors.New("could not forget values: "+err.Error()))
+			return
+		}
+	}
+	for name, value := range values {
+		cell.SetEventVar(name, value)
+	}
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    completed}
+}
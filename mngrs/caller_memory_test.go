@@ -0,0 +1,127 @@
+package mngrs
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+type memoryCallerStore struct {
+	mu      sync.Mutex
+	entries map[string]map[string]string
+	ttls    map[string]time.Duration
+	gets    int
+}
+
+func newMemoryCallerStore() *memoryCallerStore {
+	return &memoryCallerStore{
+		entries: make(map[string]map[string]string),
+		ttls:    make(map[string]time.Duration)}
+}
+
+func (store *memoryCallerStore) Get(workspaceId int, key string) (map[string]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.gets++
+	values := make(map[string]string)
+	for name, value := range store.entries[callerMemoryKey(workspaceId, key)] {
+		values[name] = value
+	}
+	return values, nil
+}
+
+func (store *memoryCallerStore) Set(workspaceId int, key string, values map[string]string, ttl time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	entry, ok := store.entries[callerMemoryKey(workspaceId, key)]
+	if !ok {
+		entry = make(map[string]string)
+		store.entries[callerMemoryKey(workspaceId, key)] = entry
+	}
+	for name, value := range values {
+		entry[name] = value
+	}
+	store.ttls[callerMemoryKey(workspaceId, key)] = ttl
+	return nil
+}
+
+func (store *memoryCallerStore) Delete(workspaceId int, key string, names []string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(names) == 0 {
+		delete(store.entries, callerMemoryKey(workspaceId, key))
+		return nil
+	}
+	for _, name := range names {
+		delete(store.entries[callerMemoryKey(workspaceId, key)], name)
+	}
+	return nil
+}
+
+func newCallerFlow(from string, cells ...*types.Cell) *types.Flow {
+	return &types.Flow{
+		User:     types.NewUser(1, 7, "test"),
+		RootCall: &types.Call{Params: &types.CallParams{From: from}},
+		Trace:    types.NewFlowTrace(1),
+		Cells:    cells}
+}
+
+func TestCallerMemory(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	store := newMemoryCallerStore()
+	SetCallerMemoryStore(store)
+	defer SetCallerMemoryStore(nil)
+	require.NoError(t, UpdateCallerMemory(7, "+15145550100", map[string]string{"lang": "fr", "ticket": "T-1"}, 0))
+
+	remember := newTestCell("1", "Remember1", "devs.CallerMemoryModel", map[string]types.ModelData{
+		"values": types.ModelDataList{Value: []map[string]string{{"name": "menu", "value": "billing-{{caller.lang}}"}}},
+		"forget": types.ModelDataArr{Value: []string{"ticket"}},
+		"ttl":    types.ModelDataStr{Value: "60"}})
+	flow := newCallerFlow("+15145550100", remember)
+
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), remember)
+
+	values, err := GetCallerMemory(7, "+15145550100")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"lang": "fr", "menu": "billing-fr"}, values)
+	require.Equal(t, time.Minute, store.ttls[callerMemoryKey(7, "+15145550100")])
+	// the memory is read again once after the cell wrote to it
+	reads := store.gets
+	require.Equal(t, "fr billing-fr", flow.Interpolate("{{caller.lang}} {{caller.menu}}"))
+	require.Equal(t, "fr", flow.Interpolate("{{caller.lang}}"))
+	require.Equal(t, reads+1, store.gets)
+	// other workspaces do not see the entry
+	values, _ = GetCallerMemory(8, "+15145550100")
+	require.Empty(t, values)
+}
+
+func TestCallerMemoryCustomKey(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	SetCallerMemoryStore(newMemoryCallerStore())
+	defer SetCallerMemoryStore(nil)
+	require.NoError(t, UpdateCallerMemory(7, "account-42", map[string]string{"tier": "gold"}, 0))
+
+	remember := newTestCell("1", "Remember1", "devs.CallerMemoryModel", map[string]types.ModelData{
+		"key": types.ModelDataStr{Value: "account-42"}})
+	flow := newCallerFlow("", remember)
+	require.Equal(t, "", flow.Interpolate("{{caller.tier}}"))
+
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), remember)
+
+	require.Equal(t, "gold", flow.Interpolate("{{caller.tier}}"))
+	_, ok := flow.GetVariable("caller_key")
+	require.False(t, ok)
+
+	forget := newTestCell("2", "Forget1", "devs.CallerMemoryModel", map[string]types.ModelData{
+		"forget": types.ModelDataArr{Value: []string{"tier"}}})
+	flow.Cells = append(flow.Cells, forget)
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), forget)
+
+	require.Equal(t, "", flow.Interpolate("{{caller.tier}}"))
+	require.Error(t, DeleteCallerMemory(7, " ", nil))
+}
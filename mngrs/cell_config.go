@@ -0,0 +1,230 @@
+package mngrs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"lineblocs.com/processor/types"
+)
+
+// CellConfig is the typed model data of a cell. Fields are read from the
+// model data key in their "cell" tag, e.g. `cell:"wait_seconds,required"`, and
+// fall back to their "default" tag when the key is missing or empty. Fields
+// can be strings, bools, ints, float64s, []string, map[string]string or
+// []map[string]string. Embedded structs share their fields.
+type CellConfig interface {
+	// Validate is called once the fields are decoded.
+	Validate() error
+}
+
+// CellConfigError is returned for model data that does not match the config
+// of its cell.
+type CellConfigError struct {
+	Field   string
+	Message string
+}
+
+func (e *CellConfigError) Error() string {
+	return "field " + e.Field + " " + e.Message
+}
+
+// DecodeCellConfig fills the config from the model data of a cell and
+// validates it.
+func DecodeCellConfig(data map[string]types.ModelData, conf CellConfig) error {
+	value := reflect.ValueOf(conf)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("cell config must be a pointer to a struct")
+	}
+	if err := decodeFields(data, value.Elem()); err != nil {
+		return err
+	}
+	return conf.Validate()
+}
+
+// loadConfig fills conf with the config the runner decoded for the cell, or
+// decodes it when the manager was started without the runner.
+func loadConfig(ctx *types.Context, conf CellConfig) error {
+	if decoded, ok := ctx.Config.(CellConfig); ok && reflect.TypeOf(decoded) == reflect.TypeOf(conf) {
+		reflect.ValueOf(conf).Elem().Set(reflect.ValueOf(decoded).Elem())
+		return nil
+	}
+	return DecodeCellConfig(ctx.Cell.Model.Data, conf)
+}
+
+// failCell resolves a cell with an error, which the runner hands to the
+// "Error" port of the cell or to the error handling of the flow.
+func failCell(ctx *types.Context, err error) {
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Error:   err}
+}
+
+// configFields returns the model data keys of a config, and whether each is
+// required.
+func configFields(conf CellConfig) map[string]bool {
+	fields := make(map[string]bool)
+	collectFields(reflect.TypeOf(conf).Elem(), fields)
+	return fields
+}
+
+func collectFields(structType reflect.Type, fields map[string]bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectFields(field.Type, fields)
+			continue
+		}
+		name, required, ok := parseCellTag(field)
+		if ok {
+			fields[name] = required
+		}
+	}
+}
+
+func parseCellTag(field reflect.StructField) (string, bool, bool) {
+	tag, ok := field.Tag.Lookup("cell")
+	if !ok || tag == "" || tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	required := false
+	for _, option := range parts[1:] {
+		if option == "required" {
+			required = true
+		}
+	}
+	return parts[0], required, true
+}
+
+func decodeFields(data map[string]types.ModelData, target reflect.Value) error {
+	structType := target.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := decodeFields(data, target.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		name, required, ok := parseCellTag(field)
+		if !ok {
+			continue
+		}
+		item, present := data[name]
+		if present && isEmptyModelData(item) {
+			present = false
+		}
+		if !present {
+			defaultValue, hasDefault := field.Tag.Lookup("default")
+			if !hasDefault {
+				if required {
+					return &CellConfigError{Field: name, Message: "is required"}
+				}
+				continue
+			}
+			item = types.ModelDataStr{Value: defaultValue}
+		}
+		if err := decodeField(item, target.Field(i)); err != nil {
+			return &CellConfigError{Field: name, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+func isEmptyModelData(item types.ModelData) bool {
+	switch value := item.(type) {
+	case nil:
+		return true
+	case types.ModelDataStr:
+		return strings.TrimSpace(value.Value) == ""
+	case types.ModelDataArr:
+		return len(value.Value) == 0
+	}
+	return false
+}
+
+func decodeField(item types.ModelData, field reflect.Value) error {
+	switch field.Kind() {
+	case reflect.String:
+		switch value := item.(type) {
+		case types.ModelDataStr:
+			field.SetString(value.Value)
+		case types.ModelDataBool:
+			field.SetString(strconv.FormatBool(value.Value))
+		default:
+			return errors.New("must be text")
+		}
+	case reflect.Bool:
+		switch value := item.(type) {
+		case types.ModelDataBool:
+			field.SetBool(value.Value)
+		case types.ModelDataStr:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value.Value))
+			if err != nil {
+				return fmt.Errorf("must be true or false, got %q", value.Value)
+			}
+			field.SetBool(parsed)
+		default:
+			return errors.New("must be true or false")
+		}
+	case reflect.Int:
+		value, ok := item.(types.ModelDataStr)
+		if !ok {
+			return errors.New("must be a number")
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value.Value), 64)
+		if err != nil || parsed != float64(int(parsed)) {
+			return fmt.Errorf("must be a whole number, got %q", value.Value)
+		}
+		field.SetInt(int64(parsed))
+	case reflect.Float64:
+		value, ok := item.(types.ModelDataStr)
+		if !ok {
+			return errors.New("must be a number")
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value.Value), 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", value.Value)
+		}
+		field.SetFloat(parsed)
+	case reflect.Slice:
+		switch field.Type().Elem().Kind() {
+		case reflect.String:
+			switch value := item.(type) {
+			case types.ModelDataArr:
+				field.Set(reflect.ValueOf(value.Value))
+			case types.ModelDataStr:
+				// a comma separated list
+				items := make([]string, 0)
+				for _, entry := range strings.Split(value.Value, ",") {
+					if entry = strings.TrimSpace(entry); entry != "" {
+						items = append(items, entry)
+					}
+				}
+				field.Set(reflect.ValueOf(items))
+			default:
+				return errors.New("must be a list")
+			}
+		case reflect.Map:
+			value, ok := item.(types.ModelDataList)
+			if !ok {
+				return errors.New("must be a list of objects")
+			}
+			field.Set(reflect.ValueOf(value.Value))
+		default:
+			return errors.New("has an unsupported type")
+		}
+	case reflect.Map:
+		value, ok := item.(types.ModelDataObj)
+		if !ok {
+			return errors.New("must be an object")
+		}
+		field.Set(reflect.ValueOf(value.Value))
+	default:
+		return errors.New("has an unsupported type")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+// editorFlow has the model data types the flow editor sends.
+const editorFlow = `{
+  "graph": {"cells": [
+    {"id": "input", "name": "Input1", "type": "devs.ProcessInputModel"},
+    {"id": "macro", "name": "Macro1", "type": "devs.MacroModel"}
+  ]},
+  "models": [
+    {"id": "input", "name": "Input1", "data": {
+      "playback_type": "Say", "text_to_say": "Enter your account", "stop_timeout": 2.5,
+      "max_digits": 6, "stop_gather_on_keypress": true, "keypress_key_stop": "#", "tags": ["a", 1]
+    }},
+    {"id": "macro", "name": "Macro1", "data": {"function": "lookup", "params": {"retries": 3, "strict": false}}}
+  ]
+}`
+
+func TestDecodeCellConfig(t *testing.T) {
+	t.Parallel()
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal([]byte(editorFlow), &vars))
+	flow := types.NewFlow(1, types.NewUser(1, 1, "test"), &vars, &types.LineChannel{}, nil, nil)
+
+	var input InputConfig
+	require.NoError(t, DecodeCellConfig(flow.FindCell("Input1").Model.Data, &input))
+	require.Equal(t, "Say", input.PlaybackType)
+	require.Equal(t, 2.5, input.StopTimeout)
+	require.Equal(t, 6, input.MaxDigits)
+	require.True(t, input.StopGatherOnKeypress)
+	require.Equal(t, types.ModelDataArr{Value: []string{"a", "1"}}, flow.FindCell("Input1").Model.Data["tags"])
+
+	var macro MacroConfig
+	require.NoError(t, DecodeCellConfig(flow.FindCell("Macro1").Model.Data, &macro))
+	require.Equal(t, map[string]string{"retries": "3", "strict": "false"}, macro.Params)
+
+	tests := []struct {
+		name string
+		data map[string]types.ModelData
+		conf CellConfig
+		err  string
+	}{
+		{
+			name: "Default",
+			data: map[string]types.ModelData{"playback_type": types.ModelDataStr{Value: "Play"}, "url_audio": types.ModelDataStr{Value: "https://example.com/a.wav"}},
+			conf: &PlaybackConfig{},
+		},
+		{
+			name: "Required",
+			data: map[string]types.ModelData{"wait_seconds": types.ModelDataStr{Value: " "}},
+			conf: &WaitConfig{},
+			err:  "field wait_seconds is required",
+		},
+		{
+			name: "WrongType",
+			data: map[string]types.ModelData{"wait_seconds": types.ModelDataStr{Value: "1.5"}},
+			conf: &WaitConfig{},
+			err:  `field wait_seconds must be a whole number, got "1.5"`,
+		},
+		{
+			name: "Invalid",
+			data: map[string]types.ModelData{"call_type": types.ModelDataStr{Value: "Phone Number"}},
+			conf: &DialConfig{},
+			err:  "field number_to_call is required for Phone Number calls",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := DecodeCellConfig(tt.data, tt.conf)
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	var playback PlaybackConfig
+	require.NoError(t, DecodeCellConfig(tests[0].data, &playback))
+	require.Equal(t, 1, playback.Loops)
+}
+
+func TestRequiredFieldsFromConfig(t *testing.T) {
+	t.Parallel()
+	cellType, ok := LookupCellType("devs.ProcessInputModel")
+	require.True(t, ok)
+	require.Equal(t, []string{"playback_type"}, cellType.RequiredFields)
+}
+
+func TestInvalidConfigFollowsErrorPort(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	wait := newTestCell("1", "Wait1", "devs.WaitModel", map[string]types.ModelData{
+		"wait_seconds": types.ModelDataStr{Value: "soon"}})
+	setVars := newTestCell("2", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{{"name": "reason", "value": "{{Wait1.error}}"}}}})
+	connectTestCells(wait, "Error", setVars)
+	flow := &types.Flow{
+		Trace: types.NewFlowTrace(1),
+		Cells: []*types.Cell{wait, setVars}}
+
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), wait)
+
+	entries := flow.Trace.Snapshot().Entries
+	require.Len(t, entries, 2)
+	require.Equal(t, "Error", entries[0].Port)
+	reason, _ := flow.GetVariable("reason")
+	require.Equal(t, `field wait_seconds must be a whole number, got "soon"`, reason)
+}
@@ -0,0 +1,335 @@
+package mngrs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+const (
+	// how long an instance counts as alive after its last heartbeat
+	checkpointHeartbeatTTL = 30 * time.Second
+	// how long a claim on a checkpoint keeps other instances from resuming it
+	checkpointClaimTTL = time.Minute
+)
+
+var (
+	// how often instances look for calls of instances that went away
+	checkpointRescanInterval = checkpointHeartbeatTTL
+	// checkpoints this instance saved before it started are from before it
+	// restarted, the later ones are of calls it is running
+	processStarted = time.Now()
+)
+
+// cells that hand the call to a bridge. Their calls are left running when
+// their instance goes away since the bridge keeps working without the flow.
+var bridgingCells = map[string]bool{
+	"devs.DialModel":       true,
+	"devs.BridgeModel":     true,
+	"devs.ConferenceModel": true,
+}
+
+// CheckpointStore keeps the position of running calls so that another
+// instance can continue them when their instance goes away.
+type CheckpointStore interface {
+	// Save writes the given parts of a checkpoint, or all of them when no
+	// parts are given.
+	Save(checkpoint *types.Checkpoint, parts ...string) error
+	Delete(channelId string) error
+	List() ([]*types.Checkpoint, error)
+	// Claim returns true for the first instance to claim the checkpoint of a
+	// channel.
+	Claim(channelId string, instance string) (bool, error)
+	Heartbeat(instance string) error
+	Alive(instance string) (bool, error)
+}
+
+// RedisCheckpointStore keeps checkpoints in Redis, as a hash with a field per
+// part. Checkpoints expire after TTL so that calls that were never ended do
+// not pile up.
+type RedisCheckpointStore struct {
+	Client *redis.Client
+	TTL    time.Duration
+}
+
+func checkpointKey(channelId string) string {
+	return "flow_checkpoint:" + channelId
+}
+
+func (store *RedisCheckpointStore) Save(checkpoint *types.Checkpoint, parts ...string) error {
+	if len(parts) == 0 {
+		parts = types.CheckpointParts
+	}
+	fields := make(map[string]interface{})
+	for _, part := range parts {
+		body, err := checkpoint.Part(part)
+		if err != nil {
+			return err
+		}
+		fields[part] = body
+	}
+	ctx := context.Background()
+	key := checkpointKey(checkpoint.ChannelId)
+	_, err := store.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fields)
+		pipe.Expire(ctx, key, store.TTL)
+		return nil
+	})
+	return err
+}
+
+func (store *RedisCheckpointStore) Delete(channelId string) error {
+	return store.Client.Del(context.Background(), checkpointKey(channelId), "flow_checkpoint_claim:"+channelId).Err()
+}
+
+func (store *RedisCheckpointStore) List() ([]*types.Checkpoint, error) {
+	ctx := context.Background()
+	checkpoints := make([]*types.Checkpoint, 0)
+	iter := store.Client.Scan(ctx, 0, checkpointKey("*"), 100).Iterator()
+	for iter.Next(ctx) {
+		fields, err := store.Client.HGetAll(ctx, iter.Val()).Result()
+		// a hop that raced the end of its call leaves the cell behind
+		if err != nil || fields[types.CHECKPOINT_CALL] == "" {
+			continue
+		}
+		checkpoint, err := readCheckpoint(fields)
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not read checkpoint "+iter.Val()+": "+err.Error())
+			continue
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, iter.Err()
+}
+
+// readCheckpoint puts the saved parts of a checkpoint back together.
+func readCheckpoint(parts map[string]string) (*types.Checkpoint, error) {
+	var checkpoint types.Checkpoint
+	for _, part := range types.CheckpointParts {
+		body, ok := parts[part]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal([]byte(body), &checkpoint); err != nil {
+			return nil, err
+		}
+	}
+	return &checkpoint, nil
+}
+
+func (store *RedisCheckpointStore) Claim(channelId string, instance string) (bool, error) {
+	return store.Client.SetNX(context.Background(), "flow_checkpoint_claim:"+channelId, instance, checkpointClaimTTL).Result()
+}
+
+func (store *RedisCheckpointStore) Heartbeat(instance string) error {
+	return store.Client.Set(context.Background(), "processor_alive:"+instance, time.Now().Unix(), checkpointHeartbeatTTL).Err()
+}
+
+func (store *RedisCheckpointStore) Alive(instance string) (bool, error) {
+	count, err := store.Client.Exists(context.Background(), "processor_alive:"+instance).Result()
+	return count > 0, err
+}
+
+var (
+	checkpointsMu     sync.Mutex
+	checkpointsLoaded bool
+	checkpointStore   CheckpointStore
+	// the variables last saved for each call of this instance
+	savedVariables = make(map[string][]byte)
+)
+
+// SetCheckpointStore replaces the store used for checkpoints. A nil store
+// turns checkpoints off.
+func SetCheckpointStore(store CheckpointStore) {
+	checkpointsMu.Lock()
+	defer checkpointsMu.Unlock()
+	checkpointStore = store
+	checkpointsLoaded = true
+}
+
+func getCheckpointStore() CheckpointStore {
+	checkpointsMu.Lock()
+	defer checkpointsMu.Unlock()
+	if !checkpointsLoaded {
+		cfg := config.NewConfig()
+		if cfg.Checkpoints {
+			checkpointStore = &RedisCheckpointStore{Client: utils.CreateRDB(), TTL: cfg.MaxDuration}
+		}
+		checkpointsLoaded = true
+	}
+	return checkpointStore
+}
+
+// saveCheckpoint records that the call is about to execute the cell. Only
+// cells of the root flow that run on the channel of the call are recorded, so
+// a call in a subflow resumes at its Subflow cell. The flow is saved with the
+// first cell of the call, and the variables again only when they changed.
+func saveCheckpoint(flow *types.Flow, lineChannel *types.LineChannel, cell *types.Cell) {
+	store := getCheckpointStore()
+	if store == nil || flow.Parent != nil || !isFlowChannel(flow, lineChannel) {
+		return
+	}
+	checkpoint := types.NewCheckpoint(flow, cell, config.NewConfig().InstanceId)
+	variables, err := checkpoint.Part(types.CHECKPOINT_VARIABLES)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not save checkpoint of channel "+checkpoint.ChannelId+": "+err.Error())
+		return
+	}
+	checkpointsMu.Lock()
+	saved, ok := savedVariables[checkpoint.ChannelId]
+	checkpointsMu.Unlock()
+	parts := []string{types.CHECKPOINT_CELL}
+	if !ok {
+		parts = types.CheckpointParts
+	} else if !bytes.Equal(saved, variables) {
+		parts = append(parts, types.CHECKPOINT_VARIABLES)
+	}
+	if err := store.Save(checkpoint, parts...); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not save checkpoint of channel "+checkpoint.ChannelId+": "+err.Error())
+		return
+	}
+	checkpointsMu.Lock()
+	savedVariables[checkpoint.ChannelId] = variables
+	checkpointsMu.Unlock()
+}
+
+func isFlowChannel(flow *types.Flow, lineChannel *types.LineChannel) bool {
+	if flow.Channel == nil || flow.Channel.Channel == nil || lineChannel == nil || lineChannel.Channel == nil {
+		return false
+	}
+	return flow.Channel.Channel.ID() == lineChannel.Channel.ID()
+}
+
+// deleteCheckpoint is called once the call of a flow ends.
+func deleteCheckpoint(flow *types.Flow) {
+	store := getCheckpointStore()
+	if store == nil || flow.Parent != nil || flow.Channel == nil || flow.Channel.Channel == nil {
+		return
+	}
+	checkpointsMu.Lock()
+	delete(savedVariables, flow.Channel.Channel.ID())
+	checkpointsMu.Unlock()
+	if err := store.Delete(flow.Channel.Channel.ID()); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not delete checkpoint of channel "+flow.Channel.Channel.ID()+": "+err.Error())
+	}
+}
+
+// StartCheckpointHeartbeat marks this instance as alive until the context is
+// done, which keeps other instances from resuming its calls.
+func StartCheckpointHeartbeat(ctx context.Context) {
+	store := getCheckpointStore()
+	if store == nil {
+		return
+	}
+	instance := config.NewConfig().InstanceId
+	ticker := time.NewTicker(checkpointHeartbeatTTL / 3)
+	defer ticker.Stop()
+	for {
+		if err := store.Heartbeat(instance); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not send checkpoint heartbeat: "+err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WatchOrphanedFlows resumes the calls of instances that went away until the
+// context is done. Checkpoints are scanned again every heartbeat TTL, since
+// an instance that is replaced during a rolling deploy keeps sending
+// heartbeats for a while after its replacement started. Claims keep a call
+// from being resumed twice.
+func WatchOrphanedFlows(cl ari.Client, ctx context.Context, onResume func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel)) {
+	if getCheckpointStore() == nil {
+		return
+	}
+	ticker := time.NewTicker(checkpointRescanInterval)
+	defer ticker.Stop()
+	for {
+		ResumeFlows(cl, ctx, onResume)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ResumeFlows continues the calls of instances that went away. Each call
+// restarts at the cell it was executing, so a prompt that was playing is
+// played again. Calls that were handed to a bridge are left running as they
+// are. onResume is called for every flow before it is started, with the
+// context of its call.
+func ResumeFlows(cl ari.Client, ctx context.Context, onResume func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel)) {
+	store := getCheckpointStore()
+	if store == nil {
+		return
+	}
+	checkpoints, err := store.List()
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not list checkpoints: "+err.Error())
+		return
+	}
+	instance := config.NewConfig().InstanceId
+	for _, checkpoint := range checkpoints {
+		resumeFlow(cl, ctx, store, instance, checkpoint, onResume)
+	}
+}
+
+func resumeFlow(cl ari.Client, ctx context.Context, store CheckpointStore, instance string, checkpoint *types.Checkpoint, onResume func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel)) {
+	channelId := checkpoint.ChannelId
+	if checkpoint.Instance == instance {
+		if !checkpoint.SavedAt.Before(processStarted) {
+			return
+		}
+	} else {
+		alive, err := store.Alive(checkpoint.Instance)
+		if err != nil || alive {
+			return
+		}
+	}
+	claimed, err := store.Claim(channelId, instance)
+	if err != nil || !claimed {
+		return
+	}
+
+	handle := cl.Channel().Get(ari.NewKey(ari.ChannelKey, channelId))
+	if _, err := handle.Data(); err != nil {
+		helpers.Log(logrus.DebugLevel, "channel "+channelId+" of checkpoint is gone")
+		store.Delete(channelId)
+		return
+	}
+	if bridgingCells[checkpoint.CellType] {
+		helpers.Log(logrus.InfoLevel, "leaving bridged channel "+channelId+" running")
+		store.Delete(channelId)
+		return
+	}
+
+	lineChannel := &types.LineChannel{Channel: handle}
+	flow, cell, err := checkpoint.Restore(cl, lineChannel)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not restore checkpoint of channel "+channelId+": "+err.Error())
+		store.Delete(channelId)
+		return
+	}
+	helpers.Log(logrus.InfoLevel, "resuming channel "+channelId+" at cell "+cell.Cell.Name)
+	callCtx := NewCallContext(ctx, lineChannel)
+	if onResume != nil {
+		onResume(callCtx, flow, lineChannel)
+	}
+	flow.Go(func() {
+		ProcessFlow(cl, callCtx, flow, lineChannel, make(map[string]string), cell)
+	})
+}
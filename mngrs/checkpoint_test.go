@@ -0,0 +1,288 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/stdbus"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+)
+
+// waitFlow sets a variable and waits, which gives the test a call to resume.
+const waitFlow = `{
+  "graph": {"cells": [
+    {"id": "launch", "name": "Launch", "type": "devs.LaunchModel"},
+    {"id": "vars", "name": "SetVars1", "type": "devs.SetVariablesModel"},
+    {"id": "wait", "name": "Wait1", "type": "devs.WaitModel"},
+    {"id": "l1", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "vars", "port": "In"}},
+    {"id": "l2", "type": "devs.FlowLink", "source": {"id": "vars", "port": "Completed"}, "target": {"id": "wait", "port": "In"}}
+  ]},
+  "models": [
+    {"id": "launch", "name": "Launch", "data": {}},
+    {"id": "vars", "name": "SetVars1", "data": {"variables": [{"name": "language", "value": "fr"}]}},
+    {"id": "wait", "name": "Wait1", "data": {"wait_seconds": "30"}}
+  ]
+}`
+
+type memoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]map[string]string
+	claims      map[string]string
+	alive       map[string]bool
+	// how often each part was written
+	writes map[string]int
+}
+
+func newMemoryCheckpointStore() *memoryCheckpointStore {
+	return &memoryCheckpointStore{
+		checkpoints: make(map[string]map[string]string),
+		claims:      make(map[string]string),
+		alive:       make(map[string]bool),
+		writes:      make(map[string]int)}
+}
+
+func (store *memoryCheckpointStore) Save(checkpoint *types.Checkpoint, parts ...string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(parts) == 0 {
+		parts = types.CheckpointParts
+	}
+	fields, ok := store.checkpoints[checkpoint.ChannelId]
+	if !ok {
+		fields = make(map[string]string)
+		store.checkpoints[checkpoint.ChannelId] = fields
+	}
+	for _, part := range parts {
+		body, err := checkpoint.Part(part)
+		if err != nil {
+			return err
+		}
+		fields[part] = string(body)
+		store.writes[part]++
+	}
+	return nil
+}
+
+func (store *memoryCheckpointStore) Delete(channelId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.checkpoints, channelId)
+	delete(store.claims, channelId)
+	return nil
+}
+
+func (store *memoryCheckpointStore) List() ([]*types.Checkpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	checkpoints := make([]*types.Checkpoint, 0)
+	for _, fields := range store.checkpoints {
+		checkpoint, err := readCheckpoint(fields)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+func (store *memoryCheckpointStore) Claim(channelId string, instance string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.claims[channelId]; ok {
+		return false, nil
+	}
+	store.claims[channelId] = instance
+	return true, nil
+}
+
+func (store *memoryCheckpointStore) Heartbeat(instance string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.alive[instance] = true
+	return nil
+}
+
+func (store *memoryCheckpointStore) Alive(instance string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.alive[instance], nil
+}
+
+func (store *memoryCheckpointStore) get(channelId string) (types.Checkpoint, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	fields, ok := store.checkpoints[channelId]
+	if !ok {
+		return types.Checkpoint{}, false
+	}
+	checkpoint, err := readCheckpoint(fields)
+	if err != nil {
+		return types.Checkpoint{}, false
+	}
+	return *checkpoint, true
+}
+
+// liveChannel is a channel namespace for channels that exist.
+type liveChannel struct {
+	testChannel
+	ids map[string]bool
+	bus ari.Bus
+}
+
+func newLiveChannel(ids ...string) *liveChannel {
+	ch := &liveChannel{ids: make(map[string]bool), bus: stdbus.New()}
+	for _, id := range ids {
+		ch.ids[id] = true
+	}
+	return ch
+}
+
+func (ch *liveChannel) Subscribe(key *ari.Key, n ...string) ari.Subscription {
+	return ch.bus.Subscribe(key, n...)
+}
+
+func (ch *liveChannel) Data(key *ari.Key) (*ari.ChannelData, error) {
+	if !ch.ids[key.ID] {
+		return nil, errors.New("channel not found")
+	}
+	return &ari.ChannelData{ID: key.ID}, nil
+}
+
+func (ch *liveChannel) Get(key *ari.Key) *ari.ChannelHandle {
+	return ari.NewChannelHandle(key, ch, nil)
+}
+
+type testClient struct {
+	ari.Client
+	channel ari.Channel
+}
+
+func (cl *testClient) Channel() ari.Channel {
+	return cl.channel
+}
+
+func TestCheckpointResume(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	store := newMemoryCheckpointStore()
+	SetCheckpointStore(store)
+	t.Cleanup(func() {
+		SetCheckpointStore(nil)
+	})
+	channel := newLiveChannel("resume-1")
+	client := &testClient{channel: channel}
+
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal([]byte(waitFlow), &vars))
+	lineChannel := &types.LineChannel{Channel: channel.Get(ari.NewKey(ari.ChannelKey, "resume-1"))}
+	flow := types.NewFlow(3, types.NewUser(1, 2, "test"), &vars, lineChannel, nil, client)
+	flow.RootCall = &types.Call{CallId: 8, Params: &types.CallParams{From: "100"}}
+	ctx, crash := context.WithCancel(context.Background())
+	go ProcessFlow(client, ctx, flow, lineChannel, make(map[string]string), flow.Cells[0])
+	require.Eventually(t, func() bool {
+		checkpoint, ok := store.get("resume-1")
+		return ok && checkpoint.CellId == "wait"
+	}, time.Second, 10*time.Millisecond)
+	crash()
+	// the flow is saved once, the variables again after SetVars1 set them
+	store.mu.Lock()
+	require.Equal(t, 1, store.writes[types.CHECKPOINT_CALL])
+	require.Equal(t, 2, store.writes[types.CHECKPOINT_VARIABLES])
+	require.Equal(t, 3, store.writes[types.CHECKPOINT_CELL])
+	store.mu.Unlock()
+
+	// the instance is still alive, so its calls are left alone
+	checkpoint, _ := store.get("resume-1")
+	checkpoint.Instance = "processor-1"
+	require.NoError(t, store.Save(&checkpoint))
+	require.NoError(t, store.Heartbeat("processor-1"))
+	resumed := make(chan *types.Flow, 2)
+	onResume := func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel) {
+		resumed <- flow
+	}
+	ResumeFlows(client, context.Background(), onResume)
+	require.Len(t, resumed, 0)
+
+	store.mu.Lock()
+	delete(store.alive, "processor-1")
+	store.mu.Unlock()
+	ResumeFlows(client, context.Background(), onResume)
+	ResumeFlows(client, context.Background(), onResume)
+	require.Len(t, resumed, 1)
+	restored := <-resumed
+	require.Eventually(t, func() bool {
+		return len(restored.Trace.Snapshot().Entries) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "Wait1", restored.Trace.Snapshot().Entries[0].CellName)
+	language, _ := restored.GetVariable("language")
+	require.Equal(t, "fr", language)
+	require.Equal(t, 8, restored.RootCall.CallId)
+
+	restored.CancelRunners()
+	require.NoError(t, EndFlow(restored))
+	_, ok := store.get("resume-1")
+	require.False(t, ok)
+}
+
+func TestCheckpointLeavesBridgedCalls(t *testing.T) {
+	store := newMemoryCheckpointStore()
+	SetCheckpointStore(store)
+	t.Cleanup(func() {
+		SetCheckpointStore(nil)
+	})
+	channel := newLiveChannel("bridged-1")
+	client := &testClient{channel: channel}
+	require.NoError(t, store.Save(&types.Checkpoint{ChannelId: "bridged-1", Instance: "gone", CellType: "devs.BridgeModel"}))
+	require.NoError(t, store.Save(&types.Checkpoint{ChannelId: "hungup-1", Instance: "gone", CellType: "devs.WaitModel"}))
+
+	ResumeFlows(client, context.Background(), func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel) {
+		t.Fatal("no flow should be resumed")
+	})
+	checkpoints, _ := store.List()
+	require.Len(t, checkpoints, 0)
+	require.Equal(t, int32(0), channel.hangups)
+}
+
+func TestCheckpointRescan(t *testing.T) {
+	store := newMemoryCheckpointStore()
+	SetCheckpointStore(store)
+	interval := checkpointRescanInterval
+	checkpointRescanInterval = 20 * time.Millisecond
+	t.Cleanup(func() {
+		SetCheckpointStore(nil)
+		checkpointRescanInterval = interval
+	})
+	channel := newLiveChannel("bridged-2")
+	client := &testClient{channel: channel}
+	require.NoError(t, store.Heartbeat("old-pod"))
+	require.NoError(t, store.Save(&types.Checkpoint{ChannelId: "bridged-2", Instance: "old-pod", CellType: "devs.BridgeModel"}))
+	// a call this instance started is not an orphan
+	require.NoError(t, store.Save(&types.Checkpoint{ChannelId: "own-1", Instance: config.NewConfig().InstanceId, CellType: "devs.WaitModel", SavedAt: time.Now()}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchOrphanedFlows(client, ctx, func(callCtx context.Context, flow *types.Flow, lineChannel *types.LineChannel) {
+		t.Error("no flow should be resumed")
+	})
+	time.Sleep(50 * time.Millisecond)
+	_, ok := store.get("bridged-2")
+	require.True(t, ok)
+
+	// the old instance stops after this one started
+	store.mu.Lock()
+	delete(store.alive, "old-pod")
+	store.mu.Unlock()
+	require.Eventually(t, func() bool {
+		_, ok := store.get("bridged-2")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	_, ok = store.get("own-1")
+	require.True(t, ok)
+}
@@ -1,24 +1,626 @@
 package mngrs
 
 import (
-	//"context"
-	//"github.com/CyCoreSystems/ari/v5"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/api"
 	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
 )
 
+const (
+	CONFERENCE_MODERATOR   = "moderator"
+	CONFERENCE_PARTICIPANT = "participant"
+)
+
+// conferencePinAttempts is how many times a caller can enter a PIN before
+// the call leaves through "Invalid PIN".
+const conferencePinAttempts = 3
+
+// conferencePinTimeout is how long a caller has to enter a PIN.
+var conferencePinTimeout = 10 * time.Second
+
+const (
+	// conferenceClaimTTL is how long the caller that starts a conference has
+	// to add it before another caller can start it.
+	conferenceClaimTTL = 15 * time.Second
+	// conferenceStartPoll is how often the other callers check whether the
+	// conference started.
+	conferenceStartPoll = 100 * time.Millisecond
+)
+
+// createConference creates the conferences Conference cells start.
+var createConference = api.CreateConference
+
+var ErrConferenceFull = errors.New("conference is full")
+
+// ConferenceStore keeps the bridges of the running conferences and their
+// members, which the instances of the processor share. Conferences are
+// scoped to a workspace.
+type ConferenceStore interface {
+	// Get returns the running conference with the name, or nil when there is
+	// none.
+	Get(client ari.Client, user *types.User, name string) (*types.LineConference, error)
+	// Claim returns true for the one caller that gets to start a conference
+	// that is not running. The claim ends after ttl or once the conference
+	// is added.
+	Claim(workspaceId int, name string, ttl time.Duration) (bool, error)
+	// Add records the bridge of a conference that started.
+	Add(client ari.Client, user *types.User, name string, conf *types.LineConference) error
+	// Remove forgets a conference that ended along with its members.
+	Remove(workspaceId int, name string) error
+	// Join adds a member with the role to a conference. It fails with
+	// ErrConferenceFull when maxMembers are in it. A maxMembers of zero does
+	// not limit the conference.
+	Join(workspaceId int, name string, channelId string, role string, maxMembers int) error
+	// Leave removes a member and returns how many members and moderators are
+	// left.
+	Leave(workspaceId int, name string, channelId string) (int, int, error)
+	// Moderators returns how many moderators are in a conference.
+	Moderators(workspaceId int, name string) (int, error)
+}
+
+// RedisConferenceStore keeps the bridges of conferences in the conference
+// cache and their members in hashes of their roles.
+type RedisConferenceStore struct {
+	Client *redis.Client
+}
+
+func conferenceMembersKey(workspaceId int, name string) string {
+	return "conference_members:" + strconv.Itoa(workspaceId) + ":" + name
+}
+
+func conferenceClaimKey(workspaceId int, name string) string {
+	return "conference_claim:" + strconv.Itoa(workspaceId) + ":" + name
+}
+
+var conferenceJoinScript = redis.NewScript(`
+local max = tonumber(ARGV[1])
+if max > 0 and redis.call('HEXISTS', KEYS[1], ARGV[2]) == 0 and redis.call('HLEN', KEYS[1]) >= max then
+  return -1
+end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+return 1
+`)
+
+var conferenceLeaveScript = redis.NewScript(`
+redis.call('HDEL', KEYS[1], ARGV[1])
+local roles = redis.call('HVALS', KEYS[1])
+local moderators = 0
+for _, role in ipairs(roles) do
+  if role == ARGV[2] then
+    moderators = moderators + 1
+  end
+end
+return {#roles, moderators}
+`)
+
+func (store *RedisConferenceStore) Get(client ari.Client, user *types.User, name string) (*types.LineConference, error) {
+	conf, err := utils.GetConfBridge(client, user, name)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	moderators, err := store.Moderators(user.Workspace.Id, name)
+	if err != nil {
+		return nil, err
+	}
+	conf.ModeratorInConf = moderators > 0
+	return conf, nil
+}
+
+func (store *RedisConferenceStore) Claim(workspaceId int, name string, ttl time.Duration) (bool, error) {
+	return store.Client.SetNX(context.Background(), conferenceClaimKey(workspaceId, name), 1, ttl).Result()
+}
+
+func (store *RedisConferenceStore) Add(client ari.Client, user *types.User, name string, conf *types.LineConference) error {
+	if _, err := utils.AddConfBridge(client, strconv.Itoa(user.Workspace.Id), name, conf); err != nil {
+		return err
+	}
+	return store.Client.Del(context.Background(), conferenceClaimKey(user.Workspace.Id, name)).Err()
+}
+
+func (store *RedisConferenceStore) Remove(workspaceId int, name string) error {
+	if err := utils.RemoveConfBridge(strconv.Itoa(workspaceId), name); err != nil {
+		return err
+	}
+	return store.Client.Del(context.Background(), conferenceMembersKey(workspaceId, name)).Err()
+}
+
+func (store *RedisConferenceStore) Join(workspaceId int, name string, channelId string, role string, maxMembers int) error {
+	keys := []string{conferenceMembersKey(workspaceId, name)}
+	joined, err := conferenceJoinScript.Run(context.Background(), store.Client, keys, maxMembers, channelId, role).Int()
+	if err != nil {
+		return err
+	}
+	if joined < 0 {
+		return ErrConferenceFull
+	}
+	return nil
+}
+
+func (store *RedisConferenceStore) Leave(workspaceId int, name string, channelId string) (int, int, error) {
+	keys := []string{conferenceMembersKey(workspaceId, name)}
+	counts, err := conferenceLeaveScript.Run(context.Background(), store.Client, keys, channelId, CONFERENCE_MODERATOR).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(counts[0]), int(counts[1]), nil
+}
+
+func (store *RedisConferenceStore) Moderators(workspaceId int, name string) (int, error) {
+	roles, err := store.Client.HVals(context.Background(), conferenceMembersKey(workspaceId, name)).Result()
+	if err != nil {
+		return 0, err
+	}
+	moderators := 0
+	for _, role := range roles {
+		if role == CONFERENCE_MODERATOR {
+			moderators++
+		}
+	}
+	return moderators, nil
+}
+
+var (
+	conferenceMu    sync.Mutex
+	conferenceStore ConferenceStore
+)
+
+// SetConferenceStore replaces the store used for conferences.
+func SetConferenceStore(store ConferenceStore) {
+	conferenceMu.Lock()
+	defer conferenceMu.Unlock()
+	conferenceStore = store
+}
+
+func getConferenceStore() ConferenceStore {
+	conferenceMu.Lock()
+	defer conferenceMu.Unlock()
+	if conferenceStore == nil {
+		conferenceStore = &RedisConferenceStore{Client: utils.CreateRDB()}
+	}
+	return conferenceStore
+}
+
+// conferenceBridgeId returns the id of the bridge of a conference, so that
+// callers who start the same conference at once end up in the same bridge.
+func conferenceBridgeId(workspaceId int, name string) string {
+	return "conference-" + strconv.Itoa(workspaceId) + "-" + hex.EncodeToString([]byte(name))
+}
+
+// ConferenceConfig is the config of Conference cells.
+type ConferenceConfig struct {
+	Name string `cell:"conference_name,required"`
+	// Pin and ModeratorPin are asked for when either is set. Callers who
+	// enter the moderator PIN join as moderators, the others need the PIN
+	// when there is one.
+	Pin          string `cell:"pin"`
+	ModeratorPin string `cell:"moderator_pin"`
+	PinPrompt    string `cell:"pin_prompt" default:"Please enter the conference PIN followed by the pound key."`
+	InvalidPin   string `cell:"invalid_pin_prompt" default:"That PIN is not valid."`
+	JoinMessage  string `cell:"join_message" default:"A participant has joined the conference."`
+	LeaveMessage string `cell:"leave_message" default:"A participant has left the conference."`
+	// the text to speech settings of the prompts and announcements, like the
+	// ones of Playback cells
+	TextGender   string `cell:"text_gender"`
+	Voice        string `cell:"voice"`
+	TextLanguage string `cell:"text_language" default:"en-US"`
+	// Moderator makes every caller of the cell a moderator.
+	Moderator              bool   `cell:"moderator"`
+	WaitForModerator       bool   `cell:"wait_for_moderator"`
+	MusicClass             string `cell:"music_class" default:"default"`
+	Announce               bool   `cell:"announce_join_leave"`
+	MuteOnEntry            bool   `cell:"mute_on_entry"`
+	EndWhenModeratorLeaves bool   `cell:"end_on_moderator_leave"`
+	MaxParticipants        int    `cell:"max_participants"`
+}
+
+func (conf *ConferenceConfig) Validate() error {
+	if strings.Trim(conf.Pin, "0123456789") != "" {
+		return &CellConfigError{Field: "pin", Message: "must only have digits"}
+	}
+	if strings.Trim(conf.ModeratorPin, "0123456789") != "" {
+		return &CellConfigError{Field: "moderator_pin", Message: "must only have digits"}
+	}
+	if conf.Pin != "" && conf.Pin == conf.ModeratorPin {
+		return &CellConfigError{Field: "moderator_pin", Message: "must not be the same as the pin"}
+	}
+	if conf.MaxParticipants < 0 {
+		return &CellConfigError{Field: "max_participants", Message: "can not be negative"}
+	}
+	return nil
+}
+
+// say returns the sound file of a prompt or announcement of the cell.
+func (conf *ConferenceConfig) say(text string) (string, error) {
+	return utils.StartTTS(text, conf.TextGender, conf.Voice, conf.TextLanguage)
+}
+
+// role returns the role of a caller who entered the PIN, or an empty string
+// when the PIN is not valid.
+func (conf *ConferenceConfig) role(pin string) string {
+	if conf.ModeratorPin != "" && pin == conf.ModeratorPin {
+		return CONFERENCE_MODERATOR
+	}
+	if conf.Pin == "" || pin == conf.Pin {
+		return CONFERENCE_PARTICIPANT
+	}
+	return ""
+}
+
 type ConferenceManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
 }
 
 func NewConferenceManager(mngrCtx *types.Context, flow *types.Flow) *ConferenceManager {
-	//rootCtx, _ := context.WithCancel(context.Background())
 	item := ConferenceManager{
 		ManagerContext: mngrCtx,
 		Flow:           flow}
 	return &item
 }
+
 func (man *ConferenceManager) StartProcessing() {
-	//log := man.ManagerContext.Log
+	goCell(man.ManagerContext, man.processConference)
+}
+
+// processConference checks the PIN of the caller and joins it to the
+// conference, which is started by the first caller. Participants wait for a
+// moderator with music on hold when the cell asks for it. The cell ends with
+// the call, or follows "Conference Ended" when the conference ends first.
+func (man *ConferenceManager) processConference() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+	channel := ctx.Channel
+	var conf ConferenceConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid conference cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+
+	role, err := man.authenticate(&conf)
+	if ctx.Context.Err() != nil {
+		helpers.Log(logrus.DebugLevel, "conference cancelled")
+		return
+	}
+	if err != nil {
+		failCell(ctx, err)
+		return
+	}
+	if role == "" {
+		helpers.Log(logrus.DebugLevel, "caller did not enter a valid conference PIN")
+		man.exit("Invalid PIN")
+		return
+	}
+	cell.SetEventVar("role", role)
+
+	store := getConferenceStore()
+	workspaceId := flow.User.Workspace.Id
+	err = store.Join(workspaceId, conf.Name, channel.Channel.ID(), role, conf.MaxParticipants)
+	if err == ErrConferenceFull {
+		helpers.Log(logrus.DebugLevel, "conference "+conf.Name+" is full")
+		man.exit("Conference Full")
+		return
+	}
+	if err != nil {
+		failCell(ctx, err)
+		return
+	}
+	conference, err := man.startConference(&conf)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not start conference "+conf.Name+": "+err.Error())
+		store.Leave(workspaceId, conf.Name, channel.Channel.ID())
+		failCell(ctx, err)
+		return
+	}
+	cell.SetEventVar("conference_id", conference.Id)
+	defer man.leaveConference(&conf, conference, role)
+
+	bridge := conference.Bridge.Bridge
+	if role == CONFERENCE_PARTICIPANT && conf.WaitForModerator && !conference.ModeratorInConf {
+		if !man.waitForModerator(&conf, conference) {
+			if ctx.Context.Err() == nil {
+				man.exit("Conference Ended")
+			}
+			return
+		}
+	}
+
+	leftSub := channel.Channel.Subscribe(ari.Events.ChannelLeftBridge)
+	defer leftSub.Cancel()
+	muted := false
+	if conf.MuteOnEntry && role == CONFERENCE_PARTICIPANT {
+		if err := channel.Channel.Mute(ari.DirectionIn); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not mute conference participant: "+err.Error())
+		} else {
+			muted = true
+		}
+	}
+	// muted participants leave unmuted, before the flow moves on
+	unmute := func() {
+		if !muted {
+			return
+		}
+		muted = false
+		if err := channel.Channel.Unmute(ari.DirectionIn); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not unmute conference participant: "+err.Error())
+		}
+	}
+	defer unmute()
+	if conf.Announce {
+		man.announce(&conf, conference, conf.JoinMessage)
+	}
+	if err := bridge.AddChannel(channel.Channel.ID()); err != nil {
+		unmute()
+		failCell(ctx, err)
+		return
+	}
+	conference.Participants = append(conference.Participants, channel)
+	helpers.Log(logrus.DebugLevel, "joined conference "+conf.Name+" as "+role)
+
+	for {
+		select {
+		case <-ctx.Context.Done():
+			helpers.Log(logrus.DebugLevel, "left conference "+conf.Name)
+			return
+		case e, ok := <-leftSub.Events():
+			if !ok {
+				return
+			}
+			if e.(*ari.ChannelLeftBridge).Bridge.ID != bridge.ID() {
+				continue
+			}
+			helpers.Log(logrus.DebugLevel, "conference "+conf.Name+" ended")
+			unmute()
+			man.exit("Conference Ended")
+			return
+		}
+	}
+}
+
+// authenticate asks for the PIN when the conference has one and returns the
+// role of the caller, or an empty string when no valid PIN was entered.
+func (man *ConferenceManager) authenticate(conf *ConferenceConfig) (string, error) {
+	if conf.Moderator {
+		return CONFERENCE_MODERATOR, nil
+	}
+	if conf.Pin == "" && conf.ModeratorPin == "" {
+		return CONFERENCE_PARTICIPANT, nil
+	}
+	for attempt := 0; attempt != conferencePinAttempts; attempt++ {
+		pin, err := man.collectPin(conf)
+		if err != nil {
+			return "", err
+		}
+		if role := conf.role(pin); role != "" {
+			return role, nil
+		}
+		file, err := conf.say(conf.InvalidPin)
+		if err != nil {
+			return "", err
+		}
+		NewPlaybackManager(man.ManagerContext, man.Flow).beginPrompt(file)
+	}
+	return "", nil
+}
+
+// collectPin plays the PIN prompt and returns the digits entered before the
+// pound key or the PIN timeout.
+func (man *ConferenceManager) collectPin(conf *ConferenceConfig) (string, error) {
+	ctx := man.ManagerContext
+	channel := ctx.Channel
+	dtmfSub := channel.Channel.Subscribe(ari.Events.ChannelDtmfReceived)
+	defer dtmfSub.Cancel()
+
+	file, err := conf.say(conf.PinPrompt)
+	if err != nil {
+		return "", err
+	}
+	playback, err := channel.Channel.Play(rid.New(rid.Playback), "sound:"+file)
+	if err != nil {
+		return "", err
+	}
+	playing := true
+	stop := func() {
+		if playing {
+			playing = false
+			playback.Stop()
+		}
+	}
+	defer stop()
+	timeout := time.NewTimer(conferencePinTimeout)
+	defer timeout.Stop()
+	pin := ""
+	for {
+		select {
+		case <-ctx.Context.Done():
+			return "", ctx.Context.Err()
+		case <-timeout.C:
+			return pin, nil
+		case e, ok := <-dtmfSub.Events():
+			if !ok {
+				return "", errors.New("DTMF subscription closed")
+			}
+			stop()
+			digit := e.(*ari.ChannelDtmfReceived).Digit
+			if digit == "#" {
+				return pin, nil
+			}
+			pin += digit
+		}
+	}
+}
+
+// startConference returns the running conference, or creates it along with
+// its bridge for the first caller. Callers that start the conference at the
+// same time wait for the one that claimed it.
+func (man *ConferenceManager) startConference(conf *ConferenceConfig) (*types.LineConference, error) {
+	ctx := man.ManagerContext
+	user := ctx.Flow.User
+	store := getConferenceStore()
+	for {
+		conference, err := store.Get(ctx.Client, user, conf.Name)
+		if err != nil {
+			return nil, err
+		}
+		if conference != nil {
+			if _, err := conference.Bridge.Bridge.Data(); err == nil {
+				return conference, nil
+			}
+			helpers.Log(logrus.DebugLevel, "bridge of conference "+conf.Name+" is gone")
+		}
+		claimed, err := store.Claim(user.Workspace.Id, conf.Name, conferenceClaimTTL)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return man.createConference(conf)
+		}
+		select {
+		case <-ctx.Context.Done():
+			return nil, ctx.Context.Err()
+		case <-time.After(conferenceStartPoll):
+		}
+	}
+}
+
+// createConference creates the conference that the caller claimed.
+func (man *ConferenceManager) createConference(conf *ConferenceConfig) (*types.LineConference, error) {
+	ctx := man.ManagerContext
+	user := ctx.Flow.User
+	store := getConferenceStore()
+	resp, err := createConference(user.Workspace.Id, conf.Name)
+	if err != nil {
+		return nil, err
+	}
+	key := ari.NewKey(ari.BridgeKey, conferenceBridgeId(user.Workspace.Id, conf.Name))
+	bridge, err := ctx.Client.Bridge().Create(key, "mixing", key.ID)
+	if err != nil {
+		return nil, err
+	}
+	conference := types.NewConference(resp.Id, user, &types.LineBridge{Bridge: bridge})
+	if err := store.Add(ctx.Client, user, conf.Name, conference); err != nil {
+		return nil, err
+	}
+	moderators, err := store.Moderators(user.Workspace.Id, conf.Name)
+	if err != nil {
+		return nil, err
+	}
+	conference.ModeratorInConf = moderators > 0
+	helpers.Log(logrus.DebugLevel, "started conference "+conf.Name)
+	return conference, nil
+}
+
+// waitForModerator plays music on hold to a participant until a moderator
+// joins. It returns false when the call or the conference ends first.
+func (man *ConferenceManager) waitForModerator(conf *ConferenceConfig, conference *types.LineConference) bool {
+	ctx := man.ManagerContext
+	channel := ctx.Channel
+	workspaceId := ctx.Flow.User.Workspace.Id
+	sub := conference.Bridge.Bridge.Subscribe(ari.Events.ChannelEnteredBridge, ari.Events.BridgeDestroyed)
+	defer sub.Cancel()
+
+	conference.WaitingParticipants = append(conference.WaitingParticipants, channel)
+	helpers.Log(logrus.DebugLevel, "waiting for the moderator of conference "+conf.Name)
+	channel.Channel.MOH(conf.MusicClass)
+	defer channel.Channel.StopMOH()
+	// a moderator may have joined while subscribing
+	if moderators, err := getConferenceStore().Moderators(workspaceId, conf.Name); err == nil && moderators > 0 {
+		return true
+	}
+	for {
+		select {
+		case <-ctx.Context.Done():
+			return false
+		case e, ok := <-sub.Events():
+			if !ok || e.GetType() == ari.Events.BridgeDestroyed {
+				return false
+			}
+			moderators, err := getConferenceStore().Moderators(workspaceId, conf.Name)
+			if err != nil {
+				helpers.Log(logrus.ErrorLevel, "could not get moderators of conference "+conf.Name+": "+err.Error())
+				continue
+			}
+			if moderators > 0 {
+				conference.ModeratorInConf = true
+				return true
+			}
+		}
+	}
+}
+
+// leaveConference removes the caller from the conference. The conference
+// ends with its last member, or with its last moderator when the cell asks
+// for it.
+func (man *ConferenceManager) leaveConference(conf *ConferenceConfig, conference *types.LineConference, role string) {
+	ctx := man.ManagerContext
+	workspaceId := ctx.Flow.User.Workspace.Id
+	members, moderators, err := getConferenceStore().Leave(workspaceId, conf.Name, ctx.Channel.Channel.ID())
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not leave conference "+conf.Name+": "+err.Error())
+		return
+	}
+	switch {
+	case members == 0:
+		man.endConference(conf, conference)
+	case role == CONFERENCE_MODERATOR && moderators == 0 && conf.EndWhenModeratorLeaves:
+		helpers.Log(logrus.DebugLevel, "last moderator left conference "+conf.Name)
+		man.endConference(conf, conference)
+	case conf.Announce:
+		man.announce(conf, conference, conf.LeaveMessage)
+	}
+}
+
+// endConference takes the members out of the conference, which moves them on
+// to "Conference Ended", and deletes its bridge unless it is already gone.
+func (man *ConferenceManager) endConference(conf *ConferenceConfig, conference *types.LineConference) {
+	bridge := conference.Bridge.Bridge
+	if data, err := bridge.Data(); err == nil {
+		for _, id := range data.ChannelIDs {
+			bridge.RemoveChannel(id)
+		}
+		if err := bridge.Delete(); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not delete bridge of conference "+conf.Name+": "+err.Error())
+		}
+	}
+	if err := getConferenceStore().Remove(man.ManagerContext.Flow.User.Workspace.Id, conf.Name); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not remove conference "+conf.Name+": "+err.Error())
+	}
+	helpers.Log(logrus.DebugLevel, "ended conference "+conf.Name)
+}
+
+// announce plays a message to the members of the conference.
+func (man *ConferenceManager) announce(conf *ConferenceConfig, conference *types.LineConference, message string) {
+	file, err := conf.say(message)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not create conference announcement: "+err.Error())
+		return
+	}
+	if _, err := conference.Bridge.Bridge.Play(rid.New(rid.Playback), "sound:"+file); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not play conference announcement: "+err.Error())
+	}
+}
+
+func (man *ConferenceManager) exit(port string) {
+	ctx := man.ManagerContext
+	next, _ := utils.FindLinkByName(ctx.Cell.SourceLinks, "source", port)
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    next}
 }
@@ -0,0 +1,160 @@
+package mngrs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/types"
+)
+
+// memoryConferenceStore keeps the conferences of a test, without members.
+type memoryConferenceStore struct {
+	mu          sync.Mutex
+	conferences map[string]*types.LineConference
+	claimed     map[string]bool
+}
+
+func (store *memoryConferenceStore) Get(client ari.Client, user *types.User, name string) (*types.LineConference, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.conferences[name], nil
+}
+
+func (store *memoryConferenceStore) Claim(workspaceId int, name string, ttl time.Duration) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.claimed[name] {
+		return false, nil
+	}
+	store.claimed[name] = true
+	return true, nil
+}
+
+func (store *memoryConferenceStore) Add(client ari.Client, user *types.User, name string, conf *types.LineConference) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.conferences[name] = conf
+	delete(store.claimed, name)
+	return nil
+}
+
+func (store *memoryConferenceStore) Remove(workspaceId int, name string) error {
+	return nil
+}
+
+func (store *memoryConferenceStore) Join(workspaceId int, name string, channelId string, role string, maxMembers int) error {
+	return nil
+}
+
+func (store *memoryConferenceStore) Leave(workspaceId int, name string, channelId string) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (store *memoryConferenceStore) Moderators(workspaceId int, name string) (int, error) {
+	return 0, nil
+}
+
+// testBridges is a bridge namespace whose bridges exist once created.
+type testBridges struct {
+	ari.Bridge
+}
+
+func (bridges *testBridges) Create(key *ari.Key, btype string, name string) (*ari.BridgeHandle, error) {
+	return ari.NewBridgeHandle(key, bridges, nil), nil
+}
+
+func (bridges *testBridges) Data(key *ari.Key) (*ari.BridgeData, error) {
+	return &ari.BridgeData{ID: key.ID}, nil
+}
+
+type bridgeClient struct {
+	ari.Client
+}
+
+func (cl *bridgeClient) Bridge() ari.Bridge {
+	return &testBridges{}
+}
+
+func TestConferenceConfig(t *testing.T) {
+	data := map[string]types.ModelData{
+		"conference_name": types.ModelDataStr{Value: "standup"},
+		"pin":             types.ModelDataStr{Value: "1234"},
+		"moderator_pin":   types.ModelDataStr{Value: "9999"}}
+	var conf ConferenceConfig
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Equal(t, "default", conf.MusicClass)
+	require.Equal(t, 0, conf.MaxParticipants)
+	require.Equal(t, "en-US", conf.TextLanguage)
+	require.Equal(t, "That PIN is not valid.", conf.InvalidPin)
+
+	data["moderator_pin"] = types.ModelDataStr{Value: "1234"}
+	require.Error(t, DecodeCellConfig(data, &ConferenceConfig{}))
+	data["moderator_pin"] = types.ModelDataStr{Value: "12a4"}
+	require.Error(t, DecodeCellConfig(data, &ConferenceConfig{}))
+	delete(data, "moderator_pin")
+	data["max_participants"] = types.ModelDataStr{Value: "-1"}
+	require.Error(t, DecodeCellConfig(data, &ConferenceConfig{}))
+	require.Error(t, DecodeCellConfig(map[string]types.ModelData{}, &ConferenceConfig{}))
+}
+
+func TestConferenceRole(t *testing.T) {
+	conf := &ConferenceConfig{Pin: "1234", ModeratorPin: "9999"}
+	require.Equal(t, CONFERENCE_MODERATOR, conf.role("9999"))
+	require.Equal(t, CONFERENCE_PARTICIPANT, conf.role("1234"))
+	require.Equal(t, "", conf.role("1111"))
+	require.Equal(t, "", conf.role(""))
+
+	// without a participant PIN, only moderators enter one
+	conf = &ConferenceConfig{ModeratorPin: "9999"}
+	require.Equal(t, CONFERENCE_MODERATOR, conf.role("9999"))
+	require.Equal(t, CONFERENCE_PARTICIPANT, conf.role(""))
+}
+
+func TestConferenceBridgeId(t *testing.T) {
+	require.Equal(t, "conference-1-7374616e647570", conferenceBridgeId(1, "standup"))
+	require.NotEqual(t, conferenceBridgeId(1, "a b"), conferenceBridgeId(1, "a_b"))
+	require.NotEqual(t, conferenceBridgeId(1, "standup"), conferenceBridgeId(2, "standup"))
+}
+
+func TestStartConferenceOnce(t *testing.T) {
+	SetConferenceStore(&memoryConferenceStore{
+		conferences: make(map[string]*types.LineConference),
+		claimed:     make(map[string]bool)})
+	defer SetConferenceStore(nil)
+	var created int32
+	createConference = func(workspaceId int, name string) (*api.ConferenceResponse, error) {
+		atomic.AddInt32(&created, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &api.ConferenceResponse{Id: "conf-1"}, nil
+	}
+	defer func() {
+		createConference = api.CreateConference
+	}()
+
+	// the first callers start the conference at the same time
+	ids := make(chan string, 3)
+	for i := 0; i != 3; i++ {
+		go func() {
+			man := NewConferenceManager(&types.Context{
+				Context: context.Background(),
+				Client:  &bridgeClient{},
+				Flow:    &types.Flow{User: types.NewUser(1, 2, "test")}}, nil)
+			conference, err := man.startConference(&ConferenceConfig{Name: "standup"})
+			if err != nil {
+				ids <- err.Error()
+				return
+			}
+			ids <- conference.Id
+		}()
+	}
+	for i := 0; i != 3; i++ {
+		require.Equal(t, "conf-1", <-ids)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&created))
+}
@@ -4,6 +4,7 @@ import (
 	//"context"
 
 	"encoding/json"
+	"errors"
 	"strconv"
 	"sync"
 
@@ -16,14 +17,69 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+// CallConfig is the call placed by Dial and Bridge cells.
+type CallConfig struct {
+	CallType     string `cell:"call_type,required"`
+	Extension    string `cell:"extension"`
+	NumberToCall string `cell:"number_to_call"`
+	CallerId     string `cell:"caller_id"`
+	Timeout      int    `cell:"timeout" default:"30"`
+}
+
+func (conf *CallConfig) Validate() error {
+	switch conf.CallType {
+	case "Extension", "ExtensionFlow":
+		if conf.Extension == "" {
+			return &CellConfigError{Field: "extension", Message: "is required for " + conf.CallType + " calls"}
+		}
+	case "Phone Number":
+		if conf.NumberToCall == "" {
+			return &CellConfigError{Field: "number_to_call", Message: "is required for Phone Number calls"}
+		}
+	}
+	if conf.Timeout <= 0 {
+		return &CellConfigError{Field: "timeout", Message: "must be more than 0"}
+	}
+	return nil
+}
+
+// numberToCall returns the extension or number the cell calls.
+func (conf *CallConfig) numberToCall() string {
+	if conf.CallType == "Phone Number" {
+		return conf.NumberToCall
+	}
+	return conf.Extension
+}
+
+// callerId returns the caller id of the call, which is the caller of the
+// flow unless the cell sets one.
+func (conf *CallConfig) callerId(call *types.Call) string {
+	if conf.CallerId == "" {
+		return call.Params.From
+	}
+	return conf.CallerId
+}
+
+// DialConfig is the config of Dial cells.
+type DialConfig struct {
+	CallConfig
+}
+
+func (conf *DialConfig) Validate() error {
+	if conf.CallType != "Extension" && conf.CallType != "Phone Number" {
+		return &CellConfigError{Field: "call_type", Message: "must be Extension or Phone Number, got " + conf.CallType}
+	}
+	return conf.CallConfig.Validate()
+}
+
 type DialManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
+	config         DialConfig
 }
 
 func (man *DialManager) manageOutboundCallLeg(outboundChannel *types.LineChannel, outCall *types.Call, wg *sync.WaitGroup, ringTimeoutChan chan<- bool) {
 	ctx := man.ManagerContext
-	lineChannel := ctx.Channel
 	cell := ctx.Cell
 	flow := ctx.Flow
 	record := processor_helpers.NewRecording(ctx.Context, flow.User, &outCall.CallId, false)
@@ -31,6 +87,9 @@ func (man *DialManager) manageOutboundCallLeg(outboundChannel *types.LineChannel
 
 	if recordErr != nil {
 		helpers.Log(logrus.ErrorLevel, "error starting recording: "+recordErr.Error())
+		outboundChannel.SafeHangup()
+		failCell(ctx, recordErr)
+		wg.Done()
 		return
 	}
 
@@ -45,8 +104,6 @@ func (man *DialManager) manageOutboundCallLeg(outboundChannel *types.LineChannel
 	startSub := outboundChannel.Channel.Subscribe(ari.Events.StasisStart)
 
 	defer startSub.Cancel()
-	rootEndSub := lineChannel.Channel.Subscribe(ari.Events.StasisEnd)
-	defer rootEndSub.Cancel()
 
 	wg.Done()
 	helpers.Log(logrus.DebugLevel, "listening for channel events...")
@@ -67,10 +124,12 @@ func (man *DialManager) manageOutboundCallLeg(outboundChannel *types.LineChannel
 			helpers.Log(logrus.DebugLevel, "ended call..")
 			record.Stop()
 			return
-		case <-rootEndSub.Events():
-			helpers.Log(logrus.DebugLevel, "root inded call..")
+		case <-ctx.Context.Done():
+			// the cell timed out or the caller hung up
+			helpers.Log(logrus.DebugLevel, "dial cancelled, hanging up outbound call..")
+			record.Stop()
+			outboundChannel.SafeHangup()
 			return
-
 		}
 	}
 }
@@ -78,41 +137,39 @@ func (man *DialManager) manageOutboundCallLeg(outboundChannel *types.LineChannel
 func (man *DialManager) startOutboundCall(callType string) {
 	ctx := man.ManagerContext
 	cell := ctx.Cell
-	model := cell.Model
 	flow := ctx.Flow
 	user := flow.User
 
 	helpers.Log(logrus.DebugLevel, "startOutboundCall called..")
 
-	callerId := utils.DetermineCallerId(flow.RootCall, model.Data["caller_id"])
+	callerId := man.config.callerId(flow.RootCall)
 	helpers.Log(logrus.DebugLevel, "caller ID was set to: "+callerId)
 
 	valid, err := api.VerifyCallerId(strconv.Itoa(user.Workspace.Id), callerId)
 	if err != nil {
 		helpers.Log(logrus.DebugLevel, "verify error: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 	if !valid {
 		helpers.Log(logrus.DebugLevel, "caller id was invalid. user provided: "+callerId)
+		failCell(ctx, errors.New("caller id "+callerId+" is not verified"))
 		return
 	}
 
-	numberToCall, err := utils.DetermineNumberToCall(model.Data)
-	if err != nil {
-		helpers.Log(logrus.DebugLevel, "verify error: "+err.Error())
-		return
-	}
+	numberToCall := man.config.numberToCall()
 	//key := src.New(ari.ChannelKey, rid.New(rid.Channel))
 
 	helpers.Log(logrus.DebugLevel, "Calling: "+numberToCall)
 
-	timeout := utils.ParseRingTimeout(model.Data["timeout"])
+	timeout := man.config.Timeout
 
 	outChannel := types.LineChannel{}
 	outboundChannel, err := ctx.Client.Channel().Create(nil, utils.CreateChannelRequest(numberToCall))
 
 	if err != nil {
 		helpers.Log(logrus.DebugLevel, "error creating outbound channel: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 
@@ -138,15 +195,22 @@ func (man *DialManager) startOutboundCall(callType string) {
 	body, err := json.Marshal(params)
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 
 	helpers.Log(logrus.InfoLevel, "creating outbound call...")
 	resp, err := api.SendHttpRequest("/call/createCall", body)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
 	outCall, err := outChannel.CreateCall(resp.Headers.Get("x-call-id"), &params)
 
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 
@@ -156,13 +220,16 @@ func (man *DialManager) startOutboundCall(callType string) {
 
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 	outChannel.Channel = outboundChannel
-	stopChannel := make(chan bool)
+	stopChannel := make(chan bool, 1)
 	wg1 := new(sync.WaitGroup)
 	wg1.Add(1)
-	go man.manageOutboundCallLeg(&outChannel, outCall, wg1, stopChannel)
+	goCell(man.ManagerContext, func() {
+		man.manageOutboundCallLeg(&outChannel, outCall, wg1, stopChannel)
+	})
 
 	wg1.Wait()
 
@@ -181,23 +248,17 @@ func NewDialManager(mngrCtx *types.Context, flow *types.Flow) *DialManager {
 	return &item
 }
 func (man *DialManager) StartProcessing() {
+	if err := loadConfig(man.ManagerContext, &man.config); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid dial cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
+	callType := man.config.CallType
 
-	cell := man.ManagerContext.Cell
-	//flow := man.ManagerContext.Flow
-	data := cell.Model.Data
-	// create the bridge
-
-	callType := data["call_type"].(types.ModelDataStr)
-
-	helpers.Log(logrus.DebugLevel, "processing call type: "+callType.Value)
+	helpers.Log(logrus.DebugLevel, "processing call type: "+callType)
 	helpers.Log(logrus.DebugLevel, "Creating DIAL... ")
 
 	helpers.Log(logrus.InfoLevel, "channel added to bridge")
 
-	switch callType.Value {
-	case "Extension":
-		man.startOutboundCall(callType.Value)
-	case "Phone Number":
-		man.startOutboundCall(callType.Value)
-	}
+	man.startOutboundCall(callType)
 }
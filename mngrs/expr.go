@@ -0,0 +1,376 @@
+package mngrs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"lineblocs.com/processor/types"
+)
+
+// exprValue is an operand of an expression. Values that came from variables
+// keep their original text so "+1514..." concatenates as written.
+type exprValue struct {
+	text  string
+	num   float64
+	isNum bool
+}
+
+func newExprNumber(num float64) exprValue {
+	return exprValue{text: strconv.FormatFloat(num, 'f', -1, 64), num: num, isNum: true}
+}
+
+func newExprText(text string) exprValue {
+	num, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return exprValue{text: text}
+	}
+	return exprValue{text: text, num: num, isNum: true}
+}
+
+const (
+	EXPR_TOKEN_NUMBER = iota
+	EXPR_TOKEN_STRING
+	EXPR_TOKEN_TEMPLATE
+	EXPR_TOKEN_IDENT
+	EXPR_TOKEN_OP
+)
+
+type exprToken struct {
+	kind  int
+	value string
+}
+
+func isTwoCharOperator(op string) bool {
+	switch op {
+	case "==", "!=", ">=", "<=", "&&", "||":
+		return true
+	}
+	return false
+}
+
+func tokenizeExpression(expr string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.HasPrefix(string(runes[i:]), "{{"):
+			rest := string(runes[i:])
+			end := strings.Index(rest, "}}")
+			if end == -1 {
+				return nil, errors.New("unterminated template")
+			}
+			tokens = append(tokens, exprToken{kind: EXPR_TOKEN_TEMPLATE, value: rest[:end+2]})
+			i += len([]rune(rest[:end+2]))
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, exprToken{kind: EXPR_TOKEN_STRING, value: string(runes[i+1 : end])})
+			i = end + 1
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: EXPR_TOKEN_NUMBER, value: string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: EXPR_TOKEN_IDENT, value: string(runes[i:end])})
+			i = end
+		case i+1 < len(runes) && isTwoCharOperator(string(runes[i:i+2])):
+			tokens = append(tokens, exprToken{kind: EXPR_TOKEN_OP, value: string(runes[i : i+2])})
+			i += 2
+		case strings.ContainsRune("+-*/%()<>!", r):
+			tokens = append(tokens, exprToken{kind: EXPR_TOKEN_OP, value: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser evaluates expressions while parsing them, lowest precedence
+// first: or, and, comparison, additive, multiplicative, unary and finally
+// operands.
+type exprParser struct {
+	flow   *types.Flow
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peekOp(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != EXPR_TOKEN_OP {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].value == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func newExprBool(value bool) exprValue {
+	return exprValue{text: strconv.FormatBool(value)}
+}
+
+// truthy treats non zero numbers and any text except "" and "false" as true.
+func (value exprValue) truthy() bool {
+	if value.isNum {
+		return value.num != 0
+	}
+	return value.text != "" && !strings.EqualFold(value.text, "false")
+}
+
+func (p *exprParser) parseOr() (exprValue, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for {
+		if _, ok := p.peekOp("||"); !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		left = newExprBool(left.truthy() || right.truthy())
+	}
+}
+
+func (p *exprParser) parseAnd() (exprValue, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return left, err
+	}
+	for {
+		if _, ok := p.peekOp("&&"); !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return right, err
+		}
+		left = newExprBool(left.truthy() && right.truthy())
+	}
+}
+
+// parseComparison compares numbers numerically and everything else as text.
+func (p *exprParser) parseComparison() (exprValue, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return left, err
+	}
+	op, ok := p.peekOp("==", "!=", ">", "<", ">=", "<=")
+	if !ok {
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parseAdditive()
+	if err != nil {
+		return right, err
+	}
+	cmp := strings.Compare(left.text, right.text)
+	if left.isNum && right.isNum {
+		cmp = 0
+		if left.num < right.num {
+			cmp = -1
+		} else if left.num > right.num {
+			cmp = 1
+		}
+	}
+	switch op {
+	case "==":
+		return newExprBool(cmp == 0), nil
+	case "!=":
+		return newExprBool(cmp != 0), nil
+	case ">":
+		return newExprBool(cmp > 0), nil
+	case "<":
+		return newExprBool(cmp < 0), nil
+	case ">=":
+		return newExprBool(cmp >= 0), nil
+	}
+	return newExprBool(cmp <= 0), nil
+}
+
+func (p *exprParser) parseAdditive() (exprValue, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return left, err
+	}
+	for {
+		op, ok := p.peekOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return right, err
+		}
+		if op == "+" && !(left.isNum && right.isNum) {
+			left = exprValue{text: left.text + right.text}
+			continue
+		}
+		if !left.isNum || !right.isNum {
+			return left, fmt.Errorf("cannot subtract %q and %q", left.text, right.text)
+		}
+		if op == "+" {
+			left = newExprNumber(left.num + right.num)
+		} else {
+			left = newExprNumber(left.num - right.num)
+		}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprValue, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+	for {
+		op, ok := p.peekOp("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return right, err
+		}
+		if !left.isNum || !right.isNum {
+			return left, fmt.Errorf("operator %s needs numbers, got %q and %q", op, left.text, right.text)
+		}
+		switch op {
+		case "*":
+			left = newExprNumber(left.num * right.num)
+		case "/":
+			if right.num == 0 {
+				return left, errors.New("division by zero")
+			}
+			left = newExprNumber(left.num / right.num)
+		case "%":
+			if right.num == 0 {
+				return left, errors.New("division by zero")
+			}
+			left = newExprNumber(math.Mod(left.num, right.num))
+		}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprValue, error) {
+	if _, ok := p.peekOp("!"); ok {
+		p.pos++
+		value, err := p.parseUnary()
+		if err != nil {
+			return value, err
+		}
+		return newExprBool(!value.truthy()), nil
+	}
+	if _, ok := p.peekOp("-"); ok {
+		p.pos++
+		value, err := p.parseUnary()
+		if err != nil {
+			return value, err
+		}
+		if !value.isNum {
+			return value, fmt.Errorf("cannot negate %q", value.text)
+		}
+		return newExprNumber(-value.num), nil
+	}
+	return p.parseOperand()
+}
+
+func (p *exprParser) parseOperand() (exprValue, error) {
+	if p.pos >= len(p.tokens) {
+		return exprValue{}, errors.New("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case EXPR_TOKEN_NUMBER:
+		num, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return exprValue{}, fmt.Errorf("invalid number %s", token.value)
+		}
+		return newExprNumber(num), nil
+	case EXPR_TOKEN_STRING:
+		return exprValue{text: token.value}, nil
+	case EXPR_TOKEN_TEMPLATE:
+		return newExprText(p.flow.Interpolate(token.value)), nil
+	case EXPR_TOKEN_IDENT:
+		if token.value == "true" || token.value == "false" {
+			return exprValue{text: token.value}, nil
+		}
+		value, ok := p.flow.ResolveVariable(token.value)
+		if !ok {
+			return exprValue{}, fmt.Errorf("unknown variable %s", token.value)
+		}
+		return newExprText(value), nil
+	}
+	if token.value == "(" {
+		value, err := p.parseOr()
+		if err != nil {
+			return value, err
+		}
+		if _, ok := p.peekOp(")"); !ok {
+			return value, errors.New("missing )")
+		}
+		p.pos++
+		return value, nil
+	}
+	return exprValue{}, fmt.Errorf("unexpected %s", token.value)
+}
+
+// EvaluateExpression computes simple arithmetic (+ - * / %), string
+// concatenation, comparisons (== != > < >= <=) and boolean logic (&& || !).
+// Operands are numbers, quoted strings, true and false, {{templates}} and
+// variable references such as total or Input1.digits.
+func EvaluateExpression(flow *types.Flow, expr string) (string, error) {
+	tokens, err := tokenizeExpression(expr)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", errors.New("empty expression")
+	}
+	parser := &exprParser{flow: flow, tokens: tokens}
+	value, err := parser.parseOr()
+	if err != nil {
+		return "", err
+	}
+	if parser.pos != len(tokens) {
+		return "", fmt.Errorf("unexpected %s", tokens[parser.pos].value)
+	}
+	return value.text, nil
+}
+
+// EvaluateCondition evaluates an expression and reports whether the result
+// is true.
+func EvaluateCondition(flow *types.Flow, expr string) (bool, error) {
+	result, err := EvaluateExpression(flow, expr)
+	if err != nil {
+		return false, err
+	}
+	return newExprText(result).truthy(), nil
+}
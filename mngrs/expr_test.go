@@ -0,0 +1,58 @@
+package mngrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	t.Parallel()
+	flow := &types.Flow{
+		RootCall: &types.Call{Params: &types.CallParams{From: "+15145550100"}},
+		Cells: []*types.Cell{
+			newTestCell("2", "Input1", "devs.ProcessInputModel", nil)},
+	}
+	flow.Cells[0].EventVars["digits"] = "21"
+	flow.SetVariable("total", "4")
+
+	tests := []struct {
+		name string
+		expr string
+		want string
+		fail bool
+	}{
+		{name: "Arithmetic", expr: "1 + 2 * 3", want: "7"},
+		{name: "Parentheses", expr: "(1 + 2) * 3", want: "9"},
+		{name: "Division", expr: "7 / 2", want: "3.5"},
+		{name: "Modulo", expr: "7 % 4", want: "3"},
+		{name: "Negative", expr: "-total + 1", want: "-3"},
+		{name: "Variables", expr: "Input1.digits * total", want: "84"},
+		{name: "Template", expr: "{{Input1.digits}} + 1", want: "22"},
+		{name: "Concatenation", expr: `"caller " + {{call.from}}`, want: "caller +15145550100"},
+		{name: "ConcatenateNumber", expr: `'#' + (total + 1)`, want: "#5"},
+		{name: "DivisionByZero", expr: "total / 0", fail: true},
+		{name: "NotANumber", expr: `"a" * 2`, fail: true},
+		{name: "UnknownVariable", expr: "missing + 1", fail: true},
+		{name: "Unbalanced", expr: "(1 + 2", fail: true},
+		{name: "Empty", expr: " ", fail: true},
+		{name: "NumericComparison", expr: "Input1.digits > 9", want: "true"},
+		{name: "TextComparison", expr: `{{call.from}} == "+15145550100"`, want: "true"},
+		{name: "AndOr", expr: "total >= 4 && (Input1.digits < 10 || !false)", want: "true"},
+		{name: "Not", expr: "!(total == 4)", want: "false"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateExpression(flow, tt.expr)
+			if tt.fail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package mngrs
+
+import (
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// maximum time to wait for the fallback announcement before hanging up
+const fallbackPlaybackTimeout = 30 * time.Second
+
+// RouteToFallback handles a call whose flow cannot be executed. When a
+// fallback number is configured the caller is bridged to it, otherwise the
+// fallback announcement is played and the call is hung up.
+func RouteToFallback(cl ari.Client, user *types.User, channel *types.LineChannel, callerId string, reason string) {
+	cfg := config.NewConfig()
+	helpers.Log(logrus.InfoLevel, "routing call to fallback. reason: "+reason)
+	if channel == nil || channel.Channel == nil {
+		return
+	}
+	channel.Answer()
+
+	if cfg.FallbackNumber != "" && user != nil {
+		err := utils.EnsureBridge(cl, channel.Channel.Key(), user, channel, callerId, cfg.FallbackNumber, "pstn", nil)
+		if err == nil {
+			return
+		}
+		helpers.Log(logrus.ErrorLevel, "could not bridge to fallback number. error: "+err.Error())
+	}
+
+	playAndWait(channel, cfg.FallbackSound, fallbackPlaybackTimeout)
+	channel.SafeHangup()
+}
+
+// playAndWait plays a sound URI on the channel and blocks until the playback
+// finishes or the timeout elapses.
+func playAndWait(channel *types.LineChannel, uri string, timeout time.Duration) {
+	if uri == "" {
+		return
+	}
+	playback, err := channel.Channel.Play(rid.New(rid.Playback), uri)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "failed to play "+uri+", error:"+err.Error())
+		return
+	}
+	finishedSub := playback.Subscribe(ari.Events.PlaybackFinished)
+	defer finishedSub.Cancel()
+
+	select {
+	case <-finishedSub.Events():
+	case <-time.After(timeout):
+		helpers.Log(logrus.DebugLevel, "timed out waiting for "+uri)
+	}
+}
@@ -2,97 +2,283 @@ package mngrs
 
 import (
 	"context"
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/CyCoreSystems/ari/v5"
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/internal/config"
 	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
 )
 
 type BaseManager interface {
 	StartProcessing()
 }
 
+// startProcessingFlow runs the cells of a flow one after the other until a
+// cell hangs up or exits, the runner is cancelled or the call goes over one
+// of its limits.
 func startProcessingFlow(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, eventVars map[string]string, cell *types.Cell, runner *types.Runner) {
-	helpers.Log(logrus.DebugLevel, "processing cell type "+cell.Cell.Type)
-	if runner.Cancelled {
-		helpers.Log(logrus.DebugLevel, "flow runner was cancelled - exiting")
+	defer func() {
+		if value := recover(); value != nil {
+			err := reportPanic(PANIC_RUNNER, value, describeCall(flow, lineChannel))
+			RouteToFallback(cl, flow.User, lineChannel, flowCallerId(flow), err.Error())
+		}
+	}()
+	for cell != nil {
+		if runner.IsCancelled() {
+			helpers.Log(logrus.DebugLevel, "flow runner was cancelled - exiting")
+			return
+		}
+		if err := flow.Guard.Visit(strconv.Itoa(flow.FlowId) + ":" + cell.Cell.Id); err != nil {
+			stopRunawayFlow(flow, lineChannel, cell, err)
+			return
+		}
+		// only the branch that owns the call can resume it
+		if !runner.Branch.Background() {
+			saveCheckpoint(flow, lineChannel, cell)
+		}
+		current := cell
+		lineChannel, cell = processCell(cl, ctx, flow, lineChannel, eventVars, cell, runner)
+		flow.LeftCell(current)
+	}
+}
+
+// startBranch runs a branch of a flow and lets the other branches know when
+// it ended.
+func startBranch(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, eventVars map[string]string, cell *types.Cell, runner *types.Runner) {
+	startProcessingFlow(cl, ctx, flow, lineChannel, eventVars, cell, runner)
+	if runner.Branch == nil {
 		return
 	}
+	flow.Media.Release(runner.Branch)
+	runner.Branch.Fork.End(runner.Branch)
+}
+
+// forkBranches starts a branch for every link of a launch cell after the
+// first, which the runner continues with. The branches run in the background
+// until they reach a join cell.
+func forkBranches(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, eventVars map[string]string, cell *types.Cell, runner *types.Runner) *types.Cell {
+	links := cell.SourceLinks
+	if len(links) == 0 {
+		return nil
+	}
+	if len(links) > 1 {
+		names := make([]string, 0, len(links))
+		for _, link := range links {
+			names = append(names, link.Target.Cell.Name)
+		}
+		fork := types.NewFork(names)
+		flow.Media.Release(runner.Branch)
+		runner.Branch = fork.Branches[0]
+		for i, link := range links[1:] {
+			branch := types.NewRunner(runner.Context())
+			branch.Branch = fork.Branches[i+1]
+			flow.AddRunner(branch)
+			target := link.Target
+			flow.Go(func() {
+				startBranch(cl, ctx, flow, lineChannel, copyEventVars(eventVars), target, branch)
+			})
+		}
+	}
+	return links[0].Target
+}
+
+// processCell executes a cell and returns the channel and the cell to
+// continue with. The cell is nil when the runner is done.
+func processCell(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, eventVars map[string]string, cell *types.Cell, runner *types.Runner) (*types.LineChannel, *types.Cell) {
+	helpers.Log(logrus.DebugLevel, "processing cell type "+cell.Cell.Type)
 	helpers.Log(logrus.DebugLevel, "source link count: "+strconv.Itoa(len(cell.SourceLinks)))
 	helpers.Log(logrus.DebugLevel, "target link count: "+strconv.Itoa(len(cell.TargetLinks)))
 
-	manRecvChannel := make(chan *types.ManagerResponse)
+	// buffered so that a manager finishing after its runner was cancelled
+	// does not block forever
+	manRecvChannel := make(chan *types.ManagerResponse, 1)
 	lineCtx := types.NewContext(
 		cl,
-		ctx,
+		runner.Context(),
 		manRecvChannel,
 		flow,
 		cell,
 		runner,
 		lineChannel)
+	entry := flow.Trace.Enter(cell)
 	// execute it
-	var mngr BaseManager
-	switch cell.Cell.Type {
-	case "devs.LaunchModel":
+	if cell.Cell.Type == "devs.LaunchModel" {
 		for _, link := range cell.SourceLinks {
-			go startProcessingFlow(cl, ctx, flow, lineChannel, eventVars, link.Target, runner)
+			flow.Trace.Exit(entry, link, nil, nil)
+		}
+		return lineChannel, forkBranches(cl, ctx, flow, lineChannel, eventVars, cell, runner)
+	}
+	cellType, ok := LookupCellType(cell.Cell.Type)
+	if !ok || cellType.Factory == nil {
+		helpers.Log(logrus.ErrorLevel, "unknown type of cell: "+cell.Cell.Type)
+		return cellFailed(cl, flow, lineChannel, cell, entry, runner, errors.New("unknown type of cell "+cell.Cell.Type))
+	}
+	if cellType.Config != nil {
+		conf := cellType.Config()
+		if err := DecodeCellConfig(cell.Model.Data, conf); err != nil {
+			helpers.Log(logrus.ErrorLevel, "invalid config of cell "+cell.Cell.Name+": "+err.Error())
+			return cellFailed(cl, flow, lineChannel, cell, entry, runner, err)
+		}
+		lineCtx.Config = conf
+	}
+	if cellType.Media {
+		// wait for the branch that uses the media of the call to end
+		if err := flow.Media.Acquire(runner.Context(), runner.Branch); err != nil {
+			flow.Trace.Exit(entry, nil, nil, errors.New("cancelled"))
+			return lineChannel, nil
 		}
-		return
-	case "devs.SwitchModel":
-		mngr = NewSwitchManager(lineCtx, flow)
-	case "devs.BridgeModel":
-		mngr = NewBridgeManager(lineCtx, flow)
-	case "devs.PlaybackModel":
-		mngr = NewPlaybackManager(lineCtx, flow)
-	case "devs.ProcessInputModel":
-		mngr = NewInputManager(lineCtx, flow)
-	case "devs.DialModel":
-		mngr = NewDialManager(lineCtx, flow)
-	case "devs.SetVariablesModel":
-		mngr = NewSetVariablesManager(lineCtx, flow)
-	case "devs.WaitModel":
-		mngr = NewWaitManager(lineCtx, flow)
-	case "devs.SendDigitsModel":
-		mngr = NewSendDigitsManager(lineCtx, flow)
-	case "devs.MacroModel":
-		mngr = NewMacroManager(lineCtx, flow)
-	case "devs.ConferenceModel":
-		mngr = NewConferenceManager(lineCtx, flow)
-	default:
-		helpers.Log(logrus.InfoLevel, "unknown type of cell..")
-		return
-
 	}
-	mngr.StartProcessing()
+	handlerMode := cellType.Handlers
+	if conf, ok := lineCtx.Config.(handlerModeConfig); ok {
+		handlerMode = conf.HandlerMode()
+	}
+	if handlerMode != HANDLERS_ACTIVE {
+		defer setHandlerMode(lineChannel, handlerMode)()
+	}
+	cellVarsBefore := cell.CopyEventVars()
+	flowVarsBefore := flow.Variables()
+	mngr := cellType.Factory(lineCtx, flow)
+	if err := startManager(mngr, flow, lineChannel, cell); err != nil {
+		return cellFailed(cl, flow, lineChannel, cell, entry, runner, err)
+	}
 
+	timeout, callLimit := cellTimeout(cellType, flow)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
 	helpers.Log(logrus.DebugLevel, "waiting to receive from channel...")
-	for {
-		select {
-		case resp, ok := <-manRecvChannel:
-			if !ok {
-				helpers.Log(logrus.DebugLevel, "error receiving result from cell..")
-				return
-			}
-			helpers.Log(logrus.DebugLevel, "ended process for cell")
-			helpers.Log(logrus.DebugLevel, "moving to next..")
+	select {
+	case <-expired:
+		lineCtx.Cancel()
+		if callLimit {
+			flow.Trace.Exit(entry, nil, nil, errors.New("cancelled"))
+			stopRunawayFlow(flow, lineChannel, cell, &types.LimitError{
+				Limit:  types.LIMIT_DURATION,
+				CellId: strconv.Itoa(flow.FlowId) + ":" + cell.Cell.Id,
+				Max:    flowLimits().MaxDuration.String()})
+			return lineChannel, nil
+		}
+		err := errors.New("cell " + cell.Cell.Name + " did not finish within " + timeout.String())
+		helpers.Log(logrus.ErrorLevel, err.Error()+", "+describeCall(flow, lineChannel))
+		return cellFailed(cl, flow, lineChannel, cell, entry, runner, err)
+	case <-runner.Context().Done():
+		helpers.Log(logrus.DebugLevel, "flow runner was cancelled while processing "+cell.Cell.Name)
+		// the variables the cell set before it was cancelled are still kept
+		flow.Trace.Exit(entry, nil, changedVariables(cell, flow, cellVarsBefore, flowVarsBefore), errors.New("cancelled"))
+		return lineChannel, nil
+	case resp, ok := <-manRecvChannel:
+		if !ok {
+			helpers.Log(logrus.DebugLevel, "error receiving result from cell..")
+			flow.Trace.Exit(entry, nil, nil, errors.New("cell stopped without a result"))
+			return lineChannel, nil
+		}
+		if resp.Error != nil {
+			return cellFailed(cl, flow, resp.Channel, cell, entry, runner, resp.Error)
+		}
+		flow.Trace.Exit(entry, resp.Link, changedVariables(cell, flow, cellVarsBefore, flowVarsBefore), nil)
+		helpers.Log(logrus.DebugLevel, "ended process for cell")
+		helpers.Log(logrus.DebugLevel, "moving to next..")
 
-			if resp.Link == nil {
-				helpers.Log(logrus.DebugLevel, "no target found... hanging up")
-				resp.Channel.SafeHangup()
-				return
-			}
-			next := resp.Link
-			defer startProcessingFlow(cl, ctx, flow, resp.Channel, eventVars, next.Target, runner)
-			return
+		if resp.Exit {
+			helpers.Log(logrus.DebugLevel, "runner exited")
+			return resp.Channel, nil
+		}
+		if resp.Link == nil && runner.Branch.Background() {
+			helpers.Log(logrus.DebugLevel, "background branch "+runner.Branch.Name+" ended")
+			return resp.Channel, nil
 		}
+		if resp.Link == nil {
+			helpers.Log(logrus.DebugLevel, "no target found... hanging up")
+			resp.Channel.SafeHangup()
+			return resp.Channel, nil
+		}
+		return resp.Channel, resp.Link.Target
 	}
 }
 
+// cellTimeout returns how long a cell can run, and true when that is bounded
+// by the time the call has left rather than by the cell type.
+func cellTimeout(cellType *CellType, flow *types.Flow) (time.Duration, bool) {
+	timeout := cellType.Timeout
+	if timeout == 0 {
+		timeout = config.NewConfig().CellTimeout
+	}
+	remaining, limited := flow.Guard.Remaining()
+	if !limited {
+		return timeout, false
+	}
+	if remaining <= 0 {
+		// the limit is already reached, give the cell a moment to resolve
+		remaining = time.Second
+	}
+	if timeout <= 0 || remaining < timeout {
+		return remaining, true
+	}
+	return timeout, false
+}
+
+// cellFailed handles a cell that could not be started, resolved with an
+// error or timed out. The error follows the "Error" port of the cell. Without
+// one, the flow continues at its "on error" cell, a subflow hands the error to
+// its parent, a background branch ends and any other flow is routed to the
+// fallback.
+func cellFailed(cl ari.Client, flow *types.Flow, lineChannel *types.LineChannel, cell *types.Cell, entry *types.TraceEntry, runner *types.Runner, cellErr error) (*types.LineChannel, *types.Cell) {
+	cell.SetEventVar("error", cellErr.Error())
+	errorLink, err := utils.FindLinkByName(cell.SourceLinks, "source", "Error")
+	if err == nil {
+		flow.Trace.Exit(entry, errorLink, nil, cellErr)
+		return lineChannel, errorLink.Target
+	}
+	flow.Trace.Exit(entry, nil, nil, cellErr)
+	if handler := flow.ErrorHandler(); handler != nil && handler != cell {
+		flow.SetVariable("error", cellErr.Error())
+		flow.SetVariable("error_cell", cell.Cell.Name)
+		return lineChannel, handler
+	}
+	if flow.Exit(nil, cellErr) {
+		return lineChannel, nil
+	}
+	if runner.Branch.Background() {
+		helpers.Log(logrus.ErrorLevel, "background branch "+runner.Branch.Name+" failed: "+cellErr.Error())
+		return lineChannel, nil
+	}
+	RouteToFallback(cl, flow.User, lineChannel, flowCallerId(flow), cellErr.Error())
+	return lineChannel, nil
+}
+
+func flowCallerId(flow *types.Flow) string {
+	if flow.RootCall == nil || flow.RootCall.Params == nil {
+		return ""
+	}
+	return flow.RootCall.Params.From
+}
+
 func ProcessFlow(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, eventVars map[string]string, cell *types.Cell) {
 	helpers.Log(logrus.DebugLevel, "processing cell type "+cell.Cell.Type)
-	runner := types.Runner{Cancelled: false}
-	flow.Runners = append(flow.Runners, &runner)
-	startProcessingFlow(cl, ctx, flow, lineChannel, eventVars, cell, &runner)
+	if flow.Guard == nil {
+		flow.Guard = types.NewFlowGuard(flowLimits())
+	}
+	runner := types.NewRunner(ctx)
+	runner.Branch = types.NewBranch(0, cell.Cell.Name)
+	flow.AddRunner(runner)
+	trackTrace(flow)
+	trackSession(cl, ctx, flow, lineChannel, eventVars)
+	// read before the launch cell resolves the templates of its data
+	handlers, err := flowHandlers(flow)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid handlers: "+err.Error())
+	}
+	gate := openHandlerGate(lineChannel, handlers)
+	flow.Go(func() {
+		watchHandlers(ctx, lineChannel, handlers, gate)
+	})
+	startBranch(cl, ctx, flow, lineChannel, eventVars, cell, runner)
 }
@@ -0,0 +1,97 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+// errorHandlerFlow fails at Wait1, handles the error, continues at Wait2
+// and fails again.
+const errorHandlerFlow = `{
+  "graph": {"cells": [
+    {"id": "launch", "name": "Launch", "type": "devs.LaunchModel"},
+    {"id": "wait1", "name": "Wait1", "type": "devs.WaitModel"},
+    {"id": "handler", "name": "Handler", "type": "devs.SetVariablesModel"},
+    {"id": "retry", "name": "Retry", "type": "devs.SwitchModel"},
+    {"id": "wait2", "name": "Wait2", "type": "devs.WaitModel"},
+    {"id": "l1", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "wait1", "port": "In"}},
+    {"id": "l2", "type": "devs.FlowLink", "source": {"id": "handler", "port": "Completed"}, "target": {"id": "retry", "port": "In"}},
+    {"id": "l3", "type": "devs.FlowLink", "source": {"id": "retry", "port": "Wait2"}, "target": {"id": "wait2", "port": "In"}}
+  ]},
+  "models": [
+    {"id": "launch", "name": "Launch", "data": {"on_error": "Handler"}},
+    {"id": "wait1", "name": "Wait1", "data": {"wait_seconds": "soon"}},
+    {"id": "handler", "name": "Handler", "data": {"variables": [{"name": "failed_cell", "value": "{{error_cell}}"}]}},
+    {"id": "retry", "name": "Retry", "data": {"test": "{{error_cell}}"},
+      "links": [{"type": "LINK_CONDITION_MATCHES", "condition": "Equals", "value": "Wait1", "cell": "Wait2"}]},
+    {"id": "wait2", "name": "Wait2", "data": {"wait_seconds": "later"}}
+  ]
+}`
+
+type stuckManager struct {
+	ctx       *types.Context
+	cancelled chan struct{}
+}
+
+func (man *stuckManager) StartProcessing() {
+	goCell(man.ctx, func() {
+		<-man.ctx.Context.Done()
+		close(man.cancelled)
+	})
+}
+
+func TestCellTimeoutFollowsErrorPort(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	cancelled := make(chan struct{})
+	Register("test.StuckModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return &stuckManager{ctx: mngrCtx, cancelled: cancelled}
+	}, CellMeta{Timeout: 20 * time.Millisecond})
+
+	stuck := newTestCell("1", "Stuck1", "test.StuckModel", map[string]types.ModelData{})
+	setVars := newTestCell("2", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{{"name": "reason", "value": "{{Stuck1.error}}"}}}})
+	connectTestCells(stuck, "Error", setVars)
+	flow := &types.Flow{
+		Trace: types.NewFlowTrace(1),
+		Cells: []*types.Cell{stuck, setVars}}
+
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), stuck)
+
+	entries := flow.Trace.Snapshot().Entries
+	require.Len(t, entries, 2)
+	require.Equal(t, "Error", entries[0].Port)
+	reason, _ := flow.GetVariable("reason")
+	require.Equal(t, "cell Stuck1 did not finish within 20ms", reason)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the cell was not cancelled")
+	}
+}
+
+func TestFlowErrorHandler(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal([]byte(errorHandlerFlow), &vars))
+	lineChannel := &types.LineChannel{}
+	flow := types.NewFlow(1, types.NewUser(1, 1, "test"), &vars, lineChannel, nil, nil)
+
+	ProcessFlow(nil, context.Background(), flow, lineChannel, make(map[string]string), flow.Cells[0])
+
+	names := make([]string, 0)
+	for _, entry := range flow.Trace.Snapshot().Entries {
+		names = append(names, entry.CellName)
+	}
+	// both errors are handled
+	require.Equal(t, []string{"Launch", "Wait1", "Handler", "Retry", "Wait2", "Handler", "Retry"}, names)
+	failedCell, _ := flow.GetVariable("failed_cell")
+	require.Equal(t, "Wait2", failedCell)
+	message, _ := flow.GetVariable("error")
+	require.Equal(t, `field wait_seconds must be a whole number, got "later"`, message)
+}
@@ -0,0 +1,170 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// followMeConfirmTimeout is how long a callee has to accept a Follow Me call
+// once it answered.
+var followMeConfirmTimeout = 10 * time.Second
+
+// followMeStep is a step of a Follow Me list, whose destinations ring at
+// once. A timeout of zero rings for the timeout of the cell.
+type followMeStep struct {
+	destinations []ringDestination
+	timeout      time.Duration
+}
+
+func parseFollowMeStep(entry map[string]string) (*followMeStep, error) {
+	step := &followMeStep{}
+	for _, extension := range splitList(entry["extensions"]) {
+		if extension != "" {
+			step.destinations = append(step.destinations, ringDestination{number: extension, callType: "Extension"})
+		}
+	}
+	for _, number := range splitList(entry["numbers"]) {
+		if number != "" {
+			step.destinations = append(step.destinations, ringDestination{number: number, callType: "Phone Number"})
+		}
+	}
+	if len(step.destinations) == 0 {
+		return nil, errors.New("every step needs extensions or numbers to ring")
+	}
+	if value := strings.TrimSpace(entry["timeout"]); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil, errors.New("step timeout must be a number of seconds, got " + strconv.Quote(value))
+		}
+		step.timeout = time.Duration(seconds) * time.Second
+	}
+	return step, nil
+}
+
+// FollowMeConfig is the config of Bridge cells with the "Follow Me" call
+// type. Every step lists the "extensions" and "numbers" it rings, separated
+// by commas, and how many seconds they ring in "timeout".
+type FollowMeConfig struct {
+	Steps         []map[string]string `cell:"follow_me_steps"`
+	Confirm       bool                `cell:"follow_me_confirm"`
+	ConfirmPrompt string              `cell:"follow_me_confirm_prompt" default:"You have a call. Press 1 to accept it."`
+
+	steps []*followMeStep
+}
+
+func (conf *FollowMeConfig) Validate() error {
+	if len(conf.Steps) == 0 {
+		return &CellConfigError{Field: "follow_me_steps", Message: "needs at least one step"}
+	}
+	conf.steps = nil
+	for _, entry := range conf.Steps {
+		step, err := parseFollowMeStep(entry)
+		if err != nil {
+			return &CellConfigError{Field: "follow_me_steps", Message: err.Error()}
+		}
+		conf.steps = append(conf.steps, step)
+	}
+	return nil
+}
+
+// startFollowMe rings the steps of the list one after the other until a
+// destination answers, and accepts the call when confirmation is on. The
+// call leaves through "No Answer" when no step was answered.
+func (man *BridgeManager) startFollowMe() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+	channel := ctx.Channel
+	conf := &man.config.FollowMeConfig
+
+	callerId := man.config.callerId(flow.RootCall)
+	if err := man.verifyCallerId(callerId); err != nil {
+		failCell(ctx, err)
+		return
+	}
+	var accept func(ringCtx context.Context, leg *types.LineChannel) bool
+	if conf.Confirm {
+		accept = man.confirmFollowMe
+	}
+
+	channel.Channel.Ring()
+	for i, step := range conf.steps {
+		if ctx.Context.Err() != nil {
+			helpers.Log(logrus.DebugLevel, "follow me cancelled")
+			return
+		}
+		timeout := step.timeout
+		if timeout == 0 {
+			timeout = time.Duration(man.config.Timeout) * time.Second
+		}
+		helpers.Log(logrus.DebugLevel, "ringing follow me step "+strconv.Itoa(i+1))
+		offer := man.ringDestinations(callerId, step.destinations, timeout, accept, func(number string) {})
+		if offer == nil {
+			continue
+		}
+		channel.Channel.StopRing()
+		cell.SetEventVar("answered_by", offer.destination.number)
+		cell.SetEventVar("step", strconv.Itoa(i+1))
+		man.bridgeWith(offer.leg, "Follow Me")
+		return
+	}
+	channel.Channel.StopRing()
+	if ctx.Context.Err() != nil {
+		return
+	}
+	helpers.Log(logrus.DebugLevel, "no follow me step answered")
+	noAnswer, _ := utils.FindLinkByName(cell.SourceLinks, "source", "No Answer")
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: channel,
+		Link:    noAnswer}
+}
+
+// confirmFollowMe asks the callee to press 1, which a voicemail answering
+// the call does not do.
+func (man *BridgeManager) confirmFollowMe(ringCtx context.Context, leg *types.LineChannel) bool {
+	conf := &man.config.FollowMeConfig
+	dtmfSub := leg.Channel.Subscribe(ari.Events.ChannelDtmfReceived)
+	defer dtmfSub.Cancel()
+	endSub := leg.Channel.Subscribe(ari.Events.StasisEnd)
+	defer endSub.Cancel()
+
+	file, err := utils.StartTTS(conf.ConfirmPrompt, "", "", "en-US")
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not create follow me prompt: "+err.Error())
+		return false
+	}
+	if _, err := leg.Channel.Play(rid.New(rid.Playback), "sound:"+file); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not play follow me prompt: "+err.Error())
+		return false
+	}
+	timeout := time.NewTimer(followMeConfirmTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case e, ok := <-dtmfSub.Events():
+			if !ok {
+				return false
+			}
+			if e.(*ari.ChannelDtmfReceived).Digit == "1" {
+				return true
+			}
+		case <-endSub.Events():
+			return false
+		case <-timeout.C:
+			helpers.Log(logrus.DebugLevel, "follow me call was not accepted")
+			return false
+		case <-ringCtx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mngrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestParseFollowMeStep(t *testing.T) {
+	step, err := parseFollowMeStep(map[string]string{"extensions": "1001, 1002", "numbers": "15145550111", "timeout": "20"})
+	require.NoError(t, err)
+	require.Equal(t, []ringDestination{
+		{number: "1001", callType: "Extension"},
+		{number: "1002", callType: "Extension"},
+		{number: "15145550111", callType: "Phone Number"}}, step.destinations)
+	require.Equal(t, 20*time.Second, step.timeout)
+
+	step, err = parseFollowMeStep(map[string]string{"numbers": "15145550111"})
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), step.timeout)
+
+	_, err = parseFollowMeStep(map[string]string{"extensions": " , "})
+	require.Error(t, err)
+	_, err = parseFollowMeStep(map[string]string{"extensions": "1001", "timeout": "soon"})
+	require.Error(t, err)
+}
+
+func TestFollowMeConfig(t *testing.T) {
+	data := map[string]types.ModelData{
+		"call_type":       types.ModelDataStr{Value: "Follow Me"},
+		"follow_me_steps": types.ModelDataList{Value: []map[string]string{{"extensions": "1001"}, {"numbers": "15145550111", "timeout": "10"}}}}
+	var conf BridgeConfig
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Len(t, conf.FollowMeConfig.steps, 2)
+	require.False(t, conf.Confirm)
+	require.NotEmpty(t, conf.ConfirmPrompt)
+
+	delete(data, "follow_me_steps")
+	require.Error(t, DecodeCellConfig(data, &BridgeConfig{}))
+}
@@ -0,0 +1,394 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+)
+
+const (
+	HANDLER_DTMF     = "dtmf"
+	HANDLER_DURATION = "duration"
+	HANDLER_SILENCE  = "silence"
+)
+
+// HandlerMode is how the global handlers of a flow behave while a cell of a
+// type runs.
+type HandlerMode int
+
+const (
+	// HANDLERS_ACTIVE leaves the handlers on.
+	HANDLERS_ACTIVE HandlerMode = iota
+	// HANDLERS_DIGITS leaves the digits the caller presses to the cell, e.g.
+	// an Input cell collecting an account number that contains a 0 which
+	// opted out of the dtmf handlers.
+	HANDLERS_DIGITS
+	// HANDLERS_SUSPENDED turns the handlers off while the cell owns the media
+	// of the call, e.g. to bridge, queue or record it. Duration handlers that
+	// became due meanwhile fire once the cell is done.
+	HANDLERS_SUSPENDED
+)
+
+// handlerModeConfig is the config of a cell that picks the handler mode
+// itself instead of its cell type.
+type handlerModeConfig interface {
+	HandlerMode() HandlerMode
+}
+
+// handlerGate is what the handlers of a call need to know about the cell
+// that is running: its handler mode and whether a prompt is playing, which
+// does not count as the caller being silent.
+type handlerGate struct {
+	mu      sync.Mutex
+	mode    HandlerMode
+	owner   *int
+	prompts int
+	// dtmf are the digits of the dtmf handlers
+	dtmf []string
+	// changed wakes up the watcher of the handlers
+	changed chan struct{}
+}
+
+func (gate *handlerGate) notify() {
+	select {
+	case gate.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (gate *handlerGate) state() (HandlerMode, bool) {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	return gate.mode, gate.prompts > 0
+}
+
+var (
+	handlerGatesMu sync.Mutex
+	handlerGates   = make(map[string]*handlerGate)
+)
+
+func lookupHandlerGate(lineChannel *types.LineChannel) *handlerGate {
+	if lineChannel == nil || lineChannel.Channel == nil {
+		return nil
+	}
+	handlerGatesMu.Lock()
+	defer handlerGatesMu.Unlock()
+	return handlerGates[lineChannel.Channel.ID()]
+}
+
+// setHandlerMode applies the handler mode of a cell to the call until the
+// returned func is called. A cell that is cancelled after another one took
+// over the call does not change the mode of the new cell.
+func setHandlerMode(lineChannel *types.LineChannel, mode HandlerMode) func() {
+	gate := lookupHandlerGate(lineChannel)
+	if gate == nil {
+		return func() {}
+	}
+	owner := new(int)
+	gate.mu.Lock()
+	gate.mode, gate.owner = mode, owner
+	gate.mu.Unlock()
+	gate.notify()
+	return func() {
+		gate.mu.Lock()
+		if gate.owner == owner {
+			gate.mode, gate.owner = HANDLERS_ACTIVE, nil
+		}
+		gate.mu.Unlock()
+		gate.notify()
+	}
+}
+
+// playingPrompt tells the handlers of the call that a prompt plays until
+// the returned func is called.
+func playingPrompt(lineChannel *types.LineChannel) func() {
+	gate := lookupHandlerGate(lineChannel)
+	if gate == nil {
+		return func() {}
+	}
+	gate.mu.Lock()
+	gate.prompts++
+	gate.mu.Unlock()
+	gate.notify()
+	return func() {
+		gate.mu.Lock()
+		gate.prompts--
+		gate.mu.Unlock()
+		gate.notify()
+	}
+}
+
+// dtmfFilter keeps the digits of the dtmf handlers from a cell that collects
+// digits while the handlers are active. Digits that may be the start of the
+// digits of a handler are held back until the next digit tells.
+type dtmfFilter struct {
+	sequences []string
+	held      string
+}
+
+func newDtmfFilter(lineChannel *types.LineChannel) *dtmfFilter {
+	filter := &dtmfFilter{}
+	if gate := lookupHandlerGate(lineChannel); gate != nil {
+		gate.mu.Lock()
+		if gate.mode == HANDLERS_ACTIVE {
+			filter.sequences = gate.dtmf
+		}
+		gate.mu.Unlock()
+	}
+	return filter
+}
+
+// Receive returns the digits that are for the cell once digit was pressed.
+func (filter *dtmfFilter) Receive(digit string) string {
+	held := filter.held + digit
+	for _, sequence := range filter.sequences {
+		if strings.HasSuffix(held, sequence) {
+			// the handler takes the digits and moves the call on
+			filter.held = ""
+			return held[:len(held)-len(sequence)]
+		}
+	}
+	for i := range held {
+		for _, sequence := range filter.sequences {
+			if strings.HasPrefix(sequence, held[i:]) {
+				filter.held = held[i:]
+				return held[:i]
+			}
+		}
+	}
+	filter.held = ""
+	return held
+}
+
+// Flush returns the digits that were held back.
+func (filter *dtmfFilter) Flush() string {
+	held := filter.held
+	filter.held = ""
+	return held
+}
+
+// GlobalHandler moves a call to its target cell when its event happens,
+// whichever cell the call is in. Handlers are listed in the "handlers" field
+// of the launch cell, e.g.
+// {"type": "dtmf", "digits": "0", "target": "Operator"},
+// {"type": "duration", "seconds": "600", "target": "Goodbye"} or
+// {"type": "silence", "seconds": "15", "target": "Reprompt"}.
+type GlobalHandler struct {
+	Type string
+	// Digits is the DTMF sequence of a dtmf handler.
+	Digits string
+	// After is how long the call lasts before a duration handler fires, or
+	// how long the caller is silent before a silence handler does.
+	After  time.Duration
+	Target string
+}
+
+// parseHandlers reads the handlers of a launch cell.
+func parseHandlers(entries []map[string]string) ([]*GlobalHandler, error) {
+	handlers := make([]*GlobalHandler, 0, len(entries))
+	for i, entry := range entries {
+		handler := &GlobalHandler{
+			Type:   strings.TrimSpace(entry["type"]),
+			Digits: strings.TrimSpace(entry["digits"]),
+			Target: strings.TrimSpace(entry["target"])}
+		prefix := "handler " + strconv.Itoa(i+1) + ": "
+		if handler.Target == "" {
+			return nil, errors.New(prefix + "target is required")
+		}
+		switch handler.Type {
+		case HANDLER_DTMF:
+			if handler.Digits == "" {
+				return nil, errors.New(prefix + "digits are required")
+			}
+		case HANDLER_DURATION, HANDLER_SILENCE:
+			seconds, err := strconv.ParseFloat(strings.TrimSpace(entry["seconds"]), 64)
+			if err != nil || seconds <= 0 {
+				return nil, errors.New(prefix + "seconds must be a positive number, got " + strconv.Quote(entry["seconds"]))
+			}
+			handler.After = time.Duration(seconds * float64(time.Second))
+		default:
+			return nil, errors.New(prefix + "type must be dtmf, duration or silence, got " + strconv.Quote(handler.Type))
+		}
+		handlers = append(handlers, handler)
+	}
+	return handlers, nil
+}
+
+// flowHandlers returns the handlers of the launch cell of a flow.
+func flowHandlers(flow *types.Flow) ([]*GlobalHandler, error) {
+	for _, cell := range flow.Cells {
+		if cell.Cell.Type != "devs.LaunchModel" || cell.Model == nil {
+			continue
+		}
+		list, ok := cell.Model.Data["handlers"].(types.ModelDataList)
+		if !ok {
+			return nil, nil
+		}
+		return parseHandlers(list.Value)
+	}
+	return nil, nil
+}
+
+// openHandlerGate registers the gate of the handlers of a call before its
+// first cell runs.
+func openHandlerGate(lineChannel *types.LineChannel, handlers []*GlobalHandler) *handlerGate {
+	if lineChannel == nil || lineChannel.Channel == nil || len(handlers) == 0 {
+		return nil
+	}
+	gate := &handlerGate{changed: make(chan struct{}, 1)}
+	for _, handler := range handlers {
+		if handler.Type == HANDLER_DTMF {
+			gate.dtmf = append(gate.dtmf, handler.Digits)
+		}
+	}
+	handlerGatesMu.Lock()
+	handlerGates[lineChannel.Channel.ID()] = gate
+	handlerGatesMu.Unlock()
+	return gate
+}
+
+// watchHandlers fires the global handlers of a flow until the call ends. A
+// handler interrupts the cell that is running, like GotoCell, and the flow
+// continues at its target with the type of the handler in {{handler}}. The
+// handler mode of the running cell can hold the handlers back, and the
+// caller is only silent while no prompt plays.
+func watchHandlers(ctx context.Context, lineChannel *types.LineChannel, handlers []*GlobalHandler, gate *handlerGate) {
+	if gate == nil {
+		return
+	}
+	channel := lineChannel.Channel
+	defer func() {
+		handlerGatesMu.Lock()
+		if handlerGates[channel.ID()] == gate {
+			delete(handlerGates, channel.ID())
+		}
+		handlerGatesMu.Unlock()
+	}()
+
+	longest := 0
+	for _, handler := range handlers {
+		if len(handler.Digits) > longest {
+			longest = len(handler.Digits)
+		}
+	}
+	for _, handler := range handlers {
+		if handler.Type == HANDLER_SILENCE {
+			// asterisk only reports talking on channels with talk detection
+			if err := channel.SetVariable("TALK_DETECT(set)", ""); err != nil {
+				helpers.Log(logrus.ErrorLevel, "could not detect talking: "+err.Error())
+			}
+			break
+		}
+	}
+	sub := channel.Subscribe(ari.Events.ChannelDtmfReceived, ari.Events.ChannelTalkingStarted, ari.Events.ChannelTalkingFinished)
+	defer sub.Cancel()
+
+	fire := func(handler *GlobalHandler) {
+		helpers.Log(logrus.InfoLevel, "global "+handler.Type+" handler moves channel "+channel.ID()+" to "+handler.Target)
+		if err := GotoCell(channel.ID(), handler.Target, map[string]string{"handler": handler.Type}); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not run global handler: "+err.Error())
+		}
+	}
+	start := time.Now()
+	lastActivity := start
+	talking := false
+	fired := make(map[*GlobalHandler]bool)
+	digits := ""
+	mode, prompting := gate.state()
+	for {
+		// the timed handler that fires next
+		var next *GlobalHandler
+		var at time.Time
+		for _, handler := range handlers {
+			var due time.Time
+			switch {
+			case mode == HANDLERS_SUSPENDED:
+				continue
+			case handler.Type == HANDLER_DURATION && !fired[handler]:
+				due = start.Add(handler.After)
+			case handler.Type == HANDLER_SILENCE && !talking && !prompting:
+				due = lastActivity.Add(handler.After)
+			default:
+				continue
+			}
+			if next == nil || due.Before(at) {
+				next, at = handler, due
+			}
+		}
+		var expired <-chan time.Time
+		var timer *time.Timer
+		if next != nil {
+			timer = time.NewTimer(time.Until(at))
+			expired = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-gate.changed:
+			if timer != nil {
+				timer.Stop()
+			}
+			wasSilent := mode != HANDLERS_SUSPENDED && !prompting
+			mode, prompting = gate.state()
+			if !wasSilent && mode != HANDLERS_SUSPENDED && !prompting {
+				// silence counts from the end of the prompt or of the cell
+				// that held the handlers back
+				lastActivity = time.Now()
+			}
+			if mode != HANDLERS_ACTIVE {
+				digits = ""
+			}
+		case <-expired:
+			if next.Type == HANDLER_DURATION {
+				fired[next] = true
+			} else {
+				// a caller that stays silent is handled again later
+				lastActivity = time.Now()
+			}
+			fire(next)
+		case e, ok := <-sub.Events():
+			if timer != nil {
+				timer.Stop()
+			}
+			if !ok {
+				return
+			}
+			switch event := e.(type) {
+			case *ari.ChannelDtmfReceived:
+				lastActivity = time.Now()
+				if mode != HANDLERS_ACTIVE {
+					// the digits are for the cell
+					continue
+				}
+				digits += event.Digit
+				if len(digits) > longest {
+					digits = digits[len(digits)-longest:]
+				}
+				for _, handler := range handlers {
+					if handler.Type == HANDLER_DTMF && strings.HasSuffix(digits, handler.Digits) {
+						digits = ""
+						fire(handler)
+						break
+					}
+				}
+			case *ari.ChannelTalkingStarted:
+				talking = true
+			case *ari.ChannelTalkingFinished:
+				talking = false
+				lastActivity = time.Now()
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+// operatorFlow waits in Wait1 until the caller presses 0 or the call lasts
+// too long.
+const operatorFlow = `{
+  "graph": {"cells": [
+    {"id": "launch", "name": "Launch", "type": "devs.LaunchModel"},
+    {"id": "wait", "name": "Wait1", "type": "devs.WaitModel"},
+    {"id": "operator", "name": "Operator", "type": "devs.SetVariablesModel"},
+    {"id": "l1", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "wait", "port": "In"}}
+  ]},
+  "models": [
+    {"id": "launch", "name": "Launch", "data": {"handlers": [
+      {"type": "dtmf", "digits": "*0", "target": "Operator"},
+      {"type": "duration", "seconds": %s, "target": "Operator"}
+    ]}},
+    {"id": "wait", "name": "Wait1", "data": {"wait_seconds": "30"}},
+    {"id": "operator", "name": "Operator", "data": {"variables": [{"name": "reached", "value": "{{handler}}"}]}}
+  ]
+}`
+
+func TestParseHandlers(t *testing.T) {
+	handlers, err := parseHandlers([]map[string]string{
+		{"type": "dtmf", "digits": "0", "target": "Operator"},
+		{"type": "silence", "seconds": "1.5", "target": "Reprompt"}})
+	require.NoError(t, err)
+	require.Equal(t, "0", handlers[0].Digits)
+	require.Equal(t, 1500*time.Millisecond, handlers[1].After)
+
+	tests := []map[string]string{
+		{"type": "dtmf", "target": "Operator"},
+		{"type": "duration", "seconds": "soon", "target": "Goodbye"},
+		{"type": "hangup", "target": "Goodbye"},
+		{"type": "dtmf", "digits": "0"},
+	}
+	for _, entry := range tests {
+		_, err := parseHandlers([]map[string]string{entry})
+		require.Error(t, err, entry)
+	}
+}
+
+func startOperatorFlow(t *testing.T, id string, seconds string) (*liveChannel, *types.Flow) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	channel := newLiveChannel(id)
+	lineChannel := &types.LineChannel{Channel: channel.Get(ari.NewKey(ari.ChannelKey, id))}
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(operatorFlow, seconds)), &vars))
+	flow := types.NewFlow(5, types.NewUser(1, 2, "test"), &vars, lineChannel, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		EndFlow(flow)
+	})
+	go ProcessFlow(nil, ctx, flow, lineChannel, make(map[string]string), flow.Cells[0])
+	require.Eventually(t, func() bool {
+		entries := flow.Trace.Snapshot().Entries
+		return len(entries) == 2
+	}, time.Second, 5*time.Millisecond)
+	return channel, flow
+}
+
+func TestDtmfHandler(t *testing.T) {
+	channel, flow := startOperatorFlow(t, "handler-1", "600")
+	for _, digit := range []string{"1", "*", "0"} {
+		channel.bus.Send(&ari.ChannelDtmfReceived{
+			EventData: ari.EventData{Type: ari.Events.ChannelDtmfReceived},
+			Channel:   ari.ChannelData{ID: "handler-1"},
+			Digit:     digit})
+	}
+	require.Eventually(t, func() bool {
+		reached, _ := flow.GetVariable("reached")
+		return reached == HANDLER_DTMF
+	}, time.Second, 5*time.Millisecond)
+	entries := flow.Trace.Snapshot().Entries
+	require.Equal(t, "Wait1", entries[1].CellName)
+	require.Equal(t, "cancelled", entries[1].Error)
+}
+
+func TestDurationHandler(t *testing.T) {
+	_, flow := startOperatorFlow(t, "handler-2", "0.05")
+	require.Eventually(t, func() bool {
+		reached, _ := flow.GetVariable("reached")
+		return reached == HANDLER_DURATION
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHandlerModeOwner(t *testing.T) {
+	channel := newLiveChannel("handler-3")
+	lineChannel := &types.LineChannel{Channel: channel.Get(ari.NewKey(ari.ChannelKey, "handler-3"))}
+	handlers := []*GlobalHandler{{Type: HANDLER_DTMF, Digits: "0", Target: "Operator"}}
+	gate := openHandlerGate(lineChannel, handlers)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchHandlers(ctx, lineChannel, handlers, gate)
+
+	// a bridge cancelled by GotoCell ends after the input cell that replaced it
+	releaseBridge := setHandlerMode(lineChannel, HANDLERS_SUSPENDED)
+	releaseInput := setHandlerMode(lineChannel, HANDLERS_DIGITS)
+	releaseBridge()
+	mode, _ := gate.state()
+	require.Equal(t, HANDLERS_DIGITS, mode)
+	releaseInput()
+	mode, _ = gate.state()
+	require.Equal(t, HANDLERS_ACTIVE, mode)
+
+	done := playingPrompt(lineChannel)
+	_, prompting := gate.state()
+	require.True(t, prompting)
+	done()
+	_, prompting = gate.state()
+	require.False(t, prompting)
+}
+
+func TestDtmfFilter(t *testing.T) {
+	filter := &dtmfFilter{sequences: []string{"*0", "9"}}
+	require.Equal(t, "1", filter.Receive("1"))
+	// * may be the start of *0
+	require.Equal(t, "", filter.Receive("*"))
+	require.Equal(t, "*2", filter.Receive("2"))
+	require.Equal(t, "", filter.Receive("*"))
+	require.Equal(t, "", filter.Receive("0"))
+	require.Equal(t, "", filter.Receive("9"))
+	require.Equal(t, "", filter.Receive("*"))
+	require.Equal(t, "*", filter.Flush())
+}
@@ -2,11 +2,13 @@ package mngrs
 
 import (
 	//"context"
+	"errors"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
 	"github.com/sirupsen/logrus"
 
 	helpers "github.com/Lineblocs/go-helpers"
@@ -14,6 +16,87 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+const (
+	INPUT_DTMF   = "DTMF"
+	INPUT_SPEECH = "Speech"
+	// INPUT_SPEECH_OR_DTMF collects digits instead of speech once the
+	// caller presses a key.
+	INPUT_SPEECH_OR_DTMF = "Speech or DTMF"
+)
+
+// InputConfig is the config of Process Input cells.
+type InputConfig struct {
+	PromptConfig
+	InputType string `cell:"input_type" default:"DTMF"`
+	// StopTimeout is the number of seconds without a digit after which the
+	// digits are collected. With speech it is the silence that ends the
+	// utterance. It and MaxDigits are only required to collect digits.
+	StopTimeout          float64 `cell:"stop_timeout"`
+	MaxDigits            int     `cell:"max_digits"`
+	StopGatherOnKeypress bool    `cell:"stop_gather_on_keypress"`
+	KeypressKeyStop      string  `cell:"keypress_key_stop"`
+	SpeechLanguage       string  `cell:"speech_language" default:"en-US"`
+	// SpeechHints are phrases the caller is likely to say.
+	SpeechHints []string `cell:"speech_hints"`
+	// SpeechMaxLength is the longest utterance in seconds.
+	SpeechMaxLength int `cell:"speech_max_length" default:"15"`
+	// DtmfHandlers leaves the dtmf handlers of the flow on while the cell
+	// collects digits. Cells that collect e.g. account numbers turn it off.
+	DtmfHandlers bool `cell:"dtmf_handlers" default:"true"`
+}
+
+func (conf *InputConfig) HandlerMode() HandlerMode {
+	if conf.DtmfHandlers {
+		return HANDLERS_ACTIVE
+	}
+	return HANDLERS_DIGITS
+}
+
+func (conf *InputConfig) Validate() error {
+	switch conf.InputType {
+	case INPUT_DTMF:
+	case INPUT_SPEECH, INPUT_SPEECH_OR_DTMF:
+		if conf.SpeechLanguage == "" {
+			return &CellConfigError{Field: "speech_language", Message: "is required for speech"}
+		}
+		if conf.SpeechMaxLength <= 0 {
+			return &CellConfigError{Field: "speech_max_length", Message: "must be more than 0"}
+		}
+	default:
+		return &CellConfigError{Field: "input_type", Message: "must be DTMF, Speech or Speech or DTMF, got " + conf.InputType}
+	}
+	if conf.InputType == INPUT_SPEECH {
+		if conf.StopTimeout < 0 {
+			return &CellConfigError{Field: "stop_timeout", Message: "can not be negative"}
+		}
+	} else {
+		if conf.StopTimeout <= 0 {
+			return &CellConfigError{Field: "stop_timeout", Message: "must be more than 0 to collect digits"}
+		}
+		if conf.MaxDigits <= 0 {
+			return &CellConfigError{Field: "max_digits", Message: "must be more than 0 to collect digits"}
+		}
+	}
+	if conf.StopGatherOnKeypress && conf.KeypressKeyStop == "" {
+		return &CellConfigError{Field: "keypress_key_stop", Message: "is required to stop on a keypress"}
+	}
+	return conf.PromptConfig.Validate()
+}
+
+// speechOptions returns how utterances are recorded. They end after a
+// second of silence at least.
+func (conf *InputConfig) speechOptions() *ari.RecordingOptions {
+	silence := time.Duration(conf.StopTimeout * float64(time.Second))
+	if silence < time.Second {
+		silence = time.Second
+	}
+	return &ari.RecordingOptions{
+		Format:      "wav",
+		MaxDuration: time.Duration(conf.SpeechMaxLength) * time.Second,
+		MaxSilence:  silence,
+		Terminate:   "none"}
+}
+
 type InputManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -28,125 +111,248 @@ func NewInputManager(mngrCtx *types.Context, flow *types.Flow) *InputManager {
 }
 func (man *InputManager) StartProcessing() {
 	helpers.Log(logrus.DebugLevel, "Creating playback for INPUT... ")
-	cell := man.ManagerContext.Cell
 	flow := man.ManagerContext.Flow
-	data := cell.Model.Data
-	playbackType := data["playback_type"].(types.ModelDataStr).Value
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Finished")
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Finished")
-
-	stopTimeout, err := strconv.ParseFloat(data["stop_timeout"].(types.ModelDataStr).Value, 64)
+	var conf InputConfig
+	if err := loadConfig(man.ManagerContext, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid input cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
 
+	file, err := conf.prompt(flow)
 	if err != nil {
-		helpers.Log(logrus.DebugLevel, "error parsing stop timeout. value was:  "+data["stop_timeout"].(types.ModelDataStr).Value)
+		helpers.Log(logrus.ErrorLevel, "error downloading: "+err.Error())
+		failCell(man.ManagerContext, err)
 		return
 	}
 
-	maxDigits, err := strconv.Atoi(data["max_digits"].(types.ModelDataStr).Value)
+	if conf.InputType != INPUT_DTMF {
+		goCell(man.ManagerContext, func() {
+			man.processSpeech(&conf, file)
+		})
+		return
+	}
 
-	stopGatherOnKeypress := data["stop_gather_on_keypress"].(types.ModelDataBool).Value
-	keypressKeyStop := data["keypress_key_stop"].(types.ModelDataStr).Value
 	stopChannel := make(chan bool, 1)
+	promptDone := make(chan struct{})
 	wg1 := new(sync.WaitGroup)
 	wg1.Add(1)
-	go man.attachDtmfListeners(stopTimeout, maxDigits, stopGatherOnKeypress, keypressKeyStop, wg1, stopChannel)
+	goCell(man.ManagerContext, func() {
+		man.attachDtmfListeners(&conf, wg1, stopChannel, promptDone)
+	})
 	wg1.Wait()
 
-	if err != nil {
-		helpers.Log(logrus.DebugLevel, "error parsing max digits. value was:  "+data["max_digits"].(types.ModelDataStr).Value)
-		return
-	}
-
-	switch playbackType {
-	case "Say":
-
-		helpers.Log(logrus.DebugLevel, "processing TTS")
-		file, err := utils.StartTTS(data["text_to_say"].(types.ModelDataStr).Value,
-			data["text_gender"].(types.ModelDataStr).Value,
-			data["voice"].(types.ModelDataStr).Value,
-			data["text_language"].(types.ModelDataStr).Value,
-		)
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "error downloading: "+err.Error())
-		}
-
-		go man.beginPrompt(file, stopChannel)
-	case "Play":
-
-		helpers.Log(logrus.DebugLevel, "processing TTS")
-		file, err := utils.DownloadFile(flow, data["url_audio"].(types.ModelDataStr).Value)
-
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "error downloading: "+err.Error())
-		}
-		go man.beginPrompt(file, stopChannel)
-
-	}
+	goCell(man.ManagerContext, func() {
+		man.beginPrompt(file, stopChannel, promptDone)
+	})
 }
 
-func (man *InputManager) attachDtmfListeners(stopTimeout float64, maxDigits int, stopGatherOnKeypress bool, keypressKeyStop string, wg *sync.WaitGroup, stopChannel chan<- bool) {
+// attachDtmfListeners collects digits until the stop key is pressed, the
+// maximum number of digits is reached or no digit was received for the stop
+// timeout. The stop timeout starts once the prompt finished, so callers who
+// do not enter anything move on with no digits.
+func (man *InputManager) attachDtmfListeners(conf *InputConfig, wg *sync.WaitGroup, stopChannel chan<- bool, promptDone <-chan struct{}) {
 	channel := man.ManagerContext.Channel
-	ctx := man.ManagerContext.Context
 	helpers.Log(logrus.DebugLevel, "listening for DTMF..")
 	dtmfSub := channel.Channel.Subscribe(ari.Events.ChannelDtmfReceived)
 	defer dtmfSub.Cancel()
-	var timeLastDtmfWasReceived *time.Time
-	timeLastDtmfWasReceived = nil
-	collectedDtmf := ""
 
 	wg.Done()
+	man.gatherDigits(conf, dtmfSub.Events(), "", promptDone, func() { stopChannel <- true })
+}
+
+// gatherDigits collects digits from the DTMF events, starting with the
+// first digit when the caller already pressed one. stopPrompt is called
+// once the digits are collected.
+func (man *InputManager) gatherDigits(conf *InputConfig, events <-chan ari.Event, first string, promptDone <-chan struct{}, stopPrompt func()) {
+	ctx := man.ManagerContext.Context
+	stopTimeout := time.Duration(conf.StopTimeout * float64(time.Second))
+	var gatherTimeout <-chan time.Time
+	collectedDtmf := ""
+	// the digits of the dtmf handlers are not for the cell
+	filter := newDtmfFilter(man.ManagerContext.Channel)
+
+	// collect adds a digit and tells whether the digits are collected.
+	collect := func(digit string) bool {
+		// stop due to key pressed
+		if conf.StopGatherOnKeypress && digit == conf.KeypressKeyStop {
+			return true
+		}
+
+		collectedDtmf += digit
+		// max digits
+		return len(collectedDtmf) >= conf.MaxDigits
+	}
+	// receive takes a digit the caller pressed and tells whether the digits
+	// are collected.
+	receive := func(digit string) bool {
+		helpers.Log(logrus.DebugLevel, "input received DTMF: "+digit)
+		for _, item := range filter.Receive(digit) {
+			if collect(string(item)) {
+				return true
+			}
+		}
+		gatherTimeout = time.After(stopTimeout)
+		return false
+	}
+	if first != "" && receive(first) {
+		stopPrompt()
+		man.finishInput(collectedDtmf, nil)
+		return
+	}
 	for {
 
 		select {
 		case <-ctx.Done():
 			return
-		case e, ok := <-dtmfSub.Events():
+		case <-promptDone:
+			promptDone = nil
+			if gatherTimeout == nil {
+				gatherTimeout = time.After(stopTimeout)
+			}
+		case <-gatherTimeout:
+			helpers.Log(logrus.DebugLevel, "input timed out waiting for DTMF")
+			for _, item := range filter.Flush() {
+				if collect(string(item)) {
+					break
+				}
+			}
+			stopPrompt()
+			man.finishInput(collectedDtmf, nil)
+			return
+		case e, ok := <-events:
 
 			if !ok {
 				helpers.Log(logrus.DebugLevel, "error fetching event")
+				failCell(man.ManagerContext, errors.New("DTMF subscription closed"))
 				return
 			}
 
-			v := e.(*ari.ChannelDtmfReceived)
-			digit := v.Digit
-			helpers.Log(logrus.DebugLevel, "input received DTMF: "+digit)
-
-			// stop due to key pressed
-			if stopGatherOnKeypress && digit == keypressKeyStop {
-				stopChannel <- true
-				man.finishProcessingDTMF(collectedDtmf)
+			if receive(e.(*ari.ChannelDtmfReceived).Digit) {
+				stopPrompt()
+				man.finishInput(collectedDtmf, nil)
 				return
 			}
+		}
+	}
+}
 
-			collectedDtmf += digit
-			if timeLastDtmfWasReceived != nil {
-				elapsed := time.Since(*timeLastDtmfWasReceived).Seconds()
+// processSpeech plays the prompt and then records what the caller says
+// until they are silent, and transcribes it. In the "Speech or DTMF" mode
+// a key pressed during the prompt or the utterance scraps the recording and
+// digits are collected instead.
+func (man *InputManager) processSpeech(conf *InputConfig, file string) {
+	ctx := man.ManagerContext
+	channel := ctx.Channel
+	var dtmfEvents <-chan ari.Event
+	if conf.InputType == INPUT_SPEECH_OR_DTMF {
+		dtmfSub := channel.Channel.Subscribe(ari.Events.ChannelDtmfReceived)
+		defer dtmfSub.Cancel()
+		dtmfEvents = dtmfSub.Events()
+	}
 
-				if elapsed > stopTimeout {
-					// time was elapsed
-					stopChannel <- true
-					man.finishProcessingDTMF(collectedDtmf)
-					return
-				}
-			}
+	stopChannel := make(chan bool, 1)
+	promptDone := make(chan struct{})
+	var stopOnce sync.Once
+	stopPrompt := func() {
+		stopOnce.Do(func() { stopChannel <- true })
+	}
+	goCell(ctx, func() {
+		man.beginPrompt(file, stopChannel, promptDone)
+	})
 
-			if len(collectedDtmf) >= maxDigits {
-				// max digits
-				stopChannel <- true
-				man.finishProcessingDTMF(collectedDtmf)
+	var recording *ari.LiveRecordingHandle
+	var recordingEvents <-chan ari.Event
+	for {
+		select {
+		case <-ctx.Context.Done():
+			helpers.Log(logrus.DebugLevel, "speech input cancelled")
+			return
+		case <-promptDone:
+			promptDone = nil
+			var err error
+			recording, err = channel.Channel.Record(rid.New(rid.Recording), conf.speechOptions())
+			if err != nil {
+				helpers.Log(logrus.ErrorLevel, "could not record speech: "+err.Error())
+				failCell(ctx, err)
+				return
+			}
+			recordingSub := recording.Subscribe(ari.Events.RecordingFinished, ari.Events.RecordingFailed)
+			defer recordingSub.Cancel()
+			recordingEvents = recordingSub.Events()
+		case e, ok := <-recordingEvents:
+			if !ok {
+				failCell(ctx, errors.New("recording subscription closed"))
+				return
+			}
+			if failed, ok := e.(*ari.RecordingFailed); ok {
+				failCell(ctx, errors.New("recording failed: "+failed.Recording.Cause))
+				return
+			}
+			man.finishSpeech(conf, recording, &e.(*ari.RecordingFinished).Recording)
+			return
+		case e, ok := <-dtmfEvents:
+			if !ok {
+				failCell(ctx, errors.New("DTMF subscription closed"))
 				return
 			}
+			helpers.Log(logrus.DebugLevel, "caller chose DTMF over speech")
+			stopPrompt()
+			if recording != nil {
+				if err := recording.Scrap(); err != nil {
+					helpers.Log(logrus.ErrorLevel, "could not scrap speech recording: "+err.Error())
+				}
+			}
+			man.gatherDigits(conf, dtmfEvents, e.(*ari.ChannelDtmfReceived).Digit, promptDone, stopPrompt)
+			return
+		}
+	}
+}
 
-			now := time.Now()
-			timeLastDtmfWasReceived = &now
+// finishSpeech transcribes a finished utterance and deletes its recording.
+// Recordings in which silence detection heard no talking are not
+// transcribed.
+func (man *InputManager) finishSpeech(conf *InputConfig, recording *ari.LiveRecordingHandle, data *ari.LiveRecordingData) {
+	ctx := man.ManagerContext
+	stored := recording.Stored()
+	defer func() {
+		if err := stored.Delete(); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not delete speech recording "+data.Name+": "+err.Error())
 		}
+	}()
+	if data.Talking == 0 && data.Silence > 0 {
+		helpers.Log(logrus.DebugLevel, "caller did not say anything")
+		man.finishInput("", &utils.Transcript{})
+		return
+	}
+	audio, err := stored.File()
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not fetch speech recording "+data.Name+": "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+	transcript, err := utils.Transcribe(ctx.Context, audio, &utils.SpeechOptions{
+		Language: conf.SpeechLanguage,
+		Hints:    conf.SpeechHints})
+	if ctx.Context.Err() != nil {
+		helpers.Log(logrus.DebugLevel, "call ended while transcribing speech")
+		return
 	}
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not transcribe speech: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+	man.finishInput("", transcript)
 }
 
-func (man *InputManager) beginPrompt(prompt string, stopChannel <-chan bool) {
+// beginPrompt plays the prompt and closes promptDone once it is over, even
+// when it could not be played.
+func (man *InputManager) beginPrompt(prompt string, stopChannel <-chan bool, promptDone chan<- struct{}) {
+	defer close(promptDone)
 	channel := man.ManagerContext.Channel
 	uri := "sound:" + prompt
-	playback, err := channel.Channel.Play(channel.Channel.Key().ID, uri)
+	playback, err := channel.Channel.Play(rid.New(rid.Playback), uri)
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "failed to play join sound, error:"+err.Error())
 		return
@@ -154,13 +360,20 @@ func (man *InputManager) beginPrompt(prompt string, stopChannel <-chan bool) {
 	finishedSub := playback.Subscribe(ari.Events.PlaybackFinished)
 	defer finishedSub.Cancel()
 	helpers.Log(logrus.DebugLevel, "PLAYBACK started...")
+	// the caller is not silent while the prompt plays
+	defer playingPrompt(channel)()
 
 	for {
 		select {
 		case <-finishedSub.Events():
 			helpers.Log(logrus.DebugLevel, "playback finished...")
 			return
-
+		case <-man.ManagerContext.Context.Done():
+			helpers.Log(logrus.DebugLevel, "stopping cancelled playback...")
+			if err := playback.Stop(); err != nil {
+				helpers.Log(logrus.DebugLevel, "error occurred: "+err.Error())
+			}
+			return
 		case <-stopChannel:
 			helpers.Log(logrus.DebugLevel, "requested playback stop..")
 			err := playback.Stop()
@@ -172,15 +385,31 @@ func (man *InputManager) beginPrompt(prompt string, stopChannel <-chan bool) {
 	}
 }
 
-func (man *InputManager) finishProcessingDTMF(result string) {
+// finishInput sets the digits, or the speech and its confidence, and
+// follows "Digits Received". Callers who entered nothing follow "No Input"
+// when it is linked.
+func (man *InputManager) finishInput(digits string, transcript *utils.Transcript) {
 	ctx := man.ManagerContext
-
 	cell := man.ManagerContext.Cell
 
-	helpers.Log(logrus.DebugLevel, "finish processing DTMF...")
-	cell.EventVars["digits"] = result
-	digits, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Digits Received")
+	helpers.Log(logrus.DebugLevel, "finish processing input...")
+	cell.SetEventVar("digits", digits)
+	empty := digits == ""
+	if transcript != nil {
+		cell.SetEventVar("speech", transcript.Text)
+		cell.SetEventVar("confidence", strconv.FormatFloat(float64(transcript.Confidence), 'f', 2, 32))
+		empty = transcript.Text == ""
+	}
+	if ctx.Context.Err() != nil {
+		return
+	}
+	next, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Digits Received")
+	if empty {
+		if noInput, err := utils.FindLinkByName(cell.SourceLinks, "source", "No Input"); err == nil {
+			next = noInput
+		}
+	}
 	resp := types.ManagerResponse{
-		Channel: ctx.Channel, Link: digits}
+		Channel: ctx.Channel, Link: next}
 	man.ManagerContext.RecvChannel <- &resp
 }
@@ -0,0 +1,48 @@
+package mngrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestInputSpeechConfig(t *testing.T) {
+	data := map[string]types.ModelData{
+		"playback_type": types.ModelDataStr{Value: "Say"},
+		"text_to_say":   types.ModelDataStr{Value: "Which department?"},
+		"max_digits":    types.ModelDataStr{Value: "1"},
+		"stop_timeout":  types.ModelDataStr{Value: "0.5"}}
+	var conf InputConfig
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Equal(t, INPUT_DTMF, conf.InputType)
+
+	data["input_type"] = types.ModelDataStr{Value: "Speech"}
+	data["speech_hints"] = types.ModelDataStr{Value: "sales, support"}
+	conf = InputConfig{}
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Equal(t, "en-US", conf.SpeechLanguage)
+	require.Equal(t, []string{"sales", "support"}, conf.SpeechHints)
+	// utterances end after a second of silence at least
+	require.Equal(t, &ari.RecordingOptions{
+		Format:      "wav",
+		MaxDuration: 15 * time.Second,
+		MaxSilence:  time.Second,
+		Terminate:   "none"}, conf.speechOptions())
+
+	// speech does not collect digits
+	delete(data, "max_digits")
+	delete(data, "stop_timeout")
+	require.NoError(t, DecodeCellConfig(data, &InputConfig{}))
+	data["input_type"] = types.ModelDataStr{Value: "Speech or DTMF"}
+	require.Error(t, DecodeCellConfig(data, &InputConfig{}))
+	data["input_type"] = types.ModelDataStr{Value: "Speech"}
+
+	data["speech_max_length"] = types.ModelDataStr{Value: "0"}
+	require.Error(t, DecodeCellConfig(data, &InputConfig{}))
+	delete(data, "speech_max_length")
+	data["input_type"] = types.ModelDataStr{Value: "Voice"}
+	require.Error(t, DecodeCellConfig(data, &InputConfig{}))
+}
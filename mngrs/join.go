@@ -0,0 +1,103 @@
+package mngrs
+
+import (
+	"strings"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// JoinConfig is the config of Join cells.
+type JoinConfig struct {
+	Mode string `cell:"mode" default:"all"`
+}
+
+func (conf *JoinConfig) Validate() error {
+	if conf.Mode != types.JOIN_ALL && conf.Mode != types.JOIN_ANY {
+		return &CellConfigError{Field: "mode", Message: "must be all or any, got " + conf.Mode}
+	}
+	return nil
+}
+
+// JoinManager brings the branches of a launch cell back together. With mode
+// "all" it waits until every branch reached the join or ended, with "any"
+// until the first one did. The first branch to arrive continues and the
+// variables the branches set are merged in the order of the launch links, so
+// the last link wins when two branches set the same variable. The other
+// branches stop at the join.
+type JoinManager struct {
+	ManagerContext *types.Context
+	Flow           *types.Flow
+}
+
+func NewJoinManager(mngrCtx *types.Context, flow *types.Flow) *JoinManager {
+	item := JoinManager{
+		ManagerContext: mngrCtx,
+		Flow:           flow}
+	return &item
+}
+
+func (man *JoinManager) StartProcessing() {
+	goCell(man.ManagerContext, man.join)
+}
+
+func (man *JoinManager) join() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+	completed, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Completed")
+
+	var conf JoinConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid join cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+
+	var branch *types.Branch
+	if ctx.Runner != nil {
+		branch = ctx.Runner.Branch
+	}
+	if branch == nil || branch.Fork == nil {
+		// the flow did not branch, there is nothing to wait for
+		ctx.RecvChannel <- &types.ManagerResponse{
+			Channel: ctx.Channel,
+			Link:    completed}
+		return
+	}
+
+	// the other branches may need the media to reach the join
+	flow.Media.Release(branch)
+	// the branches that stop at the join return after the cell was updated
+	first, err := branch.Fork.Join(ctx.Context, cell.Cell.Id, branch, conf.Mode, func(arrived []*types.Branch) {
+		names := make([]string, 0, len(arrived))
+		for _, item := range arrived {
+			names = append(names, item.Name)
+		}
+		cell.SetEventVar("branches", strings.Join(names, ","))
+	})
+	if err != nil {
+		return
+	}
+	if !first {
+		helpers.Log(logrus.DebugLevel, "branch "+branch.Name+" stopped at join "+cell.Cell.Name)
+		ctx.RecvChannel <- &types.ManagerResponse{
+			Channel: ctx.Channel,
+			Exit:    true}
+		return
+	}
+
+	for _, item := range branch.Fork.Branches {
+		for _, variable := range item.Variables(flow) {
+			if err := flow.SetTypedVariable(variable.Name, variable.Type, variable.Value); err != nil {
+				helpers.Log(logrus.ErrorLevel, "could not merge variable "+variable.Name+": "+err.Error())
+			}
+		}
+	}
+	helpers.Log(logrus.DebugLevel, "branch "+branch.Name+" continues after join "+cell.Cell.Name)
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    completed}
+}
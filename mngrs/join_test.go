@@ -0,0 +1,122 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// forkFlow runs two branches that set variables and use the media of the
+// call before they are joined.
+const forkFlow = `{
+  "graph": {"cells": [
+    {"id": "launch", "name": "Launch", "type": "devs.LaunchModel"},
+    {"id": "main", "name": "MainVars", "type": "devs.SetVariablesModel"},
+    {"id": "mainMedia", "name": "MainMedia", "type": "test.MediaModel"},
+    {"id": "side", "name": "SideVars", "type": "devs.SetVariablesModel"},
+    {"id": "sideMedia", "name": "SideMedia", "type": "test.MediaModel"},
+    {"id": "join", "name": "Join1", "type": "devs.JoinModel"},
+    {"id": "after", "name": "After", "type": "devs.SetVariablesModel"},
+    {"id": "l1", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "main", "port": "In"}},
+    {"id": "l2", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "side", "port": "In"}},
+    {"id": "l3", "type": "devs.FlowLink", "source": {"id": "main", "port": "Completed"}, "target": {"id": "mainMedia", "port": "In"}},
+    {"id": "l4", "type": "devs.FlowLink", "source": {"id": "side", "port": "Completed"}, "target": {"id": "sideMedia", "port": "In"}},
+    {"id": "l5", "type": "devs.FlowLink", "source": {"id": "mainMedia", "port": "Completed"}, "target": {"id": "join", "port": "In"}},
+    {"id": "l6", "type": "devs.FlowLink", "source": {"id": "sideMedia", "port": "Completed"}, "target": {"id": "join", "port": "In"}},
+    {"id": "l7", "type": "devs.FlowLink", "source": {"id": "join", "port": "Completed"}, "target": {"id": "after", "port": "In"}}
+  ]},
+  "models": [
+    {"id": "launch", "name": "Launch", "data": {}},
+    {"id": "main", "name": "MainVars", "data": {"variables": [{"name": "who", "value": "main"}, {"name": "a", "value": "1"}]}},
+    {"id": "mainMedia", "name": "MainMedia", "data": {}},
+    {"id": "side", "name": "SideVars", "data": {"variables": [{"name": "who", "value": "side"}, {"name": "b", "value": "2"}]}},
+    {"id": "sideMedia", "name": "SideMedia", "data": {}},
+    {"id": "join", "name": "Join1", "data": {"mode": "all"}},
+    {"id": "after", "name": "After", "data": {"variables": [{"name": "joined", "value": "{{Join1.branches}}"}]}}
+  ]
+}`
+
+// mediaManager stands in for a cell that plays audio and records how many
+// of them ran at once.
+type mediaManager struct {
+	ctx   *types.Context
+	usage *mediaUsage
+}
+
+type mediaUsage struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (man *mediaManager) StartProcessing() {
+	goCell(man.ctx, func() {
+		man.usage.mu.Lock()
+		man.usage.current++
+		if man.usage.current > man.usage.max {
+			man.usage.max = man.usage.current
+		}
+		man.usage.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		man.usage.mu.Lock()
+		man.usage.current--
+		man.usage.mu.Unlock()
+		completed, _ := utils.FindLinkByName(man.ctx.Cell.SourceLinks, "source", "Completed")
+		man.ctx.RecvChannel <- &types.ManagerResponse{Channel: man.ctx.Channel, Link: completed}
+	})
+}
+
+func TestJoinMergesBranches(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	usage := &mediaUsage{}
+	Register("test.MediaModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return &mediaManager{ctx: mngrCtx, usage: usage}
+	}, CellMeta{Ports: []CellPort{{Name: "Completed"}}, Media: true})
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal([]byte(forkFlow), &vars))
+	lineChannel := &types.LineChannel{}
+	flow := types.NewFlow(1, types.NewUser(1, 1, "test"), &vars, lineChannel, nil, nil)
+
+	ProcessFlow(nil, context.Background(), flow, lineChannel, make(map[string]string), flow.Cells[0])
+
+	var joined string
+	require.Eventually(t, func() bool {
+		joined, _ = flow.GetVariable("joined")
+		return joined != ""
+	}, time.Second, 10*time.Millisecond)
+	require.ElementsMatch(t, []string{"MainVars", "SideVars"}, strings.Split(joined, ","))
+	// the branch of the last launch link wins
+	who, _ := flow.GetVariable("who")
+	require.Equal(t, "side", who)
+	a, _ := flow.GetVariable("a")
+	b, _ := flow.GetVariable("b")
+	require.Equal(t, "1", a)
+	require.Equal(t, "2", b)
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	require.Equal(t, 1, usage.max)
+}
+
+func TestJoinWithoutBranches(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	join := newTestCell("1", "Join1", "devs.JoinModel", map[string]types.ModelData{})
+	setVars := newTestCell("2", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{{"name": "done", "value": "yes"}}}})
+	connectTestCells(join, "Completed", setVars)
+	flow := &types.Flow{
+		Trace: types.NewFlowTrace(1),
+		Cells: []*types.Cell{join, setVars}}
+
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), join)
+
+	done, _ := flow.GetVariable("done")
+	require.Equal(t, "yes", done)
+}
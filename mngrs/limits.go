@@ -0,0 +1,48 @@
+package mngrs
+
+import (
+	"strconv"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+)
+
+// describeCall returns the identifiers of the call of a flow for logs.
+func describeCall(flow *types.Flow, lineChannel *types.LineChannel) string {
+	message := "flow: " + strconv.Itoa(flow.FlowId)
+	if flow.User != nil {
+		message += ", workspace: " + strconv.Itoa(flow.User.Workspace.Id)
+	}
+	if flow.RootCall != nil {
+		message += ", call: " + strconv.Itoa(flow.RootCall.CallId)
+	}
+	if lineChannel != nil && lineChannel.Channel != nil {
+		message += ", channel: " + lineChannel.Channel.ID()
+	}
+	return message
+}
+
+func flowLimits() types.FlowLimits {
+	cfg := config.NewConfig()
+	return types.FlowLimits{
+		MaxHops:       cfg.MaxHops,
+		MaxDuration:   cfg.MaxDuration,
+		MaxCellVisits: cfg.MaxCellVisits}
+}
+
+// stopRunawayFlow ends a call whose flow went over one of its limits. The
+// limit announcement is played and the call is hung up.
+func stopRunawayFlow(flow *types.Flow, lineChannel *types.LineChannel, cell *types.Cell, err error) {
+	entry := flow.Trace.Enter(cell)
+	flow.Trace.Exit(entry, nil, nil, err)
+
+	helpers.Log(logrus.ErrorLevel, "stopping runaway flow: "+err.Error()+", "+describeCall(flow, lineChannel))
+
+	if lineChannel == nil || lineChannel.Channel == nil {
+		return
+	}
+	playAndWait(lineChannel, config.NewConfig().LimitSound, fallbackPlaybackTimeout)
+	lineChannel.SafeHangup()
+}
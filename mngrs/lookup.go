@@ -0,0 +1,267 @@
+package mngrs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+const (
+	LOOKUP_EXACT  = "exact"
+	LOOKUP_PREFIX = "prefix"
+	LOOKUP_RANGE  = "range"
+)
+
+// fetchLookupTable loads the tables of Lookup cells.
+var fetchLookupTable = api.GetLookupTable
+
+// LookupTable is a table of a workspace. Every row maps the column names to
+// its values.
+type LookupTable struct {
+	Columns []string
+	Rows    []map[string]string
+}
+
+// parseLookupTable reads a table in the "csv" or "json" format.
+func parseLookupTable(format string, data string) (*LookupTable, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, errors.New("table has no header row")
+		}
+		table := &LookupTable{Columns: records[0]}
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(table.Columns))
+			for i, column := range table.Columns {
+				if i < len(record) {
+					row[column] = record[i]
+				}
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		return table, nil
+	case "json":
+		decoder := json.NewDecoder(strings.NewReader(data))
+		// keep numbers the way they were written, e.g. zip codes
+		decoder.UseNumber()
+		var items []map[string]interface{}
+		if err := decoder.Decode(&items); err != nil {
+			return nil, err
+		}
+		table := &LookupTable{}
+		seen := make(map[string]bool)
+		for _, item := range items {
+			row := make(map[string]string, len(item))
+			for column, value := range item {
+				if value == nil {
+					continue
+				}
+				row[column] = fmt.Sprint(value)
+				if !seen[column] {
+					seen[column] = true
+					table.Columns = append(table.Columns, column)
+				}
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		return table, nil
+	}
+	return nil, errors.New("unknown table format " + strconv.Quote(format))
+}
+
+// compareLookupValues compares two values as numbers when both are numbers
+// and as text otherwise.
+func compareLookupValues(a string, b string) int {
+	numA, errA := strconv.ParseFloat(a, 64)
+	numB, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// Find returns the row matching a key. Exact matching compares the key to
+// the column, prefix matching returns the row with the longest value of the
+// column that the key starts with and range matching returns the first row
+// whose from and to columns include the key.
+func (table *LookupTable) Find(conf *LookupConfig, key string) (map[string]string, bool) {
+	var match map[string]string
+	for _, row := range table.Rows {
+		switch conf.Match {
+		case LOOKUP_EXACT:
+			if strings.TrimSpace(row[conf.Column]) == key {
+				return row, true
+			}
+		case LOOKUP_PREFIX:
+			prefix := strings.TrimSpace(row[conf.Column])
+			if prefix == "" || !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if match == nil || len(prefix) > len(strings.TrimSpace(match[conf.Column])) {
+				match = row
+			}
+		case LOOKUP_RANGE:
+			from := strings.TrimSpace(row[conf.FromColumn])
+			to := strings.TrimSpace(row[conf.ToColumn])
+			if from == "" || to == "" {
+				continue
+			}
+			if compareLookupValues(key, from) >= 0 && compareLookupValues(key, to) <= 0 {
+				return row, true
+			}
+		}
+	}
+	return match, match != nil
+}
+
+type cachedLookupTable struct {
+	table    *LookupTable
+	loadedAt time.Time
+}
+
+var (
+	lookupTablesMu sync.Mutex
+	lookupTables   = make(map[string]*cachedLookupTable)
+)
+
+// loadLookupTable returns a table of a workspace, which is cached for
+// LOOKUP_TABLE_TTL. A table that can not be loaded again is used from the
+// cache for as long as the API is unavailable.
+func loadLookupTable(workspaceId int, name string) (*LookupTable, error) {
+	cacheKey := strconv.Itoa(workspaceId) + ":" + name
+	lookupTablesMu.Lock()
+	cached := lookupTables[cacheKey]
+	lookupTablesMu.Unlock()
+	if cached != nil && time.Since(cached.loadedAt) < config.NewConfig().LookupTableTTL {
+		return cached.table, nil
+	}
+
+	table, err := fetchAndParseLookupTable(workspaceId, name)
+	if err != nil {
+		if cached != nil {
+			helpers.Log(logrus.ErrorLevel, "could not reload table "+name+", using the cached copy: "+err.Error())
+			return cached.table, nil
+		}
+		return nil, err
+	}
+	lookupTablesMu.Lock()
+	lookupTables[cacheKey] = &cachedLookupTable{table: table, loadedAt: time.Now()}
+	lookupTablesMu.Unlock()
+	return table, nil
+}
+
+func fetchAndParseLookupTable(workspaceId int, name string) (*LookupTable, error) {
+	resp, err := fetchLookupTable(strconv.Itoa(workspaceId), name)
+	if err != nil {
+		return nil, err
+	}
+	return parseLookupTable(resp.Format, resp.Data)
+}
+
+// LookupConfig is the config of Lookup cells.
+type LookupConfig struct {
+	Table      string `cell:"table,required"`
+	Key        string `cell:"key,required"`
+	Match      string `cell:"match" default:"exact"`
+	Column     string `cell:"column"`
+	FromColumn string `cell:"from_column"`
+	ToColumn   string `cell:"to_column"`
+}
+
+func (conf *LookupConfig) Validate() error {
+	switch conf.Match {
+	case LOOKUP_EXACT, LOOKUP_PREFIX:
+		if conf.Column == "" {
+			return &CellConfigError{Field: "column", Message: "is required to match on a column"}
+		}
+	case LOOKUP_RANGE:
+		if conf.FromColumn == "" || conf.ToColumn == "" {
+			return &CellConfigError{Field: "from_column", Message: "and to_column are required to match a range"}
+		}
+	default:
+		return &CellConfigError{Field: "match", Message: "must be exact, prefix or range, got " + conf.Match}
+	}
+	return nil
+}
+
+// LookupManager finds the row of a workspace table that matches a key, e.g.
+// {"table": "offices", "key": "{{Input1.digits}}", "match": "exact",
+// "column": "account"}. The columns of the row become variables of the cell,
+// like {{Lookup1.office}}.
+type LookupManager struct {
+	ManagerContext *types.Context
+	Flow           *types.Flow
+}
+
+func NewLookupManager(mngrCtx *types.Context, flow *types.Flow) *LookupManager {
+	item := LookupManager{
+		ManagerContext: mngrCtx,
+		Flow:           flow}
+	return &item
+}
+
+func (man *LookupManager) StartProcessing() {
+	goCell(man.ManagerContext, man.lookup)
+}
+
+func (man *LookupManager) lookup() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+	found, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Found")
+	notFound, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Not Found")
+
+	var conf LookupConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid lookup cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+	if flow.User == nil {
+		failCell(ctx, errors.New("the flow has no workspace to look up tables in"))
+		return
+	}
+	table, err := loadLookupTable(flow.User.Workspace.Id, conf.Table)
+	if err != nil {
+		failCell(ctx, errors.New("could not load table "+conf.Table+": "+err.Error()))
+		return
+	}
+
+	key := strings.TrimSpace(conf.Key)
+	row, ok := table.Find(&conf, key)
+	if !ok {
+		helpers.Log(logrus.DebugLevel, "no row of table "+conf.Table+" matches "+key)
+		ctx.RecvChannel <- &types.ManagerResponse{
+			Channel: ctx.Channel,
+			Link:    notFound}
+		return
+	}
+	for column, value := range row {
+		cell.SetEventVar(column, value)
+	}
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    found}
+}
@@ -0,0 +1,125 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/types"
+)
+
+const officesCsv = `account,office,prefix,zip_from,zip_to
+1001,Montreal,1514,10000,10999
+1002,Toronto,1416,11000,11999
+1003,Quebec,15,12000,12999
+`
+
+func TestParseLookupTable(t *testing.T) {
+	table, err := parseLookupTable("csv", officesCsv)
+	require.NoError(t, err)
+	require.Equal(t, []string{"account", "office", "prefix", "zip_from", "zip_to"}, table.Columns)
+	require.Len(t, table.Rows, 3)
+	require.Equal(t, "Toronto", table.Rows[1]["office"])
+
+	table, err = parseLookupTable("json", `[{"zip": 10001, "group": "east"}, {"zip": "90210", "group": null}]`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"zip": "10001", "group": "east"}, table.Rows[0])
+	require.Equal(t, map[string]string{"zip": "90210"}, table.Rows[1])
+
+	_, err = parseLookupTable("xml", "<rows/>")
+	require.Error(t, err)
+}
+
+func TestLookupTableFind(t *testing.T) {
+	table, err := parseLookupTable("csv", officesCsv)
+	require.NoError(t, err)
+	tests := []struct {
+		name   string
+		conf   LookupConfig
+		key    string
+		office string
+	}{
+		{"Exact", LookupConfig{Match: LOOKUP_EXACT, Column: "account"}, "1002", "Toronto"},
+		{"ExactMissing", LookupConfig{Match: LOOKUP_EXACT, Column: "account"}, "100", ""},
+		{"LongestPrefix", LookupConfig{Match: LOOKUP_PREFIX, Column: "prefix"}, "15145550100", "Montreal"},
+		{"ShortPrefix", LookupConfig{Match: LOOKUP_PREFIX, Column: "prefix"}, "15005550100", "Quebec"},
+		{"PrefixMissing", LookupConfig{Match: LOOKUP_PREFIX, Column: "prefix"}, "4165550100", ""},
+		{"Range", LookupConfig{Match: LOOKUP_RANGE, FromColumn: "zip_from", ToColumn: "zip_to"}, "11999", "Toronto"},
+		{"RangeIsNumeric", LookupConfig{Match: LOOKUP_RANGE, FromColumn: "zip_from", ToColumn: "zip_to"}, "9999", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			row, ok := table.Find(&tt.conf, tt.key)
+			require.Equal(t, tt.office != "", ok)
+			require.Equal(t, tt.office, row["office"])
+		})
+	}
+}
+
+func TestLookupManager(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	lookupTablesMu.Lock()
+	delete(lookupTables, "7:offices")
+	lookupTablesMu.Unlock()
+	fetches := 0
+	fetchLookupTable = func(workspace string, name string) (*api.LookupTableResponse, error) {
+		fetches++
+		require.Equal(t, "7", workspace)
+		return &api.LookupTableResponse{Name: name, Format: "csv", Data: officesCsv}, nil
+	}
+	defer func() { fetchLookupTable = api.GetLookupTable }()
+
+	run := func(key string) *types.Flow {
+		lookup := newTestCell("1", "Lookup1", "devs.LookupModel", map[string]types.ModelData{
+			"table":  types.ModelDataStr{Value: "offices"},
+			"key":    types.ModelDataStr{Value: key},
+			"column": types.ModelDataStr{Value: "account"}})
+		found := newTestCell("2", "Found", "devs.SetVariablesModel", map[string]types.ModelData{
+			"variables": types.ModelDataList{Value: []map[string]string{{"name": "office", "value": "{{Lookup1.office}}"}}}})
+		missing := newTestCell("3", "Missing", "devs.SetVariablesModel", map[string]types.ModelData{
+			"variables": types.ModelDataList{Value: []map[string]string{{"name": "office", "value": "none"}}}})
+		connectTestCells(lookup, "Found", found)
+		connectTestCells(lookup, "Not Found", missing)
+		flow := &types.Flow{
+			User:  types.NewUser(1, 7, "test"),
+			Trace: types.NewFlowTrace(1),
+			Cells: []*types.Cell{lookup, found, missing}}
+		flow.SetVariable("account", "1001")
+		ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), lookup)
+		return flow
+	}
+
+	office, _ := run("{{flow.account}}").GetVariable("office")
+	require.Equal(t, "Montreal", office)
+	office, _ = run("2000").GetVariable("office")
+	require.Equal(t, "none", office)
+	// the table was cached after the first lookup
+	require.Equal(t, 1, fetches)
+}
+
+func TestLookupTableStaleCopy(t *testing.T) {
+	fetchLookupTable = func(workspace string, name string) (*api.LookupTableResponse, error) {
+		return &api.LookupTableResponse{Name: name, Format: "csv", Data: officesCsv}, nil
+	}
+	defer func() { fetchLookupTable = api.GetLookupTable }()
+	_, err := loadLookupTable(8, "stale")
+	require.NoError(t, err)
+
+	// expire the cached copy and take the API down
+	lookupTablesMu.Lock()
+	lookupTables["8:stale"].loadedAt = lookupTables["8:stale"].loadedAt.Add(-24 * time.Hour)
+	lookupTablesMu.Unlock()
+	fetchLookupTable = func(workspace string, name string) (*api.LookupTableResponse, error) {
+		return nil, errors.New("connection refused")
+	}
+	table, err := loadLookupTable(8, "stale")
+	require.NoError(t, err)
+	require.Len(t, table.Rows, 3)
+	_, err = loadLookupTable(8, "unknown")
+	require.Error(t, err)
+}
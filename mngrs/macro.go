@@ -59,6 +59,17 @@ func (man *MacroManager) startGRPCAndRunMacro(macro *types.WorkspaceMacro, param
 	return nil
 }
 
+// MacroConfig is the config of Macro cells. Params are passed to the
+// function of the macro.
+type MacroConfig struct {
+	Function string            `cell:"function,required"`
+	Params   map[string]string `cell:"params"`
+}
+
+func (conf *MacroConfig) Validate() error {
+	return nil
+}
+
 type MacroManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -76,14 +87,21 @@ func (man *MacroManager) executeMacro() {
 	cell := man.ManagerContext.Cell
 	channel := man.ManagerContext.Channel
 	flow := man.ManagerContext.Flow
-	model := cell.Model
 	helpers.Log(logrus.DebugLevel, "running macro script..")
 
-	function := model.Data["function"].(types.ModelDataStr).Value
-	params := model.Data["params"].(types.ModelDataObj).Value
+	var conf MacroConfig
+	if err := loadConfig(man.ManagerContext, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid macro cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
+	function := conf.Function
+	params := conf.Params
+	if params == nil {
+		params = make(map[string]string)
+	}
 
 	completed, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Completed")
-	errorLink, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Error")
 
 	var foundFn *types.WorkspaceMacro
 
@@ -96,10 +114,7 @@ func (man *MacroManager) executeMacro() {
 
 	if foundFn == nil {
 		helpers.Log(logrus.DebugLevel, "could not find macro function...")
-		resp := types.ManagerResponse{
-			Channel: channel,
-			Link:    errorLink}
-		man.ManagerContext.RecvChannel <- &resp
+		failCell(man.ManagerContext, errors.New("macro function "+function+" was not found"))
 		return
 	}
 
@@ -109,10 +124,7 @@ func (man *MacroManager) executeMacro() {
 
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "error occurred: "+err.Error())
-		resp := types.ManagerResponse{
-			Channel: channel,
-			Link:    errorLink}
-		man.ManagerContext.RecvChannel <- &resp
+		failCell(man.ManagerContext, err)
 		return
 	}
 	resp := types.ManagerResponse{
@@ -121,5 +133,5 @@ func (man *MacroManager) executeMacro() {
 	man.ManagerContext.RecvChannel <- &resp
 }
 func (man *MacroManager) StartProcessing() {
-	go man.executeMacro()
+	goCell(man.ManagerContext, man.executeMacro)
 }
@@ -0,0 +1,14 @@
+package mngrs
+
+import (
+	"os"
+	"testing"
+
+	helpers "github.com/Lineblocs/go-helpers"
+)
+
+func TestMain(m *testing.M) {
+	// managers log through go-helpers which needs a logger
+	helpers.InitLogrus("")
+	os.Exit(m.Run())
+}
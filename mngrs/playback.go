@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
 	"github.com/sirupsen/logrus"
 
 	helpers "github.com/Lineblocs/go-helpers"
@@ -12,6 +13,56 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+// PromptConfig is the prompt of Playback and Input cells, either text to say
+// or an audio file to play.
+type PromptConfig struct {
+	PlaybackType string `cell:"playback_type,required"`
+	TextToSay    string `cell:"text_to_say"`
+	TextGender   string `cell:"text_gender"`
+	Voice        string `cell:"voice"`
+	TextLanguage string `cell:"text_language"`
+	UrlAudio     string `cell:"url_audio"`
+}
+
+func (conf *PromptConfig) Validate() error {
+	switch conf.PlaybackType {
+	case "Say":
+		if conf.TextToSay == "" {
+			return &CellConfigError{Field: "text_to_say", Message: "is required to say a prompt"}
+		}
+	case "Play":
+		if conf.UrlAudio == "" {
+			return &CellConfigError{Field: "url_audio", Message: "is required to play a prompt"}
+		}
+	default:
+		return &CellConfigError{Field: "playback_type", Message: "must be Say or Play, got " + conf.PlaybackType}
+	}
+	return nil
+}
+
+// prompt returns the sound file of the prompt.
+func (conf *PromptConfig) prompt(flow *types.Flow) (string, error) {
+	if conf.PlaybackType == "Say" {
+		helpers.Log(logrus.DebugLevel, "processing TTS")
+		return utils.StartTTS(conf.TextToSay, conf.TextGender, conf.Voice, conf.TextLanguage)
+	}
+	helpers.Log(logrus.DebugLevel, "processing file download")
+	return utils.DownloadFile(flow, conf.UrlAudio)
+}
+
+// PlaybackConfig is the config of Playback cells.
+type PlaybackConfig struct {
+	PromptConfig
+	Loops int `cell:"number_of_loops" default:"1"`
+}
+
+func (conf *PlaybackConfig) Validate() error {
+	if conf.Loops < 0 {
+		return &CellConfigError{Field: "number_of_loops", Message: "can not be negative"}
+	}
+	return conf.PromptConfig.Validate()
+}
+
 type PlaybackManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -25,7 +76,7 @@ func NewPlaybackManager(mngrCtx *types.Context, flow *types.Flow) *PlaybackManag
 	return &item
 }
 func (man *PlaybackManager) StartProcessing() {
-	go man.processPlayback()
+	goCell(man.ManagerContext, man.processPlayback)
 }
 
 func (man *PlaybackManager) processPlayback() {
@@ -33,44 +84,32 @@ func (man *PlaybackManager) processPlayback() {
 	cell := man.ManagerContext.Cell
 	flow := man.ManagerContext.Flow
 	channel := man.ManagerContext.Channel
-	model := cell.Model
-	data := cell.Model.Data
 	next, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Finished")
-	playbackType := data["playback_type"].(types.ModelDataStr).Value
-	loops := utils.PlaybackLoops(model.Data["number_of_loops"])
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Finished")
-
-	for i := 0; i != loops; i++ {
-		switch playbackType {
-		case "Say":
-
-			helpers.Log(logrus.DebugLevel, "processing TTS")
-			file, err := utils.StartTTS(data["text_to_say"].(types.ModelDataStr).Value,
-				data["text_gender"].(types.ModelDataStr).Value,
-				data["voice"].(types.ModelDataStr).Value,
-				data["text_language"].(types.ModelDataStr).Value)
-			if err != nil {
-				helpers.Log(logrus.ErrorLevel, "error downloading: "+err.Error())
-				man.errorResult()
-				return
-			}
-
-			man.beginPrompt(file)
-			time.Sleep(time.Duration(time.Millisecond * 100))
-		case "Play":
-
-			helpers.Log(logrus.DebugLevel, "processing file download")
-			file, err := utils.DownloadFile(flow, data["url_audio"].(types.ModelDataStr).Value)
-
-			if err != nil {
-				helpers.Log(logrus.ErrorLevel, "error downloading: "+err.Error())
-				man.errorResult()
-				return
-			}
+	var conf PlaybackConfig
+	if err := loadConfig(man.ManagerContext, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid playback cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
 
-			man.beginPrompt(file)
-			time.Sleep(time.Duration(time.Millisecond * 100))
+	for i := 0; i != conf.Loops; i++ {
+		if man.ManagerContext.Context.Err() != nil {
+			helpers.Log(logrus.DebugLevel, "playback cancelled")
+			return
 		}
+		file, err := conf.prompt(flow)
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "error downloading: "+err.Error())
+			failCell(man.ManagerContext, err)
+			return
+		}
+
+		man.beginPrompt(file)
+		time.Sleep(time.Duration(time.Millisecond * 100))
+	}
+	if man.ManagerContext.Context.Err() != nil {
+		helpers.Log(logrus.DebugLevel, "playback cancelled")
+		return
 	}
 	resp := types.ManagerResponse{
 		Channel: channel,
@@ -78,19 +117,11 @@ func (man *PlaybackManager) processPlayback() {
 	man.ManagerContext.RecvChannel <- &resp
 }
 
-func (man *PlaybackManager) errorResult() {
-	channel := man.ManagerContext.Channel
-	resp := types.ManagerResponse{
-		Channel: channel,
-		Link:    nil}
-	man.ManagerContext.RecvChannel <- &resp
-}
-
 func (man *PlaybackManager) beginPrompt(prompt string) {
 	channel := man.ManagerContext.Channel
 	//cell := man.ManagerContext.Cell
 	uri := "sound:" + prompt
-	playback, err := channel.Channel.Play(channel.Channel.Key().ID, uri)
+	playback, err := channel.Channel.Play(rid.New(rid.Playback), uri)
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, "failed to play join sound, error:"+err.Error())
 		return
@@ -99,10 +130,18 @@ func (man *PlaybackManager) beginPrompt(prompt string) {
 	//next, _ := utils.FindLinkByName( cell.SourceLinks, "source", "Finished")
 	finishedSub := playback.Subscribe(ari.Events.PlaybackFinished)
 	defer finishedSub.Cancel()
+	// the caller is not silent while the prompt plays
+	defer playingPrompt(channel)()
 
 	helpers.Log(logrus.DebugLevel, "waiting for playback to finish...")
 	for {
 		select {
+		case <-man.ManagerContext.Context.Done():
+			helpers.Log(logrus.DebugLevel, "stopping cancelled playback...")
+			if err := playback.Stop(); err != nil {
+				helpers.Log(logrus.DebugLevel, "error occurred: "+err.Error())
+			}
+			return
 		case <-finishedSub.Events():
 			helpers.Log(logrus.DebugLevel, "playback finished...")
 			/*
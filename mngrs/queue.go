@@ -0,0 +1,597 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/go-redis/redis/v8"
+	"github.com/rotisserie/eris"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+const (
+	QUEUE_RING_ALL     = "ring-all"
+	QUEUE_ROUND_ROBIN  = "round-robin"
+	QUEUE_LONGEST_IDLE = "longest-idle"
+	QUEUE_FEWEST_CALLS = "fewest-calls"
+)
+
+// queueAgentClaimGrace is how long an agent stays busy after it was rung, or
+// after its claim was last refreshed while bridged, when the instance that
+// offered it the call goes away without releasing it.
+const queueAgentClaimGrace = 15 * time.Second
+
+// queueAgentClaimRefresh is how often the claim of a bridged agent is
+// refreshed.
+const queueAgentClaimRefresh = 5 * time.Second
+
+// queueEntryStale is how long a caller stays in a queue without its instance
+// checking its position, after which the instance is assumed to be gone.
+const queueEntryStale = 2 * time.Minute
+
+// queueRecentWaits is how many answered calls the estimated wait of a queue
+// is based on.
+const queueRecentWaits = 20
+
+var ErrQueueFull = errors.New("queue is full")
+
+// QueueAgent is an agent of a queue along with what the strategies pick it
+// by.
+type QueueAgent struct {
+	Extension string
+	Calls     int
+	LastCall  time.Time
+	Busy      bool
+}
+
+// QueueStore keeps the callers of the queues and the state of their agents,
+// which the instances of the processor share. Queues are scoped to a
+// workspace and agents are busy across all the queues of their workspace.
+type QueueStore interface {
+	// Join adds a caller to a queue, ordered by the time it joined, and
+	// returns its position starting at 1. It fails with ErrQueueFull when
+	// maxLength callers are waiting. A maxLength of zero does not limit the
+	// queue.
+	Join(workspaceId int, queue string, channelId string, joined time.Time, maxLength int) (int, error)
+	Leave(workspaceId int, queue string, channelId string) error
+	// Position returns the position of a caller, or 0 when it is not in the
+	// queue. Callers whose position was not checked for queueEntryStale are
+	// removed from the queue.
+	Position(workspaceId int, queue string, channelId string) (int, error)
+	// Agents returns the state of the agents of a queue.
+	Agents(workspaceId int, queue string, extensions []string) ([]*QueueAgent, error)
+	// ClaimAgent marks an agent busy for ttl, unless it already was.
+	ClaimAgent(workspaceId int, extension string, ttl time.Duration) (bool, error)
+	// RefreshAgent keeps a claimed agent busy for another ttl.
+	RefreshAgent(workspaceId int, extension string, ttl time.Duration) error
+	// ReleaseAgent marks an agent idle again. Answered calls count towards the
+	// calls of the agent in the queue.
+	ReleaseAgent(workspaceId int, queue string, extension string, answered bool) error
+	// Turn returns how many times agents were claimed for calls of the
+	// queue round robin, which AdvanceTurn counts.
+	Turn(workspaceId int, queue string) (int, error)
+	AdvanceTurn(workspaceId int, queue string) error
+	// RecordWait records how long an answered caller waited.
+	RecordWait(workspaceId int, queue string, wait time.Duration) error
+	// AverageWait returns the average wait of the recently answered callers,
+	// or zero when no caller was answered yet.
+	AverageWait(workspaceId int, queue string) (time.Duration, error)
+}
+
+// RedisQueueStore keeps each queue in a sorted set ordered by the time its
+// callers joined, and the last time they were seen in another, and the agents
+// in keys and hashes.
+type RedisQueueStore struct {
+	Client *redis.Client
+}
+
+func queueKey(kind string, workspaceId int, name string) string {
+	return kind + ":" + strconv.Itoa(workspaceId) + ":" + name
+}
+
+var queueJoinScript = redis.NewScript(`
+local max = tonumber(ARGV[1])
+if max > 0 and redis.call('ZSCORE', KEYS[1], ARGV[3]) == false and redis.call('ZCARD', KEYS[1]) >= max then
+  return -1
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('ZADD', KEYS[2], ARGV[4], ARGV[3])
+return redis.call('ZRANK', KEYS[1], ARGV[3]) + 1
+`)
+
+// queuePositionScript only visits the callers that went stale.
+var queuePositionScript = redis.NewScript(`
+local expired = tonumber(ARGV[1]) - tonumber(ARGV[2])
+if redis.call('ZSCORE', KEYS[1], ARGV[3]) then
+  redis.call('ZADD', KEYS[2], ARGV[1], ARGV[3])
+end
+for _, member in ipairs(redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', '(' .. expired)) do
+  redis.call('ZREM', KEYS[1], member)
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', '(' .. expired)
+local rank = redis.call('ZRANK', KEYS[1], ARGV[3])
+if rank == false then
+  return 0
+end
+return rank + 1
+`)
+
+func (store *RedisQueueStore) Join(workspaceId int, queue string, channelId string, joined time.Time, maxLength int) (int, error) {
+	keys := []string{queueKey("queue", workspaceId, queue), queueKey("queue_seen", workspaceId, queue)}
+	position, err := queueJoinScript.Run(context.Background(), store.Client, keys, maxLength, joined.UnixNano(), channelId, time.Now().Unix()).Int()
+	if err != nil {
+		return 0, err
+	}
+	if position < 0 {
+		return 0, ErrQueueFull
+	}
+	return position, nil
+}
+
+func (store *RedisQueueStore) Leave(workspaceId int, queue string, channelId string) error {
+	ctx := context.Background()
+	_, err := store.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, queueKey("queue", workspaceId, queue), channelId)
+		pipe.ZRem(ctx, queueKey("queue_seen", workspaceId, queue), channelId)
+		return nil
+	})
+	return err
+}
+
+func (store *RedisQueueStore) Position(workspaceId int, queue string, channelId string) (int, error) {
+	keys := []string{queueKey("queue", workspaceId, queue), queueKey("queue_seen", workspaceId, queue)}
+	return queuePositionScript.Run(context.Background(), store.Client, keys, time.Now().Unix(), int(queueEntryStale.Seconds()), channelId).Int()
+}
+
+func (store *RedisQueueStore) Agents(workspaceId int, queue string, extensions []string) ([]*QueueAgent, error) {
+	ctx := context.Background()
+	stats, err := store.Client.HGetAll(ctx, queueKey("queue_agents", workspaceId, queue)).Result()
+	if err != nil {
+		return nil, err
+	}
+	busy := make([]*redis.IntCmd, len(extensions))
+	_, err = store.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, extension := range extensions {
+			busy[i] = pipe.Exists(ctx, queueKey("queue_agent", workspaceId, extension))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	agents := make([]*QueueAgent, len(extensions))
+	for i, extension := range extensions {
+		agent := &QueueAgent{Extension: extension, Busy: busy[i].Val() > 0}
+		agent.Calls, _ = strconv.Atoi(stats[extension+":calls"])
+		if last, err := strconv.ParseInt(stats[extension+":last"], 10, 64); err == nil {
+			agent.LastCall = time.Unix(last, 0)
+		}
+		agents[i] = agent
+	}
+	return agents, nil
+}
+
+func (store *RedisQueueStore) ClaimAgent(workspaceId int, extension string, ttl time.Duration) (bool, error) {
+	return store.Client.SetNX(context.Background(), queueKey("queue_agent", workspaceId, extension), "1", ttl).Result()
+}
+
+func (store *RedisQueueStore) RefreshAgent(workspaceId int, extension string, ttl time.Duration) error {
+	return store.Client.Expire(context.Background(), queueKey("queue_agent", workspaceId, extension), ttl).Err()
+}
+
+func (store *RedisQueueStore) ReleaseAgent(workspaceId int, queue string, extension string, answered bool) error {
+	ctx := context.Background()
+	_, err := store.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, queueKey("queue_agent", workspaceId, extension))
+		if answered {
+			pipe.HIncrBy(ctx, queueKey("queue_agents", workspaceId, queue), extension+":calls", 1)
+			pipe.HSet(ctx, queueKey("queue_agents", workspaceId, queue), extension+":last", time.Now().Unix())
+		}
+		return nil
+	})
+	return err
+}
+
+func (store *RedisQueueStore) Turn(workspaceId int, queue string) (int, error) {
+	turn, err := store.Client.Get(context.Background(), queueKey("queue_turn", workspaceId, queue)).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return turn, err
+}
+
+func (store *RedisQueueStore) AdvanceTurn(workspaceId int, queue string) error {
+	return store.Client.Incr(context.Background(), queueKey("queue_turn", workspaceId, queue)).Err()
+}
+
+func (store *RedisQueueStore) RecordWait(workspaceId int, queue string, wait time.Duration) error {
+	ctx := context.Background()
+	_, err := store.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, queueKey("queue_waits", workspaceId, queue), wait.Milliseconds())
+		pipe.LTrim(ctx, queueKey("queue_waits", workspaceId, queue), 0, queueRecentWaits-1)
+		return nil
+	})
+	return err
+}
+
+func (store *RedisQueueStore) AverageWait(workspaceId int, queue string) (time.Duration, error) {
+	waits, err := store.Client.LRange(context.Background(), queueKey("queue_waits", workspaceId, queue), 0, -1).Result()
+	if err != nil || len(waits) == 0 {
+		return 0, err
+	}
+	var total int64
+	for _, wait := range waits {
+		ms, _ := strconv.ParseInt(wait, 10, 64)
+		total += ms
+	}
+	return time.Duration(total/int64(len(waits))) * time.Millisecond, nil
+}
+
+var (
+	queueMu    sync.Mutex
+	queueStore QueueStore
+)
+
+// SetQueueStore replaces the store used for queues.
+func SetQueueStore(store QueueStore) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	queueStore = store
+}
+
+func getQueueStore() QueueStore {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	if queueStore == nil {
+		queueStore = &RedisQueueStore{Client: utils.CreateRDB()}
+	}
+	return queueStore
+}
+
+// orderQueueAgents returns the idle agents in the order the strategy offers
+// them calls. Round robin starts at the agent whose turn it is.
+func orderQueueAgents(strategy string, agents []*QueueAgent, turn int) []*QueueAgent {
+	ordered := make([]*QueueAgent, 0, len(agents))
+	if strategy == QUEUE_ROUND_ROBIN && len(agents) > 0 {
+		start := turn % len(agents)
+		ordered = append(ordered, agents[start:]...)
+		ordered = append(ordered, agents[:start]...)
+	} else {
+		ordered = append(ordered, agents...)
+	}
+	switch strategy {
+	case QUEUE_LONGEST_IDLE:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].LastCall.Before(ordered[j].LastCall)
+		})
+	case QUEUE_FEWEST_CALLS:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			if ordered[i].Calls != ordered[j].Calls {
+				return ordered[i].Calls < ordered[j].Calls
+			}
+			return ordered[i].LastCall.Before(ordered[j].LastCall)
+		})
+	}
+	idle := ordered[:0]
+	for _, agent := range ordered {
+		if !agent.Busy {
+			idle = append(idle, agent)
+		}
+	}
+	return idle
+}
+
+// queueAnnouncement tells the caller its position and, once calls of the
+// queue were answered, how long it can expect to wait.
+func queueAnnouncement(position int, estimate time.Duration) string {
+	text := fmt.Sprintf("You are number %d in the queue.", position)
+	if estimate <= 0 {
+		return text
+	}
+	minutes := int(math.Ceil(estimate.Minutes()))
+	if minutes == 1 {
+		return text + " Your estimated wait time is 1 minute."
+	}
+	return text + fmt.Sprintf(" Your estimated wait time is %d minutes.", minutes)
+}
+
+// QueueConfig is the config of Bridge cells with the "Queue" call type. The
+// agents are extensions and Timeout is how long each offer rings them.
+type QueueConfig struct {
+	Queue            string   `cell:"queue"`
+	Strategy         string   `cell:"queue_strategy" default:"ring-all"`
+	Agents           []string `cell:"queue_agents"`
+	MaxWait          int      `cell:"queue_max_wait"`
+	MaxLength        int      `cell:"queue_max_length"`
+	MusicClass       string   `cell:"queue_music_class" default:"default"`
+	AnnounceInterval int      `cell:"queue_announce_interval" default:"60"`
+	// the text to speech settings of the announcements, like the ones of
+	// prompts
+	AnnounceGender   string `cell:"queue_announce_gender"`
+	AnnounceVoice    string `cell:"queue_announce_voice"`
+	AnnounceLanguage string `cell:"queue_announce_language" default:"en-US"`
+}
+
+func (conf *QueueConfig) Validate() error {
+	if strings.TrimSpace(conf.Queue) == "" {
+		return &CellConfigError{Field: "queue", Message: "is required for Queue calls"}
+	}
+	if len(conf.Agents) == 0 {
+		return &CellConfigError{Field: "queue_agents", Message: "needs at least one agent"}
+	}
+	switch conf.Strategy {
+	case QUEUE_RING_ALL, QUEUE_ROUND_ROBIN, QUEUE_LONGEST_IDLE, QUEUE_FEWEST_CALLS:
+	default:
+		return &CellConfigError{Field: "queue_strategy", Message: "must be ring-all, round-robin, longest-idle or fewest-calls, got " + conf.Strategy}
+	}
+	if conf.MaxWait < 0 {
+		return &CellConfigError{Field: "queue_max_wait", Message: "can not be negative"}
+	}
+	if conf.MaxLength < 0 {
+		return &CellConfigError{Field: "queue_max_length", Message: "can not be negative"}
+	}
+	if conf.AnnounceInterval < 0 {
+		return &CellConfigError{Field: "queue_announce_interval", Message: "can not be negative"}
+	}
+	return nil
+}
+
+// startQueue puts the caller in a queue, where it hears music on hold and
+// its position until one of the agents answers. The caller leaves through
+// "Queue Full" when the queue has no room and through "Max Wait Exceeded"
+// when no agent answered in time.
+func (man *BridgeManager) startQueue() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+	channel := ctx.Channel
+	conf := &man.config.QueueConfig
+	workspaceId := flow.User.Workspace.Id
+	store := getQueueStore()
+
+	callerId := man.config.callerId(flow.RootCall)
+	if err := man.verifyCallerId(callerId); err != nil {
+		failCell(ctx, err)
+		return
+	}
+
+	joined := time.Now()
+	position, err := store.Join(workspaceId, conf.Queue, channel.Channel.ID(), joined, conf.MaxLength)
+	if err == ErrQueueFull {
+		helpers.Log(logrus.DebugLevel, "queue "+conf.Queue+" is full")
+		full, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Queue Full")
+		ctx.RecvChannel <- &types.ManagerResponse{
+			Channel: channel,
+			Link:    full}
+		return
+	}
+	if err != nil {
+		failCell(ctx, errors.New("could not join queue "+conf.Queue+": "+err.Error()))
+		return
+	}
+	defer func() {
+		if err := store.Leave(workspaceId, conf.Queue, channel.Channel.ID()); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not leave queue "+conf.Queue+": "+err.Error())
+		}
+	}()
+
+	key := channel.Channel.Key().New(ari.BridgeKey, rid.New(rid.Bridge))
+	holding, err := ctx.Client.Bridge().Create(key, "holding", key.ID)
+	if err != nil {
+		failCell(ctx, eris.Wrap(err, "failed to create holding bridge"))
+		return
+	}
+	defer holding.Delete()
+	if err := holding.AddChannel(channel.Channel.ID()); err != nil {
+		failCell(ctx, eris.Wrap(err, "failed to add channel to holding bridge"))
+		return
+	}
+	if err := holding.MOH(conf.MusicClass); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not start music on hold: "+err.Error())
+	}
+
+	maxWait := time.Duration(conf.MaxWait) * time.Second
+	ticker := time.NewTicker(config.NewConfig().QueuePollInterval)
+	defer ticker.Stop()
+	var announced time.Time
+	for {
+		waited := time.Since(joined)
+		cell.SetEventVar("wait", strconv.Itoa(int(waited.Seconds())))
+		if maxWait > 0 && waited >= maxWait {
+			helpers.Log(logrus.DebugLevel, "caller waited too long in queue "+conf.Queue)
+			next, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Max Wait Exceeded")
+			ctx.RecvChannel <- &types.ManagerResponse{
+				Channel: channel,
+				Link:    next}
+			return
+		}
+
+		position, err = store.Position(workspaceId, conf.Queue, channel.Channel.ID())
+		if err == nil && position == 0 {
+			// the entry went away, join again without losing the place
+			position, err = store.Join(workspaceId, conf.Queue, channel.Channel.ID(), joined, 0)
+		}
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not get position in queue "+conf.Queue+": "+err.Error())
+		}
+		cell.SetEventVar("position", strconv.Itoa(position))
+
+		if conf.AnnounceInterval > 0 && position > 0 && time.Since(announced) >= time.Duration(conf.AnnounceInterval)*time.Second {
+			announced = time.Now()
+			man.announceQueuePosition(holding, position)
+		}
+
+		ringTimeout := time.Duration(man.config.Timeout) * time.Second
+		if maxWait > 0 && maxWait-waited < ringTimeout {
+			ringTimeout = maxWait - waited
+		}
+		if offer := man.offerQueueCall(callerId, position, ringTimeout); offer != nil {
+			holding.Delete()
+			man.connectQueueAgent(offer, time.Since(joined))
+			return
+		}
+
+		select {
+		case <-ctx.Context.Done():
+			helpers.Log(logrus.DebugLevel, "caller left queue "+conf.Queue)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// announceQueuePosition interrupts the music on hold to announce the
+// position of the caller.
+func (man *BridgeManager) announceQueuePosition(holding *ari.BridgeHandle, position int) {
+	ctx := man.ManagerContext
+	conf := &man.config.QueueConfig
+	estimate, err := getQueueStore().AverageWait(ctx.Flow.User.Workspace.Id, conf.Queue)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not estimate wait of queue "+conf.Queue+": "+err.Error())
+	}
+	text := queueAnnouncement(position, estimate*time.Duration(position))
+	file, err := utils.StartTTS(text, conf.AnnounceGender, conf.AnnounceVoice, conf.AnnounceLanguage)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not create queue announcement: "+err.Error())
+		return
+	}
+	holding.StopMOH()
+	defer holding.MOH(conf.MusicClass)
+	playback, err := holding.Play(rid.New(rid.Playback), "sound:"+file)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not play queue announcement: "+err.Error())
+		return
+	}
+	finishedSub := playback.Subscribe(ari.Events.PlaybackFinished)
+	defer finishedSub.Cancel()
+	select {
+	case <-finishedSub.Events():
+	case <-ctx.Context.Done():
+		playback.Stop()
+	}
+}
+
+// offerQueueCall rings the agents the strategy picks when the caller is among
+// the first callers of the queue, one for every idle agent. It returns the
+// agent that answered, if any.
+func (man *BridgeManager) offerQueueCall(callerId string, position int, timeout time.Duration) *ringOffer {
+	ctx := man.ManagerContext
+	conf := &man.config.QueueConfig
+	workspaceId := ctx.Flow.User.Workspace.Id
+	store := getQueueStore()
+	if position == 0 || timeout <= 0 {
+		return nil
+	}
+	agents, err := store.Agents(workspaceId, conf.Queue, conf.Agents)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not get agents of queue "+conf.Queue+": "+err.Error())
+		return nil
+	}
+	idle := orderQueueAgents(QUEUE_RING_ALL, agents, 0)
+	if position > len(idle) {
+		return nil
+	}
+	turn := 0
+	if conf.Strategy == QUEUE_ROUND_ROBIN {
+		if turn, err = store.Turn(workspaceId, conf.Queue); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not get turn of queue "+conf.Queue+": "+err.Error())
+		}
+	}
+
+	claimed := make([]string, 0)
+	for _, agent := range orderQueueAgents(conf.Strategy, agents, turn) {
+		// the claim outlives the offer just long enough to bridge the agent
+		ok, err := store.ClaimAgent(workspaceId, agent.Extension, timeout+queueAgentClaimGrace)
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not claim agent "+agent.Extension+": "+err.Error())
+			continue
+		}
+		if !ok {
+			// another caller got the agent first
+			continue
+		}
+		claimed = append(claimed, agent.Extension)
+		if conf.Strategy != QUEUE_RING_ALL {
+			break
+		}
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+	// the turn only moves on once an agent was offered the call
+	if conf.Strategy == QUEUE_ROUND_ROBIN {
+		if err := store.AdvanceTurn(workspaceId, conf.Queue); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not advance turn of queue "+conf.Queue+": "+err.Error())
+		}
+	}
+	return man.ringAgents(callerId, claimed, timeout)
+}
+
+// ringAgents calls the agents until one of them answers or the timeout
+// elapses. Agents that do not get the call are released.
+func (man *BridgeManager) ringAgents(callerId string, agents []string, timeout time.Duration) *ringOffer {
+	ctx := man.ManagerContext
+	conf := &man.config.QueueConfig
+	workspaceId := ctx.Flow.User.Workspace.Id
+	destinations := make([]ringDestination, 0, len(agents))
+	for _, agent := range agents {
+		destinations = append(destinations, ringDestination{number: agent, callType: "Queue"})
+	}
+	return man.ringDestinations(callerId, destinations, timeout, nil, func(agent string) {
+		if err := getQueueStore().ReleaseAgent(workspaceId, conf.Queue, agent, false); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not release agent "+agent+": "+err.Error())
+		}
+	})
+}
+
+// connectQueueAgent bridges the caller with the agent that answered. The
+// claim of the agent is refreshed until its call ends, then it is released.
+func (man *BridgeManager) connectQueueAgent(offer *ringOffer, waited time.Duration) {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	conf := &man.config.QueueConfig
+	workspaceId := ctx.Flow.User.Workspace.Id
+	store := getQueueStore()
+	agent := offer.destination.number
+	helpers.Log(logrus.DebugLevel, "agent "+agent+" answered queue "+conf.Queue)
+	cell.SetEventVar("agent", agent)
+	cell.SetEventVar("wait", strconv.Itoa(int(waited.Seconds())))
+	if err := store.RecordWait(workspaceId, conf.Queue, waited); err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not record wait of queue "+conf.Queue+": "+err.Error())
+	}
+
+	endSub := offer.leg.Channel.Subscribe(ari.Events.StasisEnd)
+	ctx.Flow.Go(func() {
+		defer endSub.Cancel()
+		ticker := time.NewTicker(queueAgentClaimRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := store.RefreshAgent(workspaceId, agent, queueAgentClaimGrace); err != nil {
+					helpers.Log(logrus.ErrorLevel, "could not refresh agent "+agent+": "+err.Error())
+				}
+				continue
+			case <-endSub.Events():
+			}
+			if err := store.ReleaseAgent(workspaceId, conf.Queue, agent, true); err != nil {
+				helpers.Log(logrus.ErrorLevel, "could not release agent "+agent+": "+err.Error())
+			}
+			return
+		}
+	})
+	man.bridgeWith(offer.leg, "Queue")
+}
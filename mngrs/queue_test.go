@@ -0,0 +1,69 @@
+package mngrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestOrderQueueAgents(t *testing.T) {
+	now := time.Now()
+	agents := []*QueueAgent{
+		{Extension: "1001", Calls: 4, LastCall: now.Add(-time.Minute)},
+		{Extension: "1002", Calls: 1, LastCall: now.Add(-time.Second)},
+		{Extension: "1003", Busy: true},
+		{Extension: "1004", Calls: 1, LastCall: now.Add(-time.Hour)},
+	}
+	extensions := func(agents []*QueueAgent) []string {
+		items := make([]string, 0, len(agents))
+		for _, agent := range agents {
+			items = append(items, agent.Extension)
+		}
+		return items
+	}
+
+	require.Equal(t, []string{"1001", "1002", "1004"}, extensions(orderQueueAgents(QUEUE_RING_ALL, agents, 0)))
+	require.Equal(t, []string{"1004", "1001", "1002"}, extensions(orderQueueAgents(QUEUE_ROUND_ROBIN, agents, 2)))
+	require.Equal(t, []string{"1004", "1001", "1002"}, extensions(orderQueueAgents(QUEUE_ROUND_ROBIN, agents, 7)))
+	require.Equal(t, []string{"1004", "1001", "1002"}, extensions(orderQueueAgents(QUEUE_LONGEST_IDLE, agents, 0)))
+	require.Equal(t, []string{"1004", "1002", "1001"}, extensions(orderQueueAgents(QUEUE_FEWEST_CALLS, agents, 0)))
+	// the agents are left in their order
+	require.Equal(t, "1001", agents[0].Extension)
+}
+
+func TestQueueAnnouncement(t *testing.T) {
+	require.Equal(t, "You are number 3 in the queue.", queueAnnouncement(3, 0))
+	require.Equal(t, "You are number 1 in the queue. Your estimated wait time is 1 minute.", queueAnnouncement(1, 20*time.Second))
+	require.Equal(t, "You are number 2 in the queue. Your estimated wait time is 3 minutes.", queueAnnouncement(2, 150*time.Second))
+}
+
+func TestQueueConfig(t *testing.T) {
+	data := map[string]types.ModelData{
+		"call_type":    types.ModelDataStr{Value: "Queue"},
+		"queue":        types.ModelDataStr{Value: "support"},
+		"queue_agents": types.ModelDataArr{Value: []string{"1001", "1002"}}}
+	var conf BridgeConfig
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Equal(t, QUEUE_RING_ALL, conf.Strategy)
+	require.Equal(t, 60, conf.AnnounceInterval)
+	require.Equal(t, "en-US", conf.AnnounceLanguage)
+	require.Equal(t, 30, conf.Timeout)
+
+	data["queue_announce_language"] = types.ModelDataStr{Value: "fr-FR"}
+	data["queue_announce_voice"] = types.ModelDataStr{Value: "fr-FR-Standard-A"}
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Equal(t, "fr-FR", conf.AnnounceLanguage)
+	require.Equal(t, "fr-FR-Standard-A", conf.AnnounceVoice)
+
+	data["queue_strategy"] = types.ModelDataStr{Value: "random"}
+	require.Error(t, DecodeCellConfig(data, &BridgeConfig{}))
+	delete(data, "queue_strategy")
+	delete(data, "queue_agents")
+	require.Error(t, DecodeCellConfig(data, &BridgeConfig{}))
+	// other call types do not need a queue
+	require.NoError(t, DecodeCellConfig(map[string]types.ModelData{
+		"call_type": types.ModelDataStr{Value: "Extension"},
+		"extension": types.ModelDataStr{Value: "1001"}}, &BridgeConfig{}))
+}
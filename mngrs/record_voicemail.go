@@ -1,43 +1,237 @@
 package mngrs
 
 import (
-	"fmt"
+	"errors"
+	"strconv"
+	"time"
 
+	"github.com/CyCoreSystems/ari/v5"
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/api"
 	processor_helpers "lineblocs.com/processor/helpers"
 	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
 )
 
+const (
+	// GREETING_MAILBOX plays the greeting recorded for the mailbox.
+	GREETING_MAILBOX = "Mailbox"
+	GREETING_SAY     = "Say"
+	GREETING_PLAY    = "Play"
+)
+
+// VoicemailDefaultGreeting is said for mailboxes without a greeting.
+const VoicemailDefaultGreeting = "The person you are trying to reach is not available. Please leave a message after the tone."
+
+// voicemailHangupWait is how long a message left by a caller who hung up
+// has to finish recording before it is given up on.
+var voicemailHangupWait = 5 * time.Second
+
+// fetchMailboxGreeting loads the greetings of mailboxes.
+var fetchMailboxGreeting = api.GetMailboxGreeting
+
+// VoicemailConfig is the config of Voicemail cells. The greeting is the one
+// of the mailbox, text to say or an audio file to play.
+type VoicemailConfig struct {
+	Mailbox      string `cell:"mailbox,required"`
+	GreetingType string `cell:"greeting_type" default:"Mailbox"`
+	TextToSay    string `cell:"text_to_say"`
+	TextGender   string `cell:"text_gender"`
+	Voice        string `cell:"voice"`
+	TextLanguage string `cell:"text_language"`
+	UrlAudio     string `cell:"url_audio"`
+	Beep         bool   `cell:"beep" default:"true"`
+	// MaxLength, MaxSilence and MinLength are numbers of seconds.
+	MaxLength  int    `cell:"max_length" default:"120"`
+	MaxSilence int    `cell:"max_silence" default:"5"`
+	MinLength  int    `cell:"min_length" default:"1"`
+	FinishKey  string `cell:"finish_key" default:"#"`
+	Trim       bool   `cell:"trim"`
+}
+
+func (conf *VoicemailConfig) Validate() error {
+	switch conf.GreetingType {
+	case GREETING_MAILBOX:
+	case GREETING_SAY, GREETING_PLAY:
+		if err := conf.greeting().Validate(); err != nil {
+			return err
+		}
+	default:
+		return &CellConfigError{Field: "greeting_type", Message: "must be Mailbox, Say or Play, got " + conf.GreetingType}
+	}
+	switch conf.FinishKey {
+	case "#", "*", "any", "none":
+	default:
+		return &CellConfigError{Field: "finish_key", Message: "must be #, *, any or none, got " + conf.FinishKey}
+	}
+	if conf.MaxLength <= 0 {
+		return &CellConfigError{Field: "max_length", Message: "must be more than 0"}
+	}
+	if conf.MaxSilence < 0 {
+		return &CellConfigError{Field: "max_silence", Message: "can not be negative"}
+	}
+	if conf.MinLength < 0 || conf.MinLength > conf.MaxLength {
+		return &CellConfigError{Field: "min_length", Message: "must be between 0 and max_length"}
+	}
+	return nil
+}
+
+// greeting returns the prompt of Say and Play greetings.
+func (conf *VoicemailConfig) greeting() *PromptConfig {
+	return &PromptConfig{
+		PlaybackType: conf.GreetingType,
+		TextToSay:    conf.TextToSay,
+		TextGender:   conf.TextGender,
+		Voice:        conf.Voice,
+		TextLanguage: conf.TextLanguage,
+		UrlAudio:     conf.UrlAudio}
+}
+
+// greetingFile returns the sound file of the greeting. Mailboxes without a
+// greeting of their own use the default one.
+func (conf *VoicemailConfig) greetingFile(flow *types.Flow) (string, error) {
+	if conf.GreetingType != GREETING_MAILBOX {
+		return conf.greeting().prompt(flow)
+	}
+	resp, err := fetchMailboxGreeting(strconv.Itoa(flow.User.Workspace.Id), conf.Mailbox)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not get greeting of mailbox "+conf.Mailbox+": "+err.Error())
+	} else if resp.UrlAudio != "" {
+		return utils.DownloadFile(flow, resp.UrlAudio)
+	}
+	return utils.StartTTS(VoicemailDefaultGreeting, "", "", "en-US")
+}
+
+// options returns how the message is recorded.
+func (conf *VoicemailConfig) options() *ari.RecordingOptions {
+	return &ari.RecordingOptions{
+		Format:      "wav",
+		MaxDuration: time.Duration(conf.MaxLength) * time.Second,
+		MaxSilence:  time.Duration(conf.MaxSilence) * time.Second,
+		Beep:        conf.Beep,
+		Terminate:   conf.FinishKey}
+}
+
+// hasMessage tells whether a finished recording holds a message. Recordings
+// shorter than the minimum length, or in which silence detection heard no
+// talking at all, do not.
+func (conf *VoicemailConfig) hasMessage(data *ari.LiveRecordingData) bool {
+	if time.Duration(data.Duration) < time.Duration(conf.MinLength)*time.Second {
+		return false
+	}
+	return data.Silence == 0 || data.Talking > 0
+}
+
 type RecordVoicemailManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
 }
 
 func NewRecordVoicemailManager(mngrCtx *types.Context, flow *types.Flow) *RecordVoicemailManager {
-	//rootCtx, _ := context.WithCancel(context.Background())
 	item := RecordVoicemailManager{
 		ManagerContext: mngrCtx,
 		Flow:           flow}
 	return &item
 }
+
 func (man *RecordVoicemailManager) StartProcessing() {
-	helpers.Log(logrus.DebugLevel, "Creating bridge... ")
-	cell := man.ManagerContext.Cell
-	flow := man.ManagerContext.Flow
-	channel := cell.CellChannel
-	user := flow.User
-	data := cell.Model.Data
-	trimData, ok := data["trim"].(types.ModelDataBool)
-	trim := false
-
-	if ok {
-		trim = trimData.Value
-	}
-	recording := processor_helpers.NewRecording(man.ManagerContext.Context, user, nil, trim)
-	_, err := recording.InitiateRecordingForChannel(channel)
+	goCell(man.ManagerContext, man.recordVoicemail)
+}
+
+// recordVoicemail plays the greeting and records a message until the caller
+// is silent, presses the finish key or reaches the maximum length. Messages
+// are saved even when the caller hangs up to end them.
+func (man *RecordVoicemailManager) recordVoicemail() {
+	ctx := man.ManagerContext
+	flow := ctx.Flow
+	channel := ctx.Channel
+	var conf VoicemailConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid voicemail cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+
+	file, err := conf.greetingFile(flow)
 	if err != nil {
-		fmt.Println("recording err " + err.Error())
+		helpers.Log(logrus.ErrorLevel, "error downloading greeting: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+	NewPlaybackManager(ctx, flow).beginPrompt(file)
+	if ctx.Context.Err() != nil {
+		helpers.Log(logrus.DebugLevel, "voicemail cancelled during greeting")
+		return
+	}
+
+	var callId *int
+	if flow.RootCall != nil {
+		callId = &flow.RootCall.CallId
+	}
+	record := processor_helpers.NewVoicemail(ctx.Context, flow.User, callId, conf.Mailbox, conf.Trim)
+	id, err := record.RecordChannel(channel, conf.options())
+	if err != nil {
+		failCell(ctx, err)
+		return
+	}
+	sub := record.Handle.Subscribe(ari.Events.RecordingFinished, ari.Events.RecordingFailed)
+	defer sub.Cancel()
+
+	callDone := ctx.Context.Done()
+	var hangupWait <-chan time.Time
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				failCell(ctx, errors.New("recording subscription closed"))
+				return
+			}
+			if failed, ok := e.(*ari.RecordingFailed); ok {
+				failCell(ctx, errors.New("recording failed: "+failed.Recording.Cause))
+				return
+			}
+			man.finishVoicemail(&conf, record, id, &e.(*ari.RecordingFinished).Recording)
+			return
+		case <-callDone:
+			// the recording finishes with the call
+			callDone = nil
+			hangupWait = time.After(voicemailHangupWait)
+		case <-hangupWait:
+			helpers.Log(logrus.ErrorLevel, "voicemail "+id+" did not finish after the caller hung up")
+			return
+		}
+	}
+}
+
+// finishVoicemail saves a recorded message and follows "Completed", or
+// deletes the recording and follows "No Message".
+func (man *RecordVoicemailManager) finishVoicemail(conf *VoicemailConfig, record *processor_helpers.Record, id string, data *ari.LiveRecordingData) {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	port := "No Message"
+	if conf.hasMessage(data) {
+		recordingId, err := record.Save(id, time.Duration(data.Duration))
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not save voicemail "+id+": "+err.Error())
+			failCell(ctx, err)
+			return
+		}
+		cell.SetEventVar("recording_id", recordingId)
+		cell.SetEventVar("duration", strconv.Itoa(int(time.Duration(data.Duration)/time.Second)))
+		port = "Completed"
+	} else {
+		helpers.Log(logrus.DebugLevel, "deleting empty voicemail "+id)
+		if err := record.Handle.Stored().Delete(); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not delete voicemail "+id+": "+err.Error())
+		}
+	}
+	cell.SetEventVar("mailbox", conf.Mailbox)
+	if ctx.Context.Err() != nil {
 		return
 	}
+	next, _ := utils.FindLinkByName(cell.SourceLinks, "source", port)
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    next}
 }
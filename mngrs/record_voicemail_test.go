@@ -0,0 +1,49 @@
+package mngrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestVoicemailConfig(t *testing.T) {
+	data := map[string]types.ModelData{
+		"mailbox": types.ModelDataStr{Value: "200"}}
+	var conf VoicemailConfig
+	require.NoError(t, DecodeCellConfig(data, &conf))
+	require.Equal(t, GREETING_MAILBOX, conf.GreetingType)
+	require.True(t, conf.Beep)
+	require.Equal(t, &ari.RecordingOptions{
+		Format:      "wav",
+		MaxDuration: 120 * time.Second,
+		MaxSilence:  5 * time.Second,
+		Beep:        true,
+		Terminate:   "#"}, conf.options())
+
+	data["greeting_type"] = types.ModelDataStr{Value: "Say"}
+	require.Error(t, DecodeCellConfig(data, &VoicemailConfig{}))
+	data["text_to_say"] = types.ModelDataStr{Value: "Leave a message for sales."}
+	require.NoError(t, DecodeCellConfig(data, &VoicemailConfig{}))
+
+	data["finish_key"] = types.ModelDataStr{Value: "0"}
+	require.Error(t, DecodeCellConfig(data, &VoicemailConfig{}))
+	delete(data, "finish_key")
+	data["min_length"] = types.ModelDataStr{Value: "300"}
+	require.Error(t, DecodeCellConfig(data, &VoicemailConfig{}))
+	require.Error(t, DecodeCellConfig(map[string]types.ModelData{}, &VoicemailConfig{}))
+}
+
+func TestVoicemailHasMessage(t *testing.T) {
+	conf := &VoicemailConfig{MinLength: 2}
+	seconds := func(n int) ari.DurationSec {
+		return ari.DurationSec(time.Duration(n) * time.Second)
+	}
+	require.True(t, conf.hasMessage(&ari.LiveRecordingData{Duration: seconds(10)}))
+	require.True(t, conf.hasMessage(&ari.LiveRecordingData{Duration: seconds(10), Talking: seconds(4), Silence: seconds(6)}))
+	require.False(t, conf.hasMessage(&ari.LiveRecordingData{Duration: seconds(1)}))
+	// silence detection heard nothing
+	require.False(t, conf.hasMessage(&ari.LiveRecordingData{Duration: seconds(5), Silence: seconds(5)}))
+}
@@ -0,0 +1,80 @@
+package mngrs
+
+import (
+	"expvar"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/CyCoreSystems/ari/v5"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+)
+
+const (
+	PANIC_CALL   = "call"
+	PANIC_RUNNER = "runner"
+	PANIC_CELL   = "cell"
+)
+
+// panics counts the panics recovered while handling calls by where they
+// happened. Recovering a panic only affects the call it happened in.
+var panics = expvar.NewMap("flow_panics")
+
+// reportPanic logs a recovered panic with its stack and returns it as an
+// error.
+func reportPanic(where string, value interface{}, details string) error {
+	panics.Add(where, 1)
+	err := fmt.Errorf("panic: %v", value)
+	helpers.Log(logrus.ErrorLevel, "recovered "+where+" "+err.Error()+", "+details+"\n"+string(debug.Stack()))
+	return err
+}
+
+// RecoverCall is deferred by the goroutines that set up a call. A panic is
+// recovered and the caller hears the fallback announcement.
+func RecoverCall(cl ari.Client, channel *types.LineChannel) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	details := "call setup"
+	if channel != nil && channel.Channel != nil {
+		details += ", channel: " + channel.Channel.ID()
+	}
+	err := reportPanic(PANIC_CALL, value, details)
+	RouteToFallback(cl, nil, channel, "", err.Error())
+}
+
+// startManager starts a manager and returns the panic of its StartProcessing
+// as an error.
+func startManager(mngr BaseManager, flow *types.Flow, lineChannel *types.LineChannel, cell *types.Cell) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = reportPanic(PANIC_CELL, value, "cell: "+cell.Cell.Name+", "+describeCall(flow, lineChannel))
+		}
+	}()
+	mngr.StartProcessing()
+	return nil
+}
+
+// goCell runs part of a manager in its own goroutine. A panic fails the cell
+// instead of the processor.
+func goCell(ctx *types.Context, fn func()) {
+	ctx.Flow.Go(func() {
+		defer recoverCell(ctx)
+		fn()
+	})
+}
+
+func recoverCell(ctx *types.Context) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	err := reportPanic(PANIC_CELL, value, "cell: "+ctx.Cell.Cell.Name+", "+describeCall(ctx.Flow, ctx.Channel))
+	// the cell may already have sent its result
+	select {
+	case ctx.RecvChannel <- &types.ManagerResponse{Channel: ctx.Channel, Error: err}:
+	default:
+	}
+}
@@ -0,0 +1,64 @@
+package mngrs
+
+import (
+	"context"
+	"expvar"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+type panicManager struct {
+	ctx   *types.Context
+	async bool
+}
+
+func (man *panicManager) StartProcessing() {
+	if !man.async {
+		panic("broken cell")
+	}
+	goCell(man.ctx, func() {
+		var data map[string]types.ModelData
+		_ = data["missing"].(types.ModelDataBool)
+	})
+}
+
+func panicCount(where string) int64 {
+	value, ok := panics.Get(where).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return value.Value()
+}
+
+func TestPanicFollowsErrorPort(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	Register("test.PanicModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return &panicManager{ctx: mngrCtx}
+	})
+	Register("test.AsyncPanicModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return &panicManager{ctx: mngrCtx, async: true}
+	})
+
+	for _, cellType := range []string{"test.PanicModel", "test.AsyncPanicModel"} {
+		before := panicCount(PANIC_CELL)
+		broken := newTestCell("1", "Broken", cellType, map[string]types.ModelData{})
+		setVars := newTestCell("2", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+			"variables": types.ModelDataList{Value: []map[string]string{{"name": "reason", "value": "{{Broken.error}}"}}}})
+		connectTestCells(broken, "Error", setVars)
+		flow := &types.Flow{
+			Trace: types.NewFlowTrace(1),
+			Cells: []*types.Cell{broken, setVars}}
+
+		ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), broken)
+
+		entries := flow.Trace.Snapshot().Entries
+		require.Len(t, entries, 2, cellType)
+		require.Equal(t, "Error", entries[0].Port)
+		reason, _ := flow.GetVariable("reason")
+		require.Contains(t, reason, "panic: ")
+		require.Equal(t, before+1, panicCount(PANIC_CELL))
+	}
+}
@@ -0,0 +1,220 @@
+package mngrs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"lineblocs.com/processor/types"
+)
+
+// ManagerFactory creates the manager that executes a single cell.
+type ManagerFactory func(mngrCtx *types.Context, flow *types.Flow) BaseManager
+
+// CellPort is an output port a cell can resolve through.
+type CellPort struct {
+	Name     string
+	Required bool
+}
+
+// CellMeta describes what a cell type emits and needs in its model data.
+// The required fields of a cell type with a Config are taken from the tags of
+// its config.
+type CellMeta struct {
+	Ports          []CellPort
+	RequiredFields []string
+	// Config returns an empty config of the cell type, which the runner
+	// decodes the model data into before starting the manager.
+	Config func() CellConfig
+	// Timeout is how long a cell of the type can run before it fails. Zero
+	// uses FLOW_CELL_TIMEOUT and CELL_TIMEOUT_NONE leaves the cell running for
+	// as long as the call can last.
+	Timeout time.Duration
+	// Media is set for cell types that play audio to the caller or collect
+	// its DTMF. Only one branch of a flow runs them at a time.
+	Media bool
+	// Handlers is how the global handlers of the flow behave while a cell of
+	// the type runs.
+	Handlers HandlerMode
+}
+
+// CELL_TIMEOUT_NONE is the timeout of cells that last as long as the call,
+// like a bridged conversation.
+const CELL_TIMEOUT_NONE time.Duration = -1
+
+// CellType is a registered cell type.
+type CellType struct {
+	CellMeta
+	Name    string
+	Factory ManagerFactory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*CellType)
+)
+
+// Register makes a cell type available to the flow runner. Registering a
+// type that already exists replaces it, which allows built-in cells to be
+// overridden.
+func Register(cellType string, factory ManagerFactory, meta ...CellMeta) {
+	item := CellType{
+		Name:    cellType,
+		Factory: factory}
+	if len(meta) > 0 {
+		item.CellMeta = meta[0]
+	}
+	if item.Config != nil && len(item.RequiredFields) == 0 {
+		for name, required := range configFields(item.Config()) {
+			if required {
+				item.RequiredFields = append(item.RequiredFields, name)
+			}
+		}
+		sort.Strings(item.RequiredFields)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[cellType] = &item
+}
+
+// LookupCellType returns the registered cell type with the given name.
+func LookupCellType(cellType string) (*CellType, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	item, ok := registry[cellType]
+	return item, ok
+}
+
+// RegisteredCellTypes returns the names of all registered cell types.
+func RegisteredCellTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	// the launch cell is handled by the runner itself
+	Register("devs.LaunchModel", nil)
+	Register("devs.SwitchModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewSwitchManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "No Match"}},
+		Config: func() CellConfig { return &SwitchConfig{} },
+	})
+	Register("devs.BridgeModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewBridgeManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:    []CellPort{{Name: "Connected Call Ended"}, {Name: "Caller Hung Up"}, {Name: "Declined"}, {Name: "Max Wait Exceeded"}, {Name: "Queue Full"}, {Name: "No Answer"}},
+		Config:   func() CellConfig { return &BridgeConfig{} },
+		Timeout:  CELL_TIMEOUT_NONE,
+		Media:    true,
+		Handlers: HANDLERS_SUSPENDED,
+	})
+	Register("devs.PlaybackModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewPlaybackManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Finished"}},
+		Config: func() CellConfig { return &PlaybackConfig{} },
+		Media:  true,
+	})
+	Register("devs.ProcessInputModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewInputManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Digits Received", Required: true}, {Name: "No Input"}},
+		Config: func() CellConfig { return &InputConfig{} },
+		Media:  true,
+	})
+	Register("devs.DialModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewDialManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:    []CellPort{{Name: "Answer", Required: true}, {Name: "No Answer"}},
+		Config:   func() CellConfig { return &DialConfig{} },
+		Handlers: HANDLERS_SUSPENDED,
+	})
+	Register("devs.SetVariablesModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewSetVariablesManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Completed"}, {Name: "Error"}},
+		Config: func() CellConfig { return &SetVariablesConfig{} },
+	})
+	Register("devs.CallerMemoryModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewCallerMemoryManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Completed"}, {Name: "Error"}},
+		Config: func() CellConfig { return &CallerMemoryConfig{} },
+	})
+	Register("devs.LookupModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewLookupManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Found", Required: true}, {Name: "Not Found"}, {Name: "Error"}},
+		Config: func() CellConfig { return &LookupConfig{} },
+	})
+	Register("devs.TimeConditionModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewTimeConditionManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Otherwise"}, {Name: "Holiday"}, {Name: "Error"}},
+		Config: func() CellConfig { return &TimeConditionConfig{} },
+	})
+	Register("devs.VoicemailModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewRecordVoicemailManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:    []CellPort{{Name: "Completed"}, {Name: "No Message"}, {Name: "Error"}},
+		Config:   func() CellConfig { return &VoicemailConfig{} },
+		Media:    true,
+		Handlers: HANDLERS_SUSPENDED,
+	})
+	Register("devs.WaitModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewWaitManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:   []CellPort{{Name: "Completed"}},
+		Config:  func() CellConfig { return &WaitConfig{} },
+		Timeout: CELL_TIMEOUT_NONE,
+	})
+	Register("devs.SendDigitsModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewSendDigitsManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Finished"}},
+		Config: func() CellConfig { return &SendDigitsConfig{} },
+		Media:  true,
+	})
+	Register("devs.MacroModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewMacroManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:  []CellPort{{Name: "Completed"}, {Name: "Error"}},
+		Config: func() CellConfig { return &MacroConfig{} },
+	})
+	Register("devs.ConferenceModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewConferenceManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:    []CellPort{{Name: "Conference Ended"}, {Name: "Invalid PIN"}, {Name: "Conference Full"}, {Name: "Error"}},
+		Config:   func() CellConfig { return &ConferenceConfig{} },
+		Timeout:  CELL_TIMEOUT_NONE,
+		Media:    true,
+		Handlers: HANDLERS_SUSPENDED,
+	})
+	Register("devs.SubflowModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewSubflowManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:   []CellPort{{Name: "Completed"}, {Name: "Error"}},
+		Config:  func() CellConfig { return &SubflowConfig{} },
+		Timeout: CELL_TIMEOUT_NONE,
+	})
+	Register("devs.JoinModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewJoinManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:   []CellPort{{Name: "Completed"}},
+		Config:  func() CellConfig { return &JoinConfig{} },
+		Timeout: CELL_TIMEOUT_NONE,
+	})
+	Register("devs.ExitModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewExitManager(mngrCtx, flow)
+	}, CellMeta{
+		Config: func() CellConfig { return &ExitConfig{} },
+	})
+}
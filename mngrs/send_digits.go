@@ -2,6 +2,8 @@ package mngrs
 
 import (
 	//"context"
+	"strings"
+
 	//"github.com/CyCoreSystems/ari/v5"
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/sirupsen/logrus"
@@ -9,6 +11,20 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+// SendDigitsConfig is the config of Send Digits cells.
+type SendDigitsConfig struct {
+	Digits string `cell:"text,required"`
+}
+
+func (conf *SendDigitsConfig) Validate() error {
+	for _, digit := range conf.Digits {
+		if !strings.ContainsRune("0123456789*#ABCDabcd", digit) {
+			return &CellConfigError{Field: "text", Message: "has a character that is not a DTMF digit: " + string(digit)}
+		}
+	}
+	return nil
+}
+
 type SendDigitsManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -25,10 +41,14 @@ func (man *SendDigitsManager) StartProcessing() {
 	//log := man.ManagerContext.Log
 
 	cell := man.ManagerContext.Cell
-	//model := cell.Model
-	data := cell.Model.Data
 	next, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Finished")
-	keys := data["text"].(types.ModelDataStr).Value
+	var conf SendDigitsConfig
+	if err := loadConfig(man.ManagerContext, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid send digits cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
+	keys := conf.Digits
 	lineChannel := man.ManagerContext.Channel
 	//dtmfOpts := &ari.DTMFOptions{}
 
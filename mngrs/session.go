@@ -0,0 +1,99 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/CyCoreSystems/ari/v5"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+)
+
+// flowSession is a flow running on a channel.
+type flowSession struct {
+	client    ari.Client
+	ctx       context.Context
+	flow      *types.Flow
+	channel   *types.LineChannel
+	eventVars map[string]string
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]*flowSession)
+)
+
+// trackSession makes the flow running on a channel available to GotoCell.
+func trackSession(cl ari.Client, ctx context.Context, flow *types.Flow, lineChannel *types.LineChannel, eventVars map[string]string) {
+	if lineChannel == nil || lineChannel.Channel == nil {
+		return
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sessions[lineChannel.Channel.ID()] = &flowSession{
+		client:    cl,
+		ctx:       ctx,
+		flow:      flow,
+		channel:   lineChannel,
+		eventVars: eventVars}
+}
+
+func lookupSession(channelId string) (*flowSession, bool) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	session, ok := sessions[channelId]
+	return session, ok
+}
+
+// GotoCell moves the call on a channel to another cell of its flow. The cell
+// that is running is cancelled, which stops its playback, DTMF listener or
+// dial leg, and a new runner continues from the target cell. Flow variables
+// are kept and the given variables are added to them.
+func GotoCell(channelId string, cellName string, vars map[string]string) error {
+	session, ok := lookupSession(channelId)
+	if !ok {
+		return errors.New("no flow is running on channel " + channelId)
+	}
+	flow := session.flow
+	target := flow.FindCell(cellName)
+	if target == nil {
+		for _, cell := range flow.Cells {
+			if cell.Cell.Id == cellName {
+				target = cell
+				break
+			}
+		}
+	}
+	if target == nil {
+		return errors.New("flow has no cell named " + cellName)
+	}
+
+	helpers.Log(logrus.InfoLevel, "moving channel "+channelId+" to cell "+target.Cell.Name)
+	flow.CancelRunners()
+	for name, value := range vars {
+		flow.SetVariable(name, value)
+	}
+	runner := types.NewRunner(session.ctx)
+	runner.Branch = types.NewBranch(0, target.Cell.Name)
+	flow.AddRunner(runner)
+	flow.Go(func() {
+		startBranch(session.client, session.ctx, flow, session.channel, session.eventVars, target, runner)
+	})
+	return nil
+}
+
+// EndFlow is called when the call of a flow ends. The flow can no longer be
+// moved with GotoCell or resumed and its trace is saved.
+func EndFlow(flow *types.Flow) error {
+	sessionsMu.Lock()
+	for channelId, session := range sessions {
+		if session.flow == flow {
+			delete(sessions, channelId)
+		}
+	}
+	sessionsMu.Unlock()
+	deleteCheckpoint(flow)
+	return FinishTrace(flow)
+}
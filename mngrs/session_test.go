@@ -0,0 +1,68 @@
+package mngrs
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+// testChannel is a channel namespace that only supports hanging up.
+type testChannel struct {
+	ari.Channel
+	hangups int32
+}
+
+func (ch *testChannel) Hangup(key *ari.Key, reason string) error {
+	atomic.AddInt32(&ch.hangups, 1)
+	return nil
+}
+
+func TestGotoCell(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	channel := &testChannel{}
+	lineChannel := &types.LineChannel{Channel: ari.NewChannelHandle(ari.NewKey(ari.ChannelKey, "goto-1"), channel, nil)}
+
+	wait := newTestCell("1", "Wait1", "devs.WaitModel", map[string]types.ModelData{
+		"wait_seconds": types.ModelDataStr{Value: "30"}})
+	never := newTestCell("2", "Never", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{{"name": "never", "value": "yes"}}}})
+	menu := newTestCell("3", "Menu", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{{"name": "menu", "value": "{{flow.reason}}"}}}})
+	connectTestCells(wait, "Completed", never)
+	flow := &types.Flow{
+		Trace:    types.NewFlowTrace(1),
+		RootCall: &types.Call{CallId: 77},
+		Channel:  lineChannel,
+		Cells:    []*types.Cell{wait, never, menu}}
+
+	go ProcessFlow(nil, context.Background(), flow, lineChannel, make(map[string]string), wait)
+	require.Eventually(t, func() bool {
+		return len(flow.Trace.Snapshot().Entries) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.Error(t, GotoCell("goto-1", "Missing", nil))
+	require.Error(t, GotoCell("goto-2", "Menu", nil))
+	require.NoError(t, GotoCell("goto-1", "Menu", map[string]string{"reason": "agent"}))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&channel.hangups) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	entries := flow.Trace.Snapshot().Entries
+	require.Len(t, entries, 2)
+	require.Equal(t, "Wait1", entries[0].CellName)
+	require.Equal(t, "cancelled", entries[0].Error)
+	require.Equal(t, "Menu", entries[1].CellName)
+	menuValue, _ := flow.GetVariable("menu")
+	require.Equal(t, "agent", menuValue)
+	_, ok := flow.GetVariable("never")
+	require.False(t, ok)
+
+	require.NoError(t, EndFlow(flow))
+	require.Error(t, GotoCell("goto-1", "Menu", nil))
+}
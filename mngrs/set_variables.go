@@ -3,10 +3,25 @@ package mngrs
 import (
 	//"context"
 	//"github.com/CyCoreSystems/ari/v5"
+	"errors"
 
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
 	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
 )
 
+// SetVariablesConfig is the config of Set Variables cells. Templates are the
+// variables before their templates were resolved.
+type SetVariablesConfig struct {
+	Variables []map[string]string `cell:"variables"`
+	Templates []map[string]string `cell:"variables_before_interpolations"`
+}
+
+func (conf *SetVariablesConfig) Validate() error {
+	return nil
+}
+
 type SetVariablesManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -19,6 +34,72 @@ func NewSetVariablesManager(mngrCtx *types.Context, flow *types.Flow) *SetVariab
 		Flow:           flow}
 	return &item
 }
+
+// assignVariable sets a single entry of the variables list. Entries look like
+// {"name": "total", "type": "number", "value": "5"}; entries with an
+// "expression" are computed, e.g. {"expression": "{{Input1.digits}} * 2"}.
+func (man *SetVariablesManager) assignVariable(entry map[string]string, raw map[string]string) error {
+	flow := man.ManagerContext.Flow
+	cell := man.ManagerContext.Cell
+	name := entry["name"]
+	if name == "" {
+		return errors.New("variable without a name")
+	}
+	value := entry["value"]
+	if _, ok := raw["expression"]; ok {
+		// evaluate the raw expression so that resolved values can not
+		// change its meaning
+		result, err := EvaluateExpression(flow, raw["expression"])
+		if err != nil {
+			return errors.New("variable " + name + ": " + err.Error())
+		}
+		value = result
+	}
+	if err := flow.SetTypedVariable(name, entry["type"], value); err != nil {
+		return err
+	}
+	stored, _ := flow.Variable(name)
+	if man.ManagerContext.Runner != nil {
+		man.ManagerContext.Runner.Branch.Produced(flow, stored)
+	}
+	cell.SetEventVar(name, stored.Value)
+	helpers.Log(logrus.DebugLevel, "set variable "+name+" = "+stored.Value)
+	return nil
+}
+
 func (man *SetVariablesManager) StartProcessing() {
 	//log := man.ManagerContext.Log
+	goCell(man.ManagerContext, man.setVariables)
+}
+func (man *SetVariablesManager) setVariables() {
+	cell := man.ManagerContext.Cell
+	channel := man.ManagerContext.Channel
+	completed, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Completed")
+
+	var conf SetVariablesConfig
+	if err := loadConfig(man.ManagerContext, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid set variables cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
+	raws := conf.Templates
+	if raws == nil {
+		raws = conf.Variables
+	}
+	for i, entry := range conf.Variables {
+		raw := entry
+		if i < len(raws) {
+			raw = raws[i]
+		}
+		if err := man.assignVariable(entry, raw); err != nil {
+			helpers.Log(logrus.ErrorLevel, "could not set variable: "+err.Error())
+			failCell(man.ManagerContext, err)
+			return
+		}
+	}
+
+	resp := types.ManagerResponse{
+		Channel: channel,
+		Link:    completed}
+	man.ManagerContext.RecvChannel <- &resp
 }
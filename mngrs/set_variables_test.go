@@ -0,0 +1,78 @@
+package mngrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func newTestCell(id string, name string, cellType string, data map[string]types.ModelData) *types.Cell {
+	return &types.Cell{
+		Cell:      &types.GraphCell{Id: id, Name: name, Type: cellType},
+		Model:     &types.Model{Id: id, Name: name, Data: data},
+		EventVars: make(map[string]string)}
+}
+
+func connectTestCells(source *types.Cell, port string, target *types.Cell) *types.Link {
+	link := &types.Link{
+		Link:   newTestLink(source.Cell.Id+"-"+port, source.Cell.Id, port, target.Cell.Id),
+		Source: source,
+		Target: target}
+	source.SourceLinks = append(source.SourceLinks, link)
+	target.TargetLinks = append(target.TargetLinks, link)
+	return link
+}
+
+func TestSetVariablesManager(t *testing.T) {
+	t.Parallel()
+	input := newTestCell("2", "Input1", "devs.ProcessInputModel", nil)
+	input.EventVars["digits"] = "12"
+	setVars := newTestCell("3", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{
+			{"name": "greeting", "value": "You entered {{Input1.digits}}"},
+			{"name": "count", "type": "number", "value": "05"},
+			{"name": "double", "type": "number", "expression": "{{Input1.digits}} * count"},
+			{"name": "vip", "type": "boolean", "value": "TRUE"},
+		}}})
+	wait := newTestCell("4", "Wait1", "devs.WaitModel", nil)
+	completed := connectTestCells(setVars, "Completed", wait)
+	flow := &types.Flow{Cells: []*types.Cell{input, setVars, wait}}
+
+	recv := make(chan *types.ManagerResponse)
+	ctx := types.NewContext(nil, nil, recv, flow, setVars, nil, nil)
+	NewSetVariablesManager(ctx, flow).StartProcessing()
+	resp := <-recv
+
+	require.Equal(t, completed, resp.Link)
+	require.Equal(t, map[string]types.FlowVariable{
+		"greeting": {Name: "greeting", Type: types.VARIABLE_TYPE_STRING, Value: "You entered 12"},
+		"count":    {Name: "count", Type: types.VARIABLE_TYPE_NUMBER, Value: "5"},
+		"double":   {Name: "double", Type: types.VARIABLE_TYPE_NUMBER, Value: "60"},
+		"vip":      {Name: "vip", Type: types.VARIABLE_TYPE_BOOLEAN, Value: "true"},
+	}, flow.Variables())
+	require.Equal(t, "60", setVars.EventVars["double"])
+	require.Equal(t, "You entered 12", flow.Interpolate("{{flow.greeting}}"))
+}
+
+func TestSetVariablesManagerError(t *testing.T) {
+	t.Parallel()
+	setVars := newTestCell("3", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{
+			{"name": "count", "type": "number", "value": "many"},
+		}}})
+	wait := newTestCell("4", "Wait1", "devs.WaitModel", nil)
+	connectTestCells(setVars, "Completed", wait)
+	flow := &types.Flow{Cells: []*types.Cell{setVars, wait}}
+
+	recv := make(chan *types.ManagerResponse)
+	ctx := types.NewContext(nil, nil, recv, flow, setVars, nil, nil)
+	NewSetVariablesManager(ctx, flow).StartProcessing()
+	resp := <-recv
+
+	// the failure is routed by the flow, not through "Completed"
+	require.Nil(t, resp.Link)
+	require.Error(t, resp.Error)
+	_, ok := flow.GetVariable("count")
+	require.False(t, ok)
+}
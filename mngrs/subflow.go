@@ -0,0 +1,210 @@
+package mngrs
+
+import (
+	"errors"
+	"strconv"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// fetchFlow loads the flows started by Subflow cells.
+var fetchFlow = api.GetFlowInfo
+
+type subflowResult struct {
+	outputs map[string]string
+	err     error
+}
+
+// SubflowConfig is the config of Subflow cells. Parameters are set as flow
+// variables of the subflow, e.g. {"name": "account", "type": "string",
+// "value": "{{Input1.digits}}"}.
+type SubflowConfig struct {
+	FlowId     string              `cell:"flow_id,required"`
+	Parameters []map[string]string `cell:"parameters"`
+}
+
+func (conf *SubflowConfig) Validate() error {
+	return nil
+}
+
+// SubflowManager runs another flow of the workspace on the same channel and
+// continues once that flow reaches an exit cell.
+type SubflowManager struct {
+	ManagerContext *types.Context
+	Flow           *types.Flow
+}
+
+func NewSubflowManager(mngrCtx *types.Context, flow *types.Flow) *SubflowManager {
+	item := SubflowManager{
+		ManagerContext: mngrCtx,
+		Flow:           flow}
+	return &item
+}
+
+func (man *SubflowManager) StartProcessing() {
+	goCell(man.ManagerContext, man.runSubflow)
+}
+
+// startSubflow creates the flow of the cell with its parameters as flow
+// variables.
+func (man *SubflowManager) startSubflow() (*types.Flow, error) {
+	ctx := man.ManagerContext
+	flow := ctx.Flow
+
+	maxDepth := config.NewConfig().MaxSubflowDepth
+	if flow.Depth >= maxDepth {
+		return nil, errors.New("subflows are nested more than " + strconv.Itoa(maxDepth) + " levels deep")
+	}
+	var conf SubflowConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		return nil, err
+	}
+	info, err := fetchFlow(strconv.Itoa(flow.User.Workspace.Id), conf.FlowId)
+	if err != nil {
+		return nil, errors.New("could not load subflow " + conf.FlowId + ": " + err.Error())
+	}
+	if err := ValidateFlow(info.Vars).Err(); err != nil {
+		return nil, err
+	}
+
+	child := types.NewFlow(info.FlowId, flow.User, info.Vars, ctx.Channel, flow.WorkspaceFns, ctx.Client)
+	if len(child.Cells) == 0 {
+		return nil, errors.New("subflow " + conf.FlowId + " has no cells")
+	}
+	child.RootCall = flow.RootCall
+	child.Parent = flow
+	child.Depth = flow.Depth + 1
+	// the limits apply to the call, not to each flow
+	child.Guard = flow.Guard
+	// the cells of the subflow are part of the timeline of the call
+	child.Trace = flow.Trace
+	// the subflow uses the media of the call in the branch of its cell
+	child.Media = flow.Media
+	child.Routines = flow.Routines
+
+	for _, param := range conf.Parameters {
+		if param["name"] == "" {
+			continue
+		}
+		if err := child.SetTypedVariable(param["name"], param["type"], param["value"]); err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
+
+func (man *SubflowManager) runSubflow() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+
+	child, err := man.startSubflow()
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "could not start subflow: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+
+	results := make(chan subflowResult, 1)
+	child.SetExitHandler(func(outputs map[string]string, err error) {
+		results <- subflowResult{outputs: outputs, err: err}
+	})
+	// the runner of the subflow is cancelled with this cell
+	runner := types.NewRunner(ctx.Context)
+	if ctx.Runner != nil {
+		runner.Branch = ctx.Runner.Branch
+	}
+	child.AddRunner(runner)
+	helpers.Log(logrus.DebugLevel, "starting subflow "+strconv.Itoa(child.FlowId)+" at depth "+strconv.Itoa(child.Depth))
+	child.Go(func() {
+		startProcessingFlow(ctx.Client, ctx.Context, child, ctx.Channel, make(map[string]string), child.Cells[0], runner)
+	})
+
+	select {
+	case <-ctx.Context.Done():
+		child.CancelRunners()
+		return
+	case result := <-results:
+		if result.err != nil {
+			helpers.Log(logrus.ErrorLevel, "subflow failed: "+result.err.Error())
+			failCell(ctx, result.err)
+			return
+		}
+		for name, value := range result.outputs {
+			cell.SetEventVar(name, value)
+		}
+		completed, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Completed")
+		ctx.RecvChannel <- &types.ManagerResponse{
+			Channel: ctx.Channel,
+			Link:    completed}
+	}
+}
+
+// ExitConfig is the config of Exit cells.
+type ExitConfig struct {
+	Outputs []map[string]string `cell:"outputs"`
+	Error   string              `cell:"error"`
+}
+
+func (conf *ExitConfig) Validate() error {
+	return nil
+}
+
+// ExitManager returns from a subflow with the outputs of the cell, e.g.
+// {"outputs": [{"name": "verified", "value": "{{flow.verified}}"}]}. A non
+// empty "error" returns an error instead. In a flow that is not a subflow
+// the exit cell ends the call.
+type ExitManager struct {
+	ManagerContext *types.Context
+	Flow           *types.Flow
+}
+
+func NewExitManager(mngrCtx *types.Context, flow *types.Flow) *ExitManager {
+	item := ExitManager{
+		ManagerContext: mngrCtx,
+		Flow:           flow}
+	return &item
+}
+
+func (man *ExitManager) StartProcessing() {
+	goCell(man.ManagerContext, man.exit)
+}
+
+func (man *ExitManager) exit() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	var conf ExitConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid exit cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+
+	var err error
+	if conf.Error != "" {
+		err = errors.New(conf.Error)
+	}
+	outputs := make(map[string]string)
+	for _, entry := range conf.Outputs {
+		if entry["name"] == "" {
+			continue
+		}
+		outputs[entry["name"]] = entry["value"]
+		cell.SetEventVar(entry["name"], entry["value"])
+	}
+
+	if ctx.Flow.Exit(outputs, err) {
+		ctx.RecvChannel <- &types.ManagerResponse{
+			Channel: ctx.Channel,
+			Exit:    true}
+		return
+	}
+	helpers.Log(logrus.DebugLevel, "exit cell reached outside of a subflow")
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    nil}
+}
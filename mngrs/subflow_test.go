@@ -0,0 +1,114 @@
+package mngrs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/types"
+)
+
+// verifyFlow sets "verified" from the "account" parameter and returns it.
+const verifyFlow = `{
+  "graph": {"cells": [
+    {"id": "launch", "name": "Launch", "type": "devs.LaunchModel"},
+    {"id": "verify", "name": "Verify", "type": "devs.SetVariablesModel"},
+    {"id": "exit", "name": "Done", "type": "devs.ExitModel"},
+    {"id": "l1", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "verify", "port": "In"}},
+    {"id": "l2", "type": "devs.FlowLink", "source": {"id": "verify", "port": "Completed"}, "target": {"id": "exit", "port": "In"}}
+  ]},
+  "models": [
+    {"id": "launch", "name": "Launch", "data": {}},
+    {"id": "verify", "name": "Verify", "data": {"variables": [{"name": "verified", "value": "{{flow.account}}-ok"}]}},
+    {"id": "exit", "name": "Done", "data": {"outputs": [{"name": "verified", "value": "{{flow.verified}}"}]}}
+  ]
+}`
+
+// recursiveFlow starts itself until the depth limit is reached.
+const recursiveFlow = `{
+  "graph": {"cells": [
+    {"id": "launch", "name": "Launch", "type": "devs.LaunchModel"},
+    {"id": "again", "name": "Again", "type": "devs.SubflowModel"},
+    {"id": "exit", "name": "Done", "type": "devs.ExitModel"},
+    {"id": "l1", "type": "devs.FlowLink", "source": {"id": "launch", "port": "Incoming Call"}, "target": {"id": "again", "port": "In"}},
+    {"id": "l2", "type": "devs.FlowLink", "source": {"id": "again", "port": "Completed"}, "target": {"id": "exit", "port": "In"}}
+  ]},
+  "models": [
+    {"id": "launch", "name": "Launch", "data": {}},
+    {"id": "again", "name": "Again", "data": {"flow_id": "recursive"}},
+    {"id": "exit", "name": "Done", "data": {}}
+  ]
+}`
+
+func stubFlows(t *testing.T, flows map[string]string) {
+	fetchFlow = func(workspace string, flowId string) (*api.SubFlow, error) {
+		var vars types.FlowVars
+		require.NoError(t, json.Unmarshal([]byte(flows[flowId]), &vars))
+		return &api.SubFlow{FlowId: 9, Vars: &vars}, nil
+	}
+	t.Cleanup(func() {
+		fetchFlow = api.GetFlowInfo
+	})
+}
+
+func runSubflowCell(t *testing.T, subflow *types.Cell) *types.Flow {
+	flow := &types.Flow{
+		User:     types.NewUser(1, 1, "test"),
+		Trace:    types.NewFlowTrace(1),
+		RootCall: &types.Call{CallId: 5, Params: &types.CallParams{From: "100"}},
+		Cells:    []*types.Cell{subflow}}
+	done := make(chan bool)
+	go func() {
+		ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), subflow)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("flow did not finish")
+	}
+	return flow
+}
+
+func TestSubflowReturnsOutputs(t *testing.T) {
+	stubFlows(t, map[string]string{"verify": verifyFlow})
+	subflow := newTestCell("1", "VerifyAccount", "devs.SubflowModel", map[string]types.ModelData{
+		"flow_id": types.ModelDataStr{Value: "verify"},
+		"parameters": types.ModelDataList{Value: []map[string]string{
+			{"name": "account", "value": "{{call.from}}"}}}})
+	after := newTestCell("2", "After", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{
+			{"name": "result", "value": "{{VerifyAccount.verified}}"}}}})
+	connectTestCells(subflow, "Completed", after)
+
+	flow := runSubflowCell(t, subflow)
+	result, _ := flow.GetVariable("result")
+	require.Equal(t, "100-ok", result)
+	// the parameters and variables of the subflow stay in the subflow
+	_, ok := flow.GetVariable("verified")
+	require.False(t, ok)
+
+	names := make([]string, 0)
+	for _, entry := range flow.Trace.Snapshot().Entries {
+		names = append(names, entry.CellName)
+	}
+	require.Equal(t, []string{"VerifyAccount", "Launch", "Verify", "Done", "After"}, names)
+}
+
+func TestSubflowDepthLimit(t *testing.T) {
+	t.Setenv("FLOW_MAX_SUBFLOW_DEPTH", "2")
+	stubFlows(t, map[string]string{"recursive": recursiveFlow})
+	subflow := newTestCell("1", "Start", "devs.SubflowModel", map[string]types.ModelData{
+		"flow_id": types.ModelDataStr{Value: "recursive"}})
+	failed := newTestCell("2", "Failed", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{
+			{"name": "reason", "value": "{{Start.error}}"}}}})
+	connectTestCells(subflow, "Error", failed)
+
+	flow := runSubflowCell(t, subflow)
+	reason, _ := flow.GetVariable("reason")
+	require.Equal(t, "subflows are nested more than 2 levels deep", reason)
+}
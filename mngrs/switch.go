@@ -2,6 +2,9 @@ package mngrs
 
 import (
 	//"context"
+	"errors"
+	"regexp"
+	"strconv"
 	"strings"
 	//"github.com/CyCoreSystems/ari/v5"
 
@@ -11,6 +14,22 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+const (
+	LINK_CONDITION_MATCHES  = "LINK_CONDITION_MATCHES"
+	LINK_CONDITION_NO_MATCH = "LINK_CONDITION_NO_MATCH"
+)
+
+// SwitchConfig is the config of Switch cells. TestTemplate is the test before
+// its templates were resolved.
+type SwitchConfig struct {
+	Test         string `cell:"test,required"`
+	TestTemplate string `cell:"test_before_interpolations"`
+}
+
+func (conf *SwitchConfig) Validate() error {
+	return nil
+}
+
 type SwitchManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -24,26 +43,128 @@ func NewSwitchManager(mngrCtx *types.Context, flow *types.Flow) *SwitchManager {
 	return &item
 }
 func (man *SwitchManager) StartProcessing() {
-	go man.startTestForCondition()
+	goCell(man.ManagerContext, man.startTestForCondition)
+}
+
+// splitList splits a comma separated condition value.
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
+
+func parseNumbers(values ...string) ([]float64, bool) {
+	nums := make([]float64, len(values))
+	for i, value := range values {
+		num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = num
+	}
+	return nums, true
+}
+
+// matchCondition tests a single switch link against the result of the test.
+// Numeric conditions never match values that are not numbers.
+func matchCondition(flow *types.Flow, link *types.ModelLink, result string) (bool, error) {
+	if link.Condition == "Expression" {
+		return EvaluateCondition(flow, link.Value)
+	}
+	value := flow.Interpolate(link.Value)
+	if link.IgnoreCase {
+		result = strings.ToLower(result)
+		value = strings.ToLower(value)
+	}
+
+	switch link.Condition {
+	case "Equals":
+		return result == value, nil
+	case "Not equals":
+		return result != value, nil
+	case "Starts with":
+		return strings.HasPrefix(result, value), nil
+	case "Ends with":
+		return strings.HasSuffix(result, value), nil
+	case "Contains":
+		return strings.Contains(result, value), nil
+	case "Matches any":
+		for _, item := range splitList(value) {
+			if result == item {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "Matches regex":
+		pattern := link.Value
+		if link.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		rex, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		return rex.MatchString(result), nil
+	case "Greater than", "Less than":
+		nums, ok := parseNumbers(result, value)
+		if !ok {
+			return false, nil
+		}
+		if link.Condition == "Greater than" {
+			return nums[0] > nums[1], nil
+		}
+		return nums[0] < nums[1], nil
+	case "Between":
+		bounds := splitList(value)
+		if len(bounds) != 2 {
+			return false, errors.New("between needs two values separated by a comma")
+		}
+		nums, ok := parseNumbers(result, bounds[0], bounds[1])
+		if !ok {
+			return false, nil
+		}
+		return nums[0] >= nums[1] && nums[0] <= nums[2], nil
+	case "Is empty":
+		return strings.TrimSpace(result) == "", nil
+	case "Is not empty":
+		return strings.TrimSpace(result) != "", nil
+	}
+	return false, errors.New("unknown condition " + link.Condition)
 }
+
+func (man *SwitchManager) findLinkToCell(name string) *types.Link {
+	for _, item := range man.ManagerContext.Cell.SourceLinks {
+		helpers.Log(logrus.DebugLevel, "comparing 1: "+name)
+		helpers.Log(logrus.DebugLevel, "comparing 2: "+item.Target.Model.Name)
+		if item.Target.Model.Name == name {
+			return item
+		}
+	}
+	return nil
+}
+
 func (man *SwitchManager) startTestForCondition() {
 	cell := man.ManagerContext.Cell
 	flow := man.ManagerContext.Flow
 	channel := man.ManagerContext.Channel
 	//ctx := man.ManagerContext.Context
-	data := cell.Model.Data
 	links := cell.Model.Links
-	sourceLinks := cell.SourceLinks
-	before := data["test_before_interpolations"].(types.ModelDataStr).Value
-	test := data["test"].(types.ModelDataStr).Value
+	var conf SwitchConfig
+	if err := loadConfig(man.ManagerContext, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid switch cell: "+err.Error())
+		failCell(man.ManagerContext, err)
+		return
+	}
 	var result string
-	_, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Finished")
 
-	if strings.HasPrefix(test, "{{") && strings.HasSuffix(test, "}}") {
-		result = before
+	if strings.Contains(conf.TestTemplate, "{{") {
+		// the test was a template which was already resolved
+		result = conf.Test
 	} else {
-		helpers.Log(logrus.DebugLevel, "test variable: "+test)
-		splitted := strings.Split(test, ".")
+		helpers.Log(logrus.DebugLevel, "test variable: "+conf.Test)
+		splitted := strings.Split(conf.Test, ".")
 		if len(splitted) > 1 {
 			name := splitted[0]
 			variable := strings.Join(splitted[1:], ".")
@@ -53,54 +174,58 @@ func (man *SwitchManager) startTestForCondition() {
 				helpers.Log(logrus.DebugLevel, "cell lookup error: "+err.Error())
 			}
 			result = value
+		} else if value, ok := flow.GetVariable(conf.Test); ok {
+			// a flow variable set by a Set Variables cell
+			result = value
 		}
 	}
 	helpers.Log(logrus.DebugLevel, "result is: "+result)
 
-	var matched *types.ModelLink
+	// the first matching link wins
+	var next *types.Link
+	var noMatch *types.ModelLink
 	for _, link := range links {
-		cond := link.Condition
-		condType := link.Type
-		value := link.Value
-
-		helpers.Log(logrus.DebugLevel, "Cond type: "+condType)
-		helpers.Log(logrus.DebugLevel, "Cond: "+cond)
-		helpers.Log(logrus.DebugLevel, "Value: "+value)
-		if condType == "LINK_CONDITION_MATCHES" {
-			if cond == "Equals" && result == value {
-				// matched
-				matched = link
-			} else if cond == "Starts with" && strings.HasPrefix(result, value) {
-				// matched
-				matched = link
-			} else if cond == "Ends with" && strings.HasSuffix(result, value) {
-				// matched
-				matched = link
-			} else if cond == "Matches any" && strings.Contains(result, value) {
-				// matched
-				matched = link
+		helpers.Log(logrus.DebugLevel, "Cond type: "+link.Type)
+		helpers.Log(logrus.DebugLevel, "Cond: "+link.Condition)
+		helpers.Log(logrus.DebugLevel, "Value: "+link.Value)
+		if link.Type == LINK_CONDITION_NO_MATCH {
+			if noMatch == nil {
+				noMatch = link
 			}
+			continue
+		}
+		if link.Type != LINK_CONDITION_MATCHES {
+			continue
+		}
+		matched, err := matchCondition(flow, link, result)
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "switch condition error: "+err.Error())
+			continue
+		}
+		if !matched {
+			continue
 		}
+		next = man.findLinkToCell(link.Cell)
+		if next != nil {
+			helpers.Log(logrus.DebugLevel, "found match - going to result..")
+			break
+		}
+		helpers.Log(logrus.ErrorLevel, "matched cell is not linked: "+link.Cell)
 	}
 
-	if matched != nil {
-
-		for _, item := range sourceLinks {
-			helpers.Log(logrus.DebugLevel, "comparing 1: "+matched.Cell)
-			helpers.Log(logrus.DebugLevel, "comparing 2: "+item.Target.Model.Name)
-			if item.Target.Model.Name == matched.Cell {
-				helpers.Log(logrus.DebugLevel, "found match - going to result..")
-				resp := types.ManagerResponse{
-					Channel: channel,
-					Link:    item}
-				man.ManagerContext.RecvChannel <- &resp
-				return
-			}
-		}
+	if next == nil && noMatch != nil {
+		helpers.Log(logrus.DebugLevel, "no match - going to default cell..")
+		next = man.findLinkToCell(noMatch.Cell)
+	}
+	if next == nil {
+		next, _ = utils.FindLinkByName(cell.SourceLinks, "source", "No Match")
+	}
+	if next == nil {
+		helpers.Log(logrus.DebugLevel, "no condition matched and no default is linked")
 	}
 	resp := types.ManagerResponse{
 		Channel: channel,
-		Link:    nil}
+		Link:    next}
 	man.ManagerContext.RecvChannel <- &resp
 
 }
@@ -0,0 +1,118 @@
+package mngrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestMatchCondition(t *testing.T) {
+	t.Parallel()
+	flow := &types.Flow{}
+	flow.SetVariable("vip", "GOLD")
+
+	tests := []struct {
+		name   string
+		link   types.ModelLink
+		result string
+		want   bool
+		fail   bool
+	}{
+		{name: "Equals", link: types.ModelLink{Condition: "Equals", Value: "1"}, result: "1", want: true},
+		{name: "EqualsCase", link: types.ModelLink{Condition: "Equals", Value: "sales"}, result: "Sales", want: false},
+		{name: "EqualsIgnoreCase", link: types.ModelLink{Condition: "Equals", Value: "sales", IgnoreCase: true}, result: "Sales", want: true},
+		{name: "EqualsTemplate", link: types.ModelLink{Condition: "Equals", Value: "{{flow.vip}}"}, result: "GOLD", want: true},
+		{name: "NotEquals", link: types.ModelLink{Condition: "Not equals", Value: "1"}, result: "2", want: true},
+		{name: "StartsWith", link: types.ModelLink{Condition: "Starts with", Value: "+1"}, result: "+1514", want: true},
+		{name: "EndsWith", link: types.ModelLink{Condition: "Ends with", Value: "14"}, result: "+1514", want: true},
+		{name: "Contains", link: types.ModelLink{Condition: "Contains", Value: "51"}, result: "+1514", want: true},
+		{name: "MatchesAny", link: types.ModelLink{Condition: "Matches any", Value: "1, 2, 3"}, result: "2", want: true},
+		{name: "MatchesAnyIsNotContains", link: types.ModelLink{Condition: "Matches any", Value: "1, 2, 3"}, result: "12", want: false},
+		{name: "Regex", link: types.ModelLink{Condition: "Matches regex", Value: `^\d{4}$`}, result: "1234", want: true},
+		{name: "RegexIgnoreCase", link: types.ModelLink{Condition: "Matches regex", Value: "^yes", IgnoreCase: true}, result: "YES please", want: true},
+		{name: "InvalidRegex", link: types.ModelLink{Condition: "Matches regex", Value: "("}, result: "1", fail: true},
+		{name: "GreaterThan", link: types.ModelLink{Condition: "Greater than", Value: "9"}, result: "10", want: true},
+		{name: "LessThanIsNumeric", link: types.ModelLink{Condition: "Less than", Value: "10"}, result: "9", want: true},
+		{name: "LessThan", link: types.ModelLink{Condition: "Less than", Value: "9"}, result: "10", want: false},
+		{name: "NotANumber", link: types.ModelLink{Condition: "Greater than", Value: "9"}, result: "abc", want: false},
+		{name: "Between", link: types.ModelLink{Condition: "Between", Value: "1,5"}, result: "5", want: true},
+		{name: "NotBetween", link: types.ModelLink{Condition: "Between", Value: "1,5"}, result: "6", want: false},
+		{name: "BetweenOneValue", link: types.ModelLink{Condition: "Between", Value: "1"}, result: "1", fail: true},
+		{name: "IsEmpty", link: types.ModelLink{Condition: "Is empty"}, result: " ", want: true},
+		{name: "IsNotEmpty", link: types.ModelLink{Condition: "Is not empty"}, result: "1", want: true},
+		{name: "Expression", link: types.ModelLink{Condition: "Expression", Value: `vip == "GOLD" && 2 > 1`}, want: true},
+		{name: "Unknown", link: types.ModelLink{Condition: "Sounds like", Value: "1"}, result: "1", fail: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchCondition(flow, &tt.link, tt.result)
+			if tt.fail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func runTestSwitch(flow *types.Flow, cell *types.Cell) *types.ManagerResponse {
+	recv := make(chan *types.ManagerResponse)
+	ctx := types.NewContext(nil, nil, recv, flow, cell, nil, nil)
+	NewSwitchManager(ctx, flow).StartProcessing()
+	return <-recv
+}
+
+func TestSwitchManager(t *testing.T) {
+	t.Parallel()
+	newFlow := func(links []*types.ModelLink, noMatchPort bool) (*types.Flow, *types.Cell) {
+		switchCell := newTestCell("1", "Switch1", "devs.SwitchModel", map[string]types.ModelData{
+			"test": types.ModelDataStr{Value: "{{flow.amount}}"}})
+		switchCell.Model.Links = links
+		flow := &types.Flow{Cells: []*types.Cell{switchCell}}
+		for i, name := range []string{"Low", "High", "Other"} {
+			target := newTestCell(string(rune('2'+i)), name, "devs.WaitModel", nil)
+			connectTestCells(switchCell, "Out", target)
+			flow.Cells = append(flow.Cells, target)
+		}
+		if noMatchPort {
+			connectTestCells(switchCell, "No Match", flow.Cells[3])
+		}
+		flow.SetVariable("amount", "50")
+		return flow, switchCell
+	}
+
+	t.Run("FirstMatchWins", func(t *testing.T) {
+		flow, cell := newFlow([]*types.ModelLink{
+			{Type: LINK_CONDITION_MATCHES, Condition: "Less than", Value: "100", Cell: "Low"},
+			{Type: LINK_CONDITION_MATCHES, Condition: "Greater than", Value: "10", Cell: "High"},
+		}, false)
+		resp := runTestSwitch(flow, cell)
+		require.Equal(t, "Low", resp.Link.Target.Model.Name)
+	})
+	t.Run("DefaultLink", func(t *testing.T) {
+		flow, cell := newFlow([]*types.ModelLink{
+			{Type: LINK_CONDITION_NO_MATCH, Cell: "Other"},
+			{Type: LINK_CONDITION_MATCHES, Condition: "Greater than", Value: "100", Cell: "High"},
+		}, false)
+		resp := runTestSwitch(flow, cell)
+		require.Equal(t, "Other", resp.Link.Target.Model.Name)
+	})
+	t.Run("NoMatchPort", func(t *testing.T) {
+		flow, cell := newFlow([]*types.ModelLink{
+			{Type: LINK_CONDITION_MATCHES, Condition: "Greater than", Value: "100", Cell: "High"},
+		}, true)
+		resp := runTestSwitch(flow, cell)
+		require.Equal(t, "No Match", resp.Link.Link.Source.Port)
+	})
+	t.Run("NoDefault", func(t *testing.T) {
+		flow, cell := newFlow([]*types.ModelLink{
+			{Type: LINK_CONDITION_MATCHES, Condition: "Greater than", Value: "100", Cell: "High"},
+		}, false)
+		resp := runTestSwitch(flow, cell)
+		require.Nil(t, resp.Link)
+	})
+}
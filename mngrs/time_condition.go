@@ -0,0 +1,437 @@
+package mngrs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+const (
+	TIME_OVERRIDE_OPEN   = "open"
+	TIME_OVERRIDE_CLOSED = "closed"
+)
+
+var (
+	clockMu sync.Mutex
+	clock   = time.Now
+)
+
+// SetClock replaces the clock Time Condition cells are evaluated against. A
+// nil clock uses the time of the system again.
+func SetClock(now func() time.Time) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	if now == nil {
+		now = time.Now
+	}
+	clock = now
+}
+
+func currentTime() time.Time {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	return clock()
+}
+
+// TimeOverrideStore keeps the overrides of time conditions, which force them
+// open or closed, e.g. on a snow day. Overrides are scoped to a workspace and
+// named, and every Time Condition cell names the override it follows.
+type TimeOverrideStore interface {
+	// Get returns the mode of an override, or an empty string when it is
+	// not set.
+	Get(workspaceId int, name string) (string, error)
+	// Set sets the mode of an override, which expires after ttl unless ttl
+	// is zero. An empty mode clears the override.
+	Set(workspaceId int, name string, mode string, ttl time.Duration) error
+}
+
+// RedisTimeOverrideStore keeps each override in a Redis key.
+type RedisTimeOverrideStore struct {
+	Client *redis.Client
+}
+
+func timeOverrideKey(workspaceId int, name string) string {
+	return "time_override:" + strconv.Itoa(workspaceId) + ":" + name
+}
+
+func (store *RedisTimeOverrideStore) Get(workspaceId int, name string) (string, error) {
+	mode, err := store.Client.Get(context.Background(), timeOverrideKey(workspaceId, name)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return mode, err
+}
+
+func (store *RedisTimeOverrideStore) Set(workspaceId int, name string, mode string, ttl time.Duration) error {
+	if mode == "" {
+		return store.Client.Del(context.Background(), timeOverrideKey(workspaceId, name)).Err()
+	}
+	return store.Client.Set(context.Background(), timeOverrideKey(workspaceId, name), mode, ttl).Err()
+}
+
+var (
+	timeOverrideMu    sync.Mutex
+	timeOverrideStore TimeOverrideStore
+)
+
+// SetTimeOverrideStore replaces the store used for time condition overrides.
+func SetTimeOverrideStore(store TimeOverrideStore) {
+	timeOverrideMu.Lock()
+	defer timeOverrideMu.Unlock()
+	timeOverrideStore = store
+}
+
+func getTimeOverrideStore() TimeOverrideStore {
+	timeOverrideMu.Lock()
+	defer timeOverrideMu.Unlock()
+	if timeOverrideStore == nil {
+		timeOverrideStore = &RedisTimeOverrideStore{Client: utils.CreateRDB()}
+	}
+	return timeOverrideStore
+}
+
+// GetTimeOverride returns the mode of an override of a workspace.
+func GetTimeOverride(workspaceId int, name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", errors.New("override name is empty")
+	}
+	return getTimeOverrideStore().Get(workspaceId, name)
+}
+
+// SetTimeOverride forces the time conditions following an override of a
+// workspace "open" or "closed", or clears the override with an empty mode.
+// A ttl of zero keeps the override until it is cleared.
+func SetTimeOverride(workspaceId int, name string, mode string, ttl time.Duration) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("override name is empty")
+	}
+	switch mode {
+	case "", TIME_OVERRIDE_OPEN, TIME_OVERRIDE_CLOSED:
+	default:
+		return errors.New("override mode must be open, closed or empty, got " + mode)
+	}
+	return getTimeOverrideStore().Set(workspaceId, name, mode, ttl)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseDays reads days like "mon-fri" or "sat,sun". Ranges wrap around the
+// week, so "fri-mon" includes the weekend. No days means every day.
+func parseDays(value string) ([7]bool, error) {
+	var days [7]bool
+	if strings.TrimSpace(value) == "" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, item := range splitList(strings.ToLower(value)) {
+		bounds := strings.SplitN(item, "-", 2)
+		first, ok := weekdays[strings.TrimSpace(bounds[0])]
+		if !ok {
+			return days, errors.New("unknown day " + strconv.Quote(bounds[0]))
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[strings.TrimSpace(bounds[1])]; !ok {
+				return days, errors.New("unknown day " + strconv.Quote(bounds[1]))
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock reads a time of day like "09:30" as minutes since midnight.
+// "24:00" is the end of the day.
+func parseClock(value string, defaultValue int) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue, nil
+	}
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	at, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("time must be HH:MM, got " + strconv.Quote(value))
+	}
+	return at.Hour()*60 + at.Minute(), nil
+}
+
+func parseDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return "", errors.New("date must be YYYY-MM-DD, got " + strconv.Quote(value))
+	}
+	return value, nil
+}
+
+// parseHoliday reads a holiday on a date like "2024-12-26", or every year
+// like "12-25".
+func parseHoliday(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		return value, nil
+	}
+	if _, err := time.Parse("01-02", value); err == nil {
+		return value, nil
+	}
+	return "", errors.New("holiday must be YYYY-MM-DD or MM-DD, got " + strconv.Quote(value))
+}
+
+// TimeSchedule is a weekly schedule of a Time Condition cell. A schedule
+// whose end is before its start runs overnight into the next day, and a
+// schedule with from and to dates only applies between them.
+type TimeSchedule struct {
+	Name  string
+	Days  [7]bool
+	Start int
+	End   int
+	From  string
+	To    string
+}
+
+func parseTimeSchedule(entry map[string]string) (*TimeSchedule, error) {
+	schedule := &TimeSchedule{Name: strings.TrimSpace(entry["name"])}
+	if schedule.Name == "" {
+		return nil, errors.New("every schedule needs a name")
+	}
+	var err error
+	if schedule.Days, err = parseDays(entry["days"]); err != nil {
+		return nil, err
+	}
+	if schedule.Start, err = parseClock(entry["start"], 0); err != nil {
+		return nil, err
+	}
+	if schedule.End, err = parseClock(entry["end"], 24*60); err != nil {
+		return nil, err
+	}
+	if schedule.From, err = parseDate(entry["from"]); err != nil {
+		return nil, err
+	}
+	if schedule.To, err = parseDate(entry["to"]); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Includes reports whether the schedule is open at a local time.
+func (schedule *TimeSchedule) Includes(at time.Time) bool {
+	date := at.Format("2006-01-02")
+	if (schedule.From != "" && date < schedule.From) || (schedule.To != "" && date > schedule.To) {
+		return false
+	}
+	minute := at.Hour()*60 + at.Minute()
+	day := at.Weekday()
+	if schedule.Start < schedule.End {
+		return schedule.Days[day] && minute >= schedule.Start && minute < schedule.End
+	}
+	// overnight, the early hours belong to the day before
+	yesterday := (day + 6) % 7
+	return (schedule.Days[day] && minute >= schedule.Start) || (schedule.Days[yesterday] && minute < schedule.End)
+}
+
+// TimeConditionConfig is the config of Time Condition cells.
+type TimeConditionConfig struct {
+	Timezone        string              `cell:"timezone"`
+	Schedules       []map[string]string `cell:"schedules,required"`
+	Holidays        []string            `cell:"holidays"`
+	HolidayCalendar string              `cell:"holiday_calendar"`
+	Override        string              `cell:"override" default:"default"`
+
+	schedules []*TimeSchedule
+	holidays  map[string]string
+}
+
+func (conf *TimeConditionConfig) Validate() error {
+	if conf.Timezone != "" {
+		if _, err := time.LoadLocation(conf.Timezone); err != nil {
+			return &CellConfigError{Field: "timezone", Message: "unknown timezone " + conf.Timezone}
+		}
+	}
+	conf.schedules = nil
+	for _, entry := range conf.Schedules {
+		schedule, err := parseTimeSchedule(entry)
+		if err != nil {
+			return &CellConfigError{Field: "schedules", Message: err.Error()}
+		}
+		conf.schedules = append(conf.schedules, schedule)
+	}
+	conf.holidays = make(map[string]string)
+	for _, value := range conf.Holidays {
+		date, err := parseHoliday(value)
+		if err != nil {
+			return &CellConfigError{Field: "holidays", Message: err.Error()}
+		}
+		conf.holidays[date] = date
+	}
+	return nil
+}
+
+// Evaluate returns the first schedule open at a local time and the name of
+// the holiday it falls on, if any. Holidays are keyed by their date, like
+// "2024-12-26", or their day of every year, like "12-25".
+func (conf *TimeConditionConfig) Evaluate(at time.Time, holidays map[string]string) (*TimeSchedule, string) {
+	holiday, ok := holidays[at.Format("2006-01-02")]
+	if !ok {
+		holiday = holidays[at.Format("01-02")]
+	}
+	for _, schedule := range conf.schedules {
+		if schedule.Includes(at) {
+			return schedule, holiday
+		}
+	}
+	return nil, holiday
+}
+
+// loadHolidays returns the holidays of the cell along with the ones of its
+// calendar, a workspace table with a "date" column and an optional "name"
+// column.
+func (conf *TimeConditionConfig) loadHolidays(workspaceId int) (map[string]string, error) {
+	if conf.HolidayCalendar == "" {
+		return conf.holidays, nil
+	}
+	table, err := loadLookupTable(workspaceId, conf.HolidayCalendar)
+	if err != nil {
+		return nil, err
+	}
+	holidays := make(map[string]string, len(conf.holidays)+len(table.Rows))
+	for date, name := range conf.holidays {
+		holidays[date] = name
+	}
+	for _, row := range table.Rows {
+		date, err := parseHoliday(row["date"])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, "skipping holiday of calendar "+conf.HolidayCalendar+": "+err.Error())
+			continue
+		}
+		name := strings.TrimSpace(row["name"])
+		if name == "" {
+			name = date
+		}
+		holidays[date] = name
+	}
+	return holidays, nil
+}
+
+// TimeConditionManager routes a call by the time of day, e.g.
+// {"schedules": [{"name": "Open", "days": "mon-fri", "start": "09:00",
+// "end": "17:00"}], "holidays": ["12-25"], "timezone": "America/Toronto"}.
+// The call leaves through the port named after the first open schedule, the
+// "Holiday" port on holidays, when it is linked, and the "Otherwise" port
+// when no schedule is open. Times are in the timezone of the cell, or of the
+// workspace when the cell has none. The override of the cell forces it open,
+// through its first schedule, or closed.
+type TimeConditionManager struct {
+	ManagerContext *types.Context
+	Flow           *types.Flow
+}
+
+func NewTimeConditionManager(mngrCtx *types.Context, flow *types.Flow) *TimeConditionManager {
+	item := TimeConditionManager{
+		ManagerContext: mngrCtx,
+		Flow:           flow}
+	return &item
+}
+
+func (man *TimeConditionManager) StartProcessing() {
+	goCell(man.ManagerContext, man.evaluate)
+}
+
+func (man *TimeConditionManager) evaluate() {
+	ctx := man.ManagerContext
+	cell := ctx.Cell
+	flow := ctx.Flow
+
+	var conf TimeConditionConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.ErrorLevel, "invalid time condition cell: "+err.Error())
+		failCell(ctx, err)
+		return
+	}
+	if flow.User == nil {
+		failCell(ctx, errors.New("the flow has no workspace to evaluate times in"))
+		return
+	}
+	workspaceId := flow.User.Workspace.Id
+	timezone := conf.Timezone
+	if timezone == "" {
+		timezone = flow.User.Workspace.Timezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		failCell(ctx, errors.New("unknown timezone "+timezone))
+		return
+	}
+	at := currentTime().In(location)
+	cell.SetEventVar("local_time", at.Format(time.RFC3339))
+
+	override, err := GetTimeOverride(workspaceId, conf.Override)
+	if err != nil {
+		// a broken store should not close the business
+		helpers.Log(logrus.ErrorLevel, "could not read time override: "+err.Error())
+	}
+	cell.SetEventVar("override", override)
+
+	var schedule *TimeSchedule
+	var holiday string
+	switch override {
+	case TIME_OVERRIDE_OPEN:
+		if len(conf.schedules) > 0 {
+			schedule = conf.schedules[0]
+		}
+	case TIME_OVERRIDE_CLOSED:
+	default:
+		holidays, err := conf.loadHolidays(workspaceId)
+		if err != nil {
+			failCell(ctx, errors.New("could not load holiday calendar "+conf.HolidayCalendar+": "+err.Error()))
+			return
+		}
+		schedule, holiday = conf.Evaluate(at, holidays)
+	}
+	cell.SetEventVar("holiday", holiday)
+
+	var next *types.Link
+	if holiday != "" {
+		next, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Holiday")
+	}
+	if next == nil && holiday == "" && schedule != nil {
+		cell.SetEventVar("schedule", schedule.Name)
+		next, _ = utils.FindLinkByName(cell.SourceLinks, "source", schedule.Name)
+		if next == nil {
+			helpers.Log(logrus.ErrorLevel, "schedule "+schedule.Name+" is not linked")
+		}
+	}
+	if next == nil {
+		next, _ = utils.FindLinkByName(cell.SourceLinks, "source", "Otherwise")
+	}
+	ctx.RecvChannel <- &types.ManagerResponse{
+		Channel: ctx.Channel,
+		Link:    next}
+}
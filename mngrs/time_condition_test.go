@@ -0,0 +1,147 @@
+package mngrs
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/types"
+)
+
+type memoryTimeOverrideStore struct {
+	mu    sync.Mutex
+	modes map[string]string
+}
+
+func (store *memoryTimeOverrideStore) Get(workspaceId int, name string) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.modes[timeOverrideKey(workspaceId, name)], nil
+}
+
+func (store *memoryTimeOverrideStore) Set(workspaceId int, name string, mode string, ttl time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.modes[timeOverrideKey(workspaceId, name)] = mode
+	return nil
+}
+
+var officeSchedules = []map[string]string{
+	{"name": "Open", "days": "mon-fri", "start": "09:00", "end": "17:00"},
+	{"name": "Night", "days": "fri-sun", "start": "22:00", "end": "02:00"},
+	{"name": "Summer", "from": "2024-07-01", "to": "2024-08-31", "days": "sat"},
+}
+
+func TestTimeConditionEvaluate(t *testing.T) {
+	conf := TimeConditionConfig{Schedules: officeSchedules, Holidays: []string{"12-25", "2024-12-26"}}
+	require.NoError(t, conf.Validate())
+	location, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		at       string
+		schedule string
+		holiday  string
+	}{
+		{"Weekday", "2024-03-06 09:00", "Open", ""},
+		{"WeekdayClosed", "2024-03-06 17:00", "", ""},
+		{"Overnight", "2024-03-08 23:30", "Night", ""},
+		{"OvernightNextDay", "2024-03-11 01:59", "Night", ""},
+		{"OvernightEnded", "2024-03-09 02:00", "", ""},
+		{"DateRange", "2024-07-06 12:00", "Summer", ""},
+		{"OutsideDateRange", "2024-09-07 12:00", "", ""},
+		{"YearlyHoliday", "2025-12-25 10:00", "Open", "12-25"},
+		{"DatedHoliday", "2024-12-26 10:00", "Open", "2024-12-26"},
+		{"DatedHolidayOtherYear", "2025-12-26 10:00", "Open", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := time.ParseInLocation("2006-01-02 15:04", tt.at, location)
+			require.NoError(t, err)
+			schedule, holiday := conf.Evaluate(at, conf.holidays)
+			name := ""
+			if schedule != nil {
+				name = schedule.Name
+			}
+			require.Equal(t, tt.schedule, name)
+			require.Equal(t, tt.holiday, holiday)
+		})
+	}
+}
+
+func TestTimeConditionConfigValidate(t *testing.T) {
+	for _, conf := range []TimeConditionConfig{
+		{Schedules: []map[string]string{{"days": "mon"}}},
+		{Schedules: []map[string]string{{"name": "Open", "days": "mon-fry"}}},
+		{Schedules: []map[string]string{{"name": "Open", "start": "9am"}}},
+		{Schedules: []map[string]string{{"name": "Open", "from": "2024-13-01"}}},
+		{Schedules: officeSchedules, Holidays: []string{"christmas"}},
+		{Schedules: officeSchedules, Timezone: "Mars/Olympus"},
+	} {
+		conf := conf
+		require.Error(t, conf.Validate())
+	}
+}
+
+func TestTimeConditionManager(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	overrides := &memoryTimeOverrideStore{modes: make(map[string]string)}
+	SetTimeOverrideStore(overrides)
+	defer SetTimeOverrideStore(nil)
+	defer SetClock(nil)
+	fetchLookupTable = func(workspace string, name string) (*api.LookupTableResponse, error) {
+		require.Equal(t, "holidays", name)
+		return &api.LookupTableResponse{Name: name, Format: "csv", Data: "date,name\n2024-07-01,Canada Day\n"}, nil
+	}
+	defer func() { fetchLookupTable = api.GetLookupTable }()
+
+	run := func(now string, timezone string) (string, *types.Cell) {
+		at, err := time.Parse(time.RFC3339, now)
+		require.NoError(t, err)
+		SetClock(func() time.Time { return at })
+
+		condition := newTestCell("1", "Hours1", "devs.TimeConditionModel", map[string]types.ModelData{
+			"schedules":        types.ModelDataList{Value: officeSchedules},
+			"holiday_calendar": types.ModelDataStr{Value: "holidays"}})
+		cells := []*types.Cell{condition}
+		for i, port := range []string{"Open", "Night", "Holiday", "Otherwise"} {
+			target := newTestCell(string(rune('2'+i)), port, "devs.SetVariablesModel", map[string]types.ModelData{
+				"variables": types.ModelDataList{Value: []map[string]string{{"name": "route", "value": port}}}})
+			connectTestCells(condition, port, target)
+			cells = append(cells, target)
+		}
+		user := types.NewUser(1, 7, "test")
+		user.Workspace.Timezone = timezone
+		flow := &types.Flow{User: user, Trace: types.NewFlowTrace(1), Cells: cells}
+		ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), condition)
+		route, _ := flow.GetVariable("route")
+		return route, condition
+	}
+
+	// 14:00 UTC is 10:00 in Toronto and 23:00 in Tokyo
+	route, _ := run("2024-03-08T14:00:00Z", "America/Toronto")
+	require.Equal(t, "Open", route)
+	route, cell := run("2024-03-08T14:00:00Z", "Asia/Tokyo")
+	require.Equal(t, "Night", route)
+	require.Equal(t, "2024-03-08T23:00:00+09:00", cell.EventVars["local_time"])
+	route, cell = run("2024-07-01T14:00:00Z", "America/Toronto")
+	require.Equal(t, "Holiday", route)
+	require.Equal(t, "Canada Day", cell.EventVars["holiday"])
+
+	require.Error(t, SetTimeOverride(7, "default", "maybe", 0))
+	require.NoError(t, SetTimeOverride(7, "default", TIME_OVERRIDE_CLOSED, 0))
+	route, _ = run("2024-03-08T14:00:00Z", "America/Toronto")
+	require.Equal(t, "Otherwise", route)
+	require.NoError(t, SetTimeOverride(7, "default", TIME_OVERRIDE_OPEN, 0))
+	route, _ = run("2024-07-01T14:00:00Z", "America/Toronto")
+	require.Equal(t, "Open", route)
+	require.NoError(t, SetTimeOverride(7, "default", "", 0))
+	route, _ = run("2024-03-09T20:00:00Z", "America/Toronto")
+	require.Equal(t, "Otherwise", route)
+}
@@ -0,0 +1,276 @@
+package mngrs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/internal/config"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// TraceStore persists the traces of calls that have ended.
+type TraceStore interface {
+	Save(trace *types.FlowTrace) error
+	Load(callId string) (*types.FlowTrace, error)
+}
+
+// FileTraceStore appends traces to a JSONL file. Once the file reaches
+// MaxSize bytes it is moved to "<Path>.1", replacing the previous one, so
+// that at most twice MaxSize is kept. A MaxSize of zero never rotates.
+type FileTraceStore struct {
+	Path    string
+	MaxSize int64
+	mu      sync.Mutex
+}
+
+func (store *FileTraceStore) Save(trace *types.FlowTrace) error {
+	line, err := json.Marshal(trace)
+	if err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if err := store.rotate(int64(len(line) + 1)); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(store.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// rotate moves the file aside when writing size more bytes would take it
+// past the maximum size.
+func (store *FileTraceStore) rotate(size int64) error {
+	if store.MaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(store.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+size <= store.MaxSize {
+		return nil
+	}
+	return os.Rename(store.Path, store.Path+".1")
+}
+
+// Load returns the last trace saved for the call, looking in the rotated
+// file when the current one does not have it.
+func (store *FileTraceStore) Load(callId string) (*types.FlowTrace, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, path := range []string{store.Path, store.Path + ".1"} {
+		found, err := loadTraceFile(path, callId)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+	return nil, errors.New("no trace found for call " + callId)
+}
+
+func loadTraceFile(path string, callId string) (*types.FlowTrace, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var found *types.FlowTrace
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var trace types.FlowTrace
+		if err := json.Unmarshal(scanner.Bytes(), &trace); err != nil {
+			continue
+		}
+		if trace.CallId == callId {
+			found = &trace
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// RedisTraceStore keeps each trace under its call id for TTL.
+type RedisTraceStore struct {
+	Client *redis.Client
+	TTL    time.Duration
+}
+
+func flowTraceKey(callId string) string {
+	return "flow_trace:" + callId
+}
+
+func (store *RedisTraceStore) Save(trace *types.FlowTrace) error {
+	data, err := json.Marshal(trace)
+	if err != nil {
+		return err
+	}
+	key := trace.CallId
+	if key == "" {
+		key = trace.ChannelId
+	}
+	return store.Client.Set(context.Background(), flowTraceKey(key), data, store.TTL).Err()
+}
+
+func (store *RedisTraceStore) Load(callId string) (*types.FlowTrace, error) {
+	data, err := store.Client.Get(context.Background(), flowTraceKey(callId)).Bytes()
+	if err == redis.Nil {
+		return nil, errors.New("no trace found for call " + callId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var trace types.FlowTrace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, err
+	}
+	return &trace, nil
+}
+
+// APITraceStore saves traces through the internals API.
+type APITraceStore struct{}
+
+func (store *APITraceStore) Save(trace *types.FlowTrace) error {
+	return api.SaveFlowTrace(trace)
+}
+
+func (store *APITraceStore) Load(callId string) (*types.FlowTrace, error) {
+	return api.FetchFlowTrace(callId)
+}
+
+var (
+	tracesMu   sync.Mutex
+	liveTraces = make(map[string]*types.FlowTrace)
+	traceStore TraceStore
+)
+
+// SetTraceStore replaces the store used for traces of ended calls.
+func SetTraceStore(store TraceStore) {
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	traceStore = store
+}
+
+func getTraceStore() TraceStore {
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	if traceStore == nil {
+		cfg := config.NewConfig()
+		switch cfg.TraceStore {
+		case "file":
+			traceStore = &FileTraceStore{Path: cfg.TraceFile, MaxSize: cfg.TraceFileMaxSize}
+		case "redis":
+			traceStore = &RedisTraceStore{Client: utils.CreateRDB(), TTL: cfg.TraceTTL}
+		default:
+			traceStore = &APITraceStore{}
+		}
+	}
+	return traceStore
+}
+
+// trackTrace makes the trace of a running flow available by call id.
+func trackTrace(flow *types.Flow) {
+	trace := flow.Trace
+	if trace == nil {
+		return
+	}
+	if flow.RootCall != nil {
+		trace.CallId = strconv.Itoa(flow.RootCall.CallId)
+	}
+	if flow.Channel != nil && flow.Channel.Channel != nil {
+		trace.ChannelId = flow.Channel.Channel.ID()
+	}
+	key := trace.CallId
+	if key == "" {
+		key = trace.ChannelId
+	}
+	if key == "" {
+		return
+	}
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	liveTraces[key] = trace
+}
+
+// FinishTrace is called when the call ends. The trace is saved to the trace
+// store and is no longer kept in memory.
+func FinishTrace(flow *types.Flow) error {
+	trace := flow.Trace
+	if trace == nil {
+		return nil
+	}
+	trace.End()
+	snapshot := trace.Snapshot()
+	tracesMu.Lock()
+	for key, item := range liveTraces {
+		if item == trace {
+			delete(liveTraces, key)
+		}
+	}
+	tracesMu.Unlock()
+
+	helpers.Log(logrus.DebugLevel, "saving trace of call "+snapshot.CallId+" with "+strconv.Itoa(len(snapshot.Entries))+" entries")
+	return getTraceStore().Save(snapshot)
+}
+
+// LookupTrace returns the trace of a call, either while it is running or from
+// the trace store once it has ended. Flows started without a call are looked
+// up by channel id.
+func LookupTrace(callId string) (*types.FlowTrace, error) {
+	tracesMu.Lock()
+	trace, ok := liveTraces[callId]
+	tracesMu.Unlock()
+	if ok {
+		return trace.Snapshot(), nil
+	}
+	return getTraceStore().Load(callId)
+}
+
+// changedVariables returns the cell and flow variables that differ from the
+// given snapshots. Flow variables are prefixed with "flow.".
+func changedVariables(cell *types.Cell, flow *types.Flow, eventVars map[string]string, flowVars map[string]types.FlowVariable) map[string]string {
+	changed := make(map[string]string)
+	for name, value := range cell.CopyEventVars() {
+		if before, ok := eventVars[name]; !ok || before != value {
+			changed[name] = value
+		}
+	}
+	for name, variable := range flow.Variables() {
+		if before, ok := flowVars[name]; !ok || before.Value != variable.Value {
+			changed["flow."+name] = variable.Value
+		}
+	}
+	return changed
+}
+
+func copyEventVars(vars map[string]string) map[string]string {
+	result := make(map[string]string, len(vars))
+	for name, value := range vars {
+		result[name] = value
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package mngrs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func TestFlowTrace(t *testing.T) {
+	SetTraceStore(&FileTraceStore{Path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	setVars := newTestCell("3", "SetVars1", "devs.SetVariablesModel", map[string]types.ModelData{
+		"variables": types.ModelDataList{Value: []map[string]string{
+			{"name": "count", "type": "number", "value": "5"},
+		}}})
+	flow := &types.Flow{
+		Trace:    types.NewFlowTrace(7),
+		RootCall: &types.Call{CallId: 42},
+		Cells:    []*types.Cell{setVars}}
+
+	// the cell has no outgoing link so the flow ends after it
+	ProcessFlow(nil, context.Background(), flow, &types.LineChannel{}, make(map[string]string), setVars)
+
+	trace, err := LookupTrace("42")
+	require.NoError(t, err)
+	require.True(t, trace.EndedAt.IsZero())
+	require.Len(t, trace.Entries, 1)
+	entry := trace.Entries[0]
+	require.Equal(t, "SetVars1", entry.CellName)
+	require.Equal(t, "devs.SetVariablesModel", entry.CellType)
+	require.Equal(t, map[string]string{"count": "5", "flow.count": "5"}, entry.Vars)
+	require.False(t, entry.ExitedAt.IsZero())
+	require.Empty(t, entry.Port)
+
+	require.NoError(t, FinishTrace(flow))
+	saved, err := LookupTrace("42")
+	require.NoError(t, err)
+	require.Equal(t, 7, saved.FlowId)
+	require.False(t, saved.EndedAt.IsZero())
+	require.Len(t, saved.Entries, 1)
+
+	_, err = LookupTrace("43")
+	require.Error(t, err)
+}
+
+func TestFileTraceStoreRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	store := &FileTraceStore{Path: path, MaxSize: 200}
+	for i := 1; i <= 3; i++ {
+		trace := types.NewFlowTrace(7)
+		trace.CallId = strconv.Itoa(i)
+		require.NoError(t, store.Save(trace))
+	}
+
+	// every trace takes more than half of the maximum size, so only the
+	// last two are kept
+	_, err := store.Load("1")
+	require.Error(t, err)
+	for _, callId := range []string{"2", "3"} {
+		trace, err := store.Load(callId)
+		require.NoError(t, err)
+		require.Equal(t, callId, trace.CallId)
+	}
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.LessOrEqual(t, info.Size(), int64(200))
+}
@@ -0,0 +1,299 @@
+package mngrs
+
+import (
+	"fmt"
+	"strings"
+
+	"lineblocs.com/processor/types"
+)
+
+const (
+	PROBLEM_EMPTY_FLOW          = "empty_flow"
+	PROBLEM_MISSING_LINK_SOURCE = "missing_link_source"
+	PROBLEM_MISSING_LINK_TARGET = "missing_link_target"
+	PROBLEM_UNKNOWN_CELL_TYPE   = "unknown_cell_type"
+	PROBLEM_MISSING_PORT_LINK   = "missing_port_link"
+	PROBLEM_MISSING_MODEL       = "missing_model"
+	PROBLEM_MISSING_FIELD       = "missing_field"
+	PROBLEM_UNREACHABLE_CELL    = "unreachable_cell"
+	PROBLEM_UNKNOWN_ERROR_CELL  = "unknown_error_cell"
+	PROBLEM_INVALID_HANDLER     = "invalid_handler"
+)
+
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+// FlowProblem describes a single defect found in a flow graph.
+type FlowProblem struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	CellId   string `json:"cell_id"`
+	CellName string `json:"cell_name"`
+	Message  string `json:"message"`
+}
+
+func (p *FlowProblem) String() string {
+	if p.CellId == "" {
+		return p.Severity + ": " + p.Message
+	}
+	return fmt.Sprintf("%s: cell %s (%s): %s", p.Severity, p.CellName, p.CellId, p.Message)
+}
+
+// FlowProblems is the result of ValidateFlow.
+type FlowProblems []*FlowProblem
+
+// Err returns nil when none of the problems prevent the flow from running.
+func (problems FlowProblems) Err() error {
+	for _, problem := range problems {
+		if problem.Severity == SEVERITY_ERROR {
+			return &FlowValidationError{Problems: problems}
+		}
+	}
+	return nil
+}
+
+// FlowValidationError is returned for flows that must not be executed.
+type FlowValidationError struct {
+	Problems FlowProblems
+}
+
+func (e *FlowValidationError) Error() string {
+	lines := make([]string, 0)
+	for _, problem := range e.Problems {
+		if problem.Severity == SEVERITY_ERROR {
+			lines = append(lines, problem.String())
+		}
+	}
+	return "invalid flow: " + strings.Join(lines, "; ")
+}
+
+// findGraphCell returns the cell with the given name, like Flow.FindCell.
+func findGraphCell(vars *types.FlowVars, name string) *types.GraphCell {
+	ids := make(map[string]bool)
+	for _, model := range vars.Models {
+		if model.Name == name {
+			ids[model.Id] = true
+		}
+	}
+	for _, cell := range vars.Graph.Cells {
+		if cell == nil || cell.Type == "devs.FlowLink" {
+			continue
+		}
+		if cell.Name == name || ids[cell.Id] {
+			return cell
+		}
+	}
+	return nil
+}
+
+// rawEntries converts a list of objects of the model data.
+func rawEntries(raw []interface{}) []map[string]string {
+	entries := make([]map[string]string, 0, len(raw))
+	for _, item := range raw {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entry := make(map[string]string)
+		for field, value := range obj {
+			if value != nil {
+				entry[field] = fmt.Sprint(value)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func isFieldMissing(data map[string]interface{}, key string) bool {
+	value, ok := data[key]
+	if !ok || value == nil {
+		return true
+	}
+	if str, ok := value.(string); ok && strings.TrimSpace(str) == "" {
+		return true
+	}
+	return false
+}
+
+// ValidateFlow checks a flow graph before any of its cells are created. Dangling
+// links, unknown cell types, unlinked required ports and missing model fields
+// are errors; unreachable cells are reported as warnings.
+func ValidateFlow(vars *types.FlowVars) FlowProblems {
+	problems := make(FlowProblems, 0)
+	add := func(code string, severity string, cell *types.GraphCell, format string, args ...interface{}) {
+		problem := &FlowProblem{
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...)}
+		if cell != nil {
+			problem.CellId = cell.Id
+			problem.CellName = cell.Name
+		}
+		problems = append(problems, problem)
+	}
+
+	if vars == nil {
+		add(PROBLEM_EMPTY_FLOW, SEVERITY_ERROR, nil, "flow has no graph")
+		return problems
+	}
+
+	cells := make(map[string]*types.GraphCell)
+	order := make([]*types.GraphCell, 0)
+	links := make([]*types.GraphCell, 0)
+	for _, cell := range vars.Graph.Cells {
+		if cell == nil {
+			continue
+		}
+		if cell.Type == "devs.FlowLink" {
+			links = append(links, cell)
+			continue
+		}
+		cells[cell.Id] = cell
+		order = append(order, cell)
+	}
+	if len(order) == 0 {
+		add(PROBLEM_EMPTY_FLOW, SEVERITY_ERROR, nil, "flow has no cells")
+		return problems
+	}
+
+	models := make(map[string]map[string]interface{})
+	for _, model := range vars.Models {
+		models[model.Id] = model.Data
+	}
+
+	// the launch cell can name the cell that handles errors of the flow
+	var errorHandler *types.GraphCell
+	for _, cell := range order {
+		if cell.Type != "devs.LaunchModel" {
+			continue
+		}
+		name, _ := models[cell.Id]["on_error"].(string)
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		errorHandler = findGraphCell(vars, name)
+		if errorHandler == nil {
+			add(PROBLEM_UNKNOWN_ERROR_CELL, SEVERITY_ERROR, cell, "on error cell %q does not exist", name)
+		}
+	}
+
+	// and the global handlers of the flow
+	handlerTargets := make([]*types.GraphCell, 0)
+	for _, cell := range order {
+		if cell.Type != "devs.LaunchModel" {
+			continue
+		}
+		raw, ok := models[cell.Id]["handlers"].([]interface{})
+		if !ok {
+			continue
+		}
+		handlers, err := parseHandlers(rawEntries(raw))
+		if err != nil {
+			add(PROBLEM_INVALID_HANDLER, SEVERITY_ERROR, cell, "%s", err.Error())
+			continue
+		}
+		for _, handler := range handlers {
+			target := findGraphCell(vars, handler.Target)
+			if target == nil {
+				add(PROBLEM_INVALID_HANDLER, SEVERITY_ERROR, cell, "%s handler target %q does not exist", handler.Type, handler.Target)
+				continue
+			}
+			handlerTargets = append(handlerTargets, target)
+		}
+	}
+
+	// outgoing links grouped by source cell
+	outgoing := make(map[string][]*types.GraphCell)
+	for _, link := range links {
+		source, hasSource := cells[link.Source.Id]
+		_, hasTarget := cells[link.Target.Id]
+		if !hasSource {
+			add(PROBLEM_MISSING_LINK_SOURCE, SEVERITY_ERROR, nil, "link %s starts at missing cell %s", link.Id, link.Source.Id)
+			continue
+		}
+		if !hasTarget {
+			add(PROBLEM_MISSING_LINK_TARGET, SEVERITY_ERROR, source, "port %q links to missing cell %s", link.Source.Port, link.Target.Id)
+			continue
+		}
+		outgoing[link.Source.Id] = append(outgoing[link.Source.Id], link)
+	}
+
+	for _, cell := range order {
+		if !strings.HasPrefix(cell.Type, "devs.") {
+			continue
+		}
+		cellType, ok := LookupCellType(cell.Type)
+		if !ok {
+			add(PROBLEM_UNKNOWN_CELL_TYPE, SEVERITY_ERROR, cell, "unknown cell type %s", cell.Type)
+			continue
+		}
+		for _, port := range cellType.Ports {
+			if !port.Required {
+				continue
+			}
+			linked := false
+			for _, link := range outgoing[cell.Id] {
+				if link.Source.Port == port.Name {
+					linked = true
+					break
+				}
+			}
+			if !linked {
+				add(PROBLEM_MISSING_PORT_LINK, SEVERITY_ERROR, cell, "required port %q is not linked", port.Name)
+			}
+		}
+		if len(cellType.RequiredFields) == 0 {
+			continue
+		}
+		data, ok := models[cell.Id]
+		if !ok {
+			add(PROBLEM_MISSING_MODEL, SEVERITY_ERROR, cell, "cell has no model data")
+			continue
+		}
+		for _, field := range cellType.RequiredFields {
+			if isFieldMissing(data, field) {
+				add(PROBLEM_MISSING_FIELD, SEVERITY_ERROR, cell, "required field %q is missing", field)
+			}
+		}
+	}
+
+	// walk the graph from the launch cells, falling back to the first cell
+	// which is where execution starts when no launch cell exists
+	queue := make([]string, 0)
+	for _, cell := range order {
+		if cell.Type == "devs.LaunchModel" {
+			queue = append(queue, cell.Id)
+		}
+	}
+	if len(queue) == 0 {
+		queue = append(queue, order[0].Id)
+	}
+	if errorHandler != nil {
+		queue = append(queue, errorHandler.Id)
+	}
+	for _, target := range handlerTargets {
+		queue = append(queue, target.Id)
+	}
+	reached := make(map[string]bool)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if reached[id] {
+			continue
+		}
+		reached[id] = true
+		for _, link := range outgoing[id] {
+			queue = append(queue, link.Target.Id)
+		}
+	}
+	for _, cell := range order {
+		if !reached[cell.Id] {
+			add(PROBLEM_UNREACHABLE_CELL, SEVERITY_WARNING, cell, "cell can never be reached")
+		}
+	}
+	return problems
+}
@@ -0,0 +1,152 @@
+package mngrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/types"
+)
+
+func newTestLink(id string, source string, port string, target string) *types.GraphCell {
+	return &types.GraphCell{
+		Id:     id,
+		Type:   "devs.FlowLink",
+		Source: types.CellConnection{Id: source, Port: port},
+		Target: types.CellConnection{Id: target, Port: "In"}}
+}
+
+func newTestFlowVars(cells []*types.GraphCell, models []types.UnparsedModel) *types.FlowVars {
+	return &types.FlowVars{
+		Graph:  types.Graph{Cells: cells},
+		Models: models}
+}
+
+func problemCodes(problems FlowProblems) []string {
+	codes := make([]string, 0)
+	for _, problem := range problems {
+		codes = append(codes, problem.Code)
+	}
+	return codes
+}
+
+func TestValidateFlow(t *testing.T) {
+	t.Parallel()
+	launch := &types.GraphCell{Id: "1", Name: "Launch", Type: "devs.LaunchModel"}
+	wait := &types.GraphCell{Id: "2", Name: "Wait1", Type: "devs.WaitModel"}
+	waitModel := types.UnparsedModel{Id: "2", Name: "Wait1", Data: map[string]interface{}{"wait_seconds": "5"}}
+
+	type want struct {
+		codes []string
+		fails bool
+	}
+	tests := []struct {
+		name string
+		vars *types.FlowVars
+		want want
+	}{
+		{
+			name: "OK",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait, newTestLink("l1", "1", "Incoming Call", "2")},
+				[]types.UnparsedModel{waitModel}),
+			want: want{codes: []string{}, fails: false},
+		},
+		{
+			name: "EmptyFlow",
+			vars: newTestFlowVars([]*types.GraphCell{}, nil),
+			want: want{codes: []string{PROBLEM_EMPTY_FLOW}, fails: true},
+		},
+		{
+			name: "MissingLinkTarget",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait, newTestLink("l1", "1", "Incoming Call", "2"), newTestLink("l2", "2", "Completed", "99")},
+				[]types.UnparsedModel{waitModel}),
+			want: want{codes: []string{PROBLEM_MISSING_LINK_TARGET}, fails: true},
+		},
+		{
+			name: "UnknownCellType",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, {Id: "3", Name: "Fax1", Type: "devs.FaxModel"}, newTestLink("l1", "1", "Incoming Call", "3")},
+				nil),
+			want: want{codes: []string{PROBLEM_UNKNOWN_CELL_TYPE}, fails: true},
+		},
+		{
+			name: "RequiredPortNotLinked",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, {Id: "4", Name: "Dial1", Type: "devs.DialModel"}, newTestLink("l1", "1", "Incoming Call", "4")},
+				[]types.UnparsedModel{{Id: "4", Data: map[string]interface{}{"call_type": "Extension"}}}),
+			want: want{codes: []string{PROBLEM_MISSING_PORT_LINK}, fails: true},
+		},
+		{
+			name: "MissingField",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait, newTestLink("l1", "1", "Incoming Call", "2")},
+				[]types.UnparsedModel{{Id: "2", Data: map[string]interface{}{"wait_seconds": ""}}}),
+			want: want{codes: []string{PROBLEM_MISSING_FIELD}, fails: true},
+		},
+		{
+			name: "UnreachableCell",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait},
+				[]types.UnparsedModel{waitModel}),
+			want: want{codes: []string{PROBLEM_UNREACHABLE_CELL}, fails: false},
+		},
+		{
+			name: "ErrorHandlerIsReachable",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait},
+				[]types.UnparsedModel{{Id: "1", Data: map[string]interface{}{"on_error": "Wait1"}}, waitModel}),
+			want: want{codes: []string{}, fails: false},
+		},
+		{
+			name: "UnknownErrorHandler",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait, newTestLink("l1", "1", "Incoming Call", "2")},
+				[]types.UnparsedModel{{Id: "1", Data: map[string]interface{}{"on_error": "Oops"}}, waitModel}),
+			want: want{codes: []string{PROBLEM_UNKNOWN_ERROR_CELL}, fails: true},
+		},
+		{
+			name: "HandlerTargetIsReachable",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait},
+				[]types.UnparsedModel{{Id: "1", Data: map[string]interface{}{"handlers": []interface{}{
+					map[string]interface{}{"type": "dtmf", "digits": "0", "target": "Wait1"}}}}, waitModel}),
+			want: want{codes: []string{}, fails: false},
+		},
+		{
+			name: "InvalidHandler",
+			vars: newTestFlowVars(
+				[]*types.GraphCell{launch, wait, newTestLink("l1", "1", "Incoming Call", "2")},
+				[]types.UnparsedModel{{Id: "1", Data: map[string]interface{}{"handlers": []interface{}{
+					map[string]interface{}{"type": "silence", "seconds": 0, "target": "Wait1"}}}}, waitModel}),
+			want: want{codes: []string{PROBLEM_INVALID_HANDLER}, fails: true},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			problems := ValidateFlow(tt.vars)
+			require.Equal(t, tt.want.codes, problemCodes(problems))
+			require.Equal(t, tt.want.fails, problems.Err() != nil)
+		})
+	}
+}
+
+func TestValidateFlowRegisteredCell(t *testing.T) {
+	Register("devs.RegistryTestModel", func(mngrCtx *types.Context, flow *types.Flow) BaseManager {
+		return NewWaitManager(mngrCtx, flow)
+	}, CellMeta{
+		Ports:          []CellPort{{Name: "Matched", Required: true}},
+		RequiredFields: []string{"table"},
+	})
+
+	launch := &types.GraphCell{Id: "1", Name: "Launch", Type: "devs.LaunchModel"}
+	custom := &types.GraphCell{Id: "2", Name: "Custom1", Type: "devs.RegistryTestModel"}
+	vars := newTestFlowVars(
+		[]*types.GraphCell{launch, custom, newTestLink("l1", "1", "Incoming Call", "2")},
+		[]types.UnparsedModel{{Id: "2", Data: map[string]interface{}{}}})
+
+	problems := ValidateFlow(vars)
+	require.Equal(t, []string{PROBLEM_MISSING_PORT_LINK, PROBLEM_MISSING_FIELD}, problemCodes(problems))
+}
@@ -4,7 +4,6 @@ import (
 	//"context"
 	"time"
 	//"github.com/CyCoreSystems/ari/v5"
-	"strconv"
 
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/sirupsen/logrus"
@@ -12,6 +11,18 @@ import (
 	"lineblocs.com/processor/utils"
 )
 
+// WaitConfig is the config of Wait cells.
+type WaitConfig struct {
+	Seconds int `cell:"wait_seconds,required"`
+}
+
+func (conf *WaitConfig) Validate() error {
+	if conf.Seconds < 0 {
+		return &CellConfigError{Field: "wait_seconds", Message: "can not be negative"}
+	}
+	return nil
+}
+
 type WaitManager struct {
 	ManagerContext *types.Context
 	Flow           *types.Flow
@@ -25,23 +36,33 @@ func NewWaitManager(mngrCtx *types.Context, flow *types.Flow) *WaitManager {
 	return &item
 }
 func (man *WaitManager) StartProcessing() {
+	goCell(man.ManagerContext, man.wait)
+}
+
+func (man *WaitManager) wait() {
 	helpers.Log(logrus.DebugLevel, "starting WAIT...")
 	//man.ManagerContext.RecvChannel <- *item
 
 	ctx := man.ManagerContext
 	cell := ctx.Cell
 	channel := ctx.Channel
-	model := cell.Model
 	completed, _ := utils.FindLinkByName(cell.SourceLinks, "source", "Completed")
 
-	val, err := strconv.Atoi(model.Data["wait_seconds"].(types.ModelDataStr).Value)
-	if err != nil {
-		helpers.Log(logrus.DebugLevel, "could not parse wait timeout of: "+model.Data["wait_seconds"].(types.ModelDataStr).Value)
-		man.ManagerContext.RecvChannel <- nil
+	var conf WaitConfig
+	if err := loadConfig(ctx, &conf); err != nil {
+		helpers.Log(logrus.DebugLevel, "invalid wait cell: "+err.Error())
+		failCell(ctx, err)
 		return
 	}
 
-	time.Sleep(time.Duration(val) * time.Second)
+	timer := time.NewTimer(time.Duration(conf.Seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-ctx.Context.Done():
+		helpers.Log(logrus.DebugLevel, "WAIT cancelled")
+		return
+	case <-timer.C:
+	}
 
 	resp := types.ManagerResponse{
 		Channel: channel,
@@ -0,0 +1,86 @@
+package sim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+)
+
+// fakeAPI answers the internals API requests a flow makes. Calls,
+// recordings and conferences get increasing ids and every other request
+// succeeds with an empty object. Completed recordings are recorded as events
+// of the client.
+type fakeAPI struct {
+	mu     sync.Mutex
+	nextId int
+	server *httptest.Server
+	client *Client
+}
+
+func newFakeAPI(callerId string, client *Client) *fakeAPI {
+	fake := &fakeAPI{nextId: 1000, client: client}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/call/createCall", fake.withId("x-call-id"))
+	mux.HandleFunc("/recording/createRecording", func(w http.ResponseWriter, r *http.Request) {
+		fake.saveRecording(r)
+		fake.withId("x-recording-id")(w, r)
+	})
+	mux.HandleFunc("/conference/createConference", fake.withId("x-conference-id"))
+	mux.HandleFunc("/user/verifyCaller", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"valid": true})
+	})
+	mux.HandleFunc("/user/getCallerIdToUse", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"caller_id": callerId})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{})
+	})
+	fake.server = httptest.NewServer(mux)
+	return fake
+}
+
+func (fake *fakeAPI) withId(header string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		fake.nextId++
+		id := fake.nextId
+		fake.mu.Unlock()
+		w.Header().Set(header, strconv.Itoa(id))
+		writeJSON(w, map[string]interface{}{})
+	}
+}
+
+func (fake *fakeAPI) saveRecording(r *http.Request) {
+	var params struct {
+		Status    string `json:"status"`
+		Tag       string `json:"tag"`
+		StorageId string `json:"storage_id"`
+		MailboxId string `json:"mailbox_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Status != "completed" {
+		return
+	}
+	detail := "saved " + params.StorageId
+	if params.Tag != "" {
+		detail += " as " + params.Tag
+	}
+	if params.MailboxId != "" {
+		detail += " in mailbox " + params.MailboxId
+	}
+	fake.client.record(EVENT_RECORD, "", detail)
+}
+
+func (fake *fakeAPI) URL() string {
+	return fake.server.URL
+}
+
+func (fake *fakeAPI) Close() {
+	fake.server.Close()
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,552 @@
+package sim
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"github.com/CyCoreSystems/ari/v5/rid"
+	"github.com/CyCoreSystems/ari/v5/stdbus"
+)
+
+const (
+	EVENT_PROMPT  = "prompt"
+	EVENT_DTMF    = "dtmf"
+	EVENT_DIAL    = "dial"
+	EVENT_ANSWER  = "answer"
+	EVENT_HANGUP  = "hangup"
+	EVENT_BRIDGE  = "bridge"
+	EVENT_RECORD  = "record"
+	EVENT_SPEECH  = "speech"
+	EVENT_RINGING = "ringing"
+	EVENT_MOH     = "moh"
+	EVENT_MUTE    = "mute"
+	EVENT_UNMUTE  = "unmute"
+)
+
+// Event is something that happened on a simulated channel or bridge. The
+// channel is "caller" for the caller and the dialed number for other
+// channels.
+type Event struct {
+	At      time.Duration `json:"at"`
+	Kind    string        `json:"kind"`
+	Channel string        `json:"channel,omitempty"`
+	Detail  string        `json:"detail,omitempty"`
+}
+
+type channelState struct {
+	id       string
+	endpoint string
+	number   string
+	caller   bool
+	answered bool
+	hungUp   bool
+	bridge   string
+}
+
+type recordingState struct {
+	name      string
+	key       *ari.Key
+	target    string
+	started   time.Time
+	terminate string
+	// maxSilence is how long after the caller stops speaking the recording
+	// finishes, speech is what they said.
+	maxSilence time.Duration
+	speech     string
+	done       bool
+}
+
+type bridgeState struct {
+	id       string
+	channels []string
+}
+
+// Client is an in-memory ari.Client. Channels, bridges, playbacks and
+// recordings only exist in memory and their events are published on a
+// stdbus. The Application, Asterisk, DeviceState, Endpoint, Mailbox, Sound
+// and TextMessage namespaces are not simulated.
+type Client struct {
+	bus      ari.Bus
+	script   *Script
+	media    *Media
+	started  time.Time
+	mu       sync.Mutex
+	channels map[string]*channelState
+	bridges  map[string]*bridgeState
+	playing  map[string]int
+	plays    int
+	events   []Event
+	hangups  chan string
+	// recordings are the recordings by name. The caller talks for as long
+	// as a recording runs.
+	recordings map[string]*recordingState
+}
+
+func NewClient(script *Script, media *Media) *Client {
+	return &Client{
+		bus:      stdbus.New(),
+		script:   script,
+		media:    media,
+		started:  time.Now(),
+		channels: make(map[string]*channelState),
+		bridges:  make(map[string]*bridgeState),
+		playing:  make(map[string]int),
+		events:   make([]Event, 0),
+		hangups:  make(chan string, 100),
+
+		recordings: make(map[string]*recordingState)}
+}
+
+func (c *Client) record(kind string, channel string, detail string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state, ok := c.channels[channel]; ok {
+		channel = state.number
+		if state.caller {
+			channel = "caller"
+		}
+	}
+	c.events = append(c.events, Event{
+		At:      time.Since(c.started),
+		Kind:    kind,
+		Channel: channel,
+		Detail:  detail})
+}
+
+// Events returns the timeline of the simulation so far.
+func (c *Client) Events() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Event{}, c.events...)
+}
+
+// Hangups reports the id of every channel that is hung up.
+func (c *Client) Hangups() <-chan string {
+	return c.hangups
+}
+
+func (c *Client) eventData(eventType string) ari.EventData {
+	return ari.EventData{
+		Application: c.ApplicationName(),
+		Type:        eventType,
+		Timestamp:   ari.DateTime(time.Now())}
+}
+
+func (c *Client) channelData(id string) ari.ChannelData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := ari.ChannelData{
+		Key:   ari.NewKey(ari.ChannelKey, id),
+		ID:    id,
+		Name:  id,
+		State: "Down"}
+	if state, ok := c.channels[id]; ok {
+		data.Name = state.endpoint
+		if state.answered {
+			data.State = "Up"
+		}
+		data.Caller = &ari.CallerID{Number: state.number}
+	}
+	return data
+}
+
+func (c *Client) addChannel(id string, endpoint string) *channelState {
+	if id == "" {
+		id = rid.New(rid.Channel)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.channels[id]
+	if !ok {
+		state = &channelState{id: id, endpoint: endpoint, number: numberFromEndpoint(endpoint)}
+		c.channels[id] = state
+	}
+	return state
+}
+
+// numberFromEndpoint returns the number of endpoints such as
+// "SIP/1001@proxy" or "SIP/1001/proxy".
+func numberFromEndpoint(endpoint string) string {
+	number := endpoint
+	if idx := strings.Index(number, "/"); idx != -1 {
+		number = number[idx+1:]
+	}
+	if idx := strings.IndexAny(number, "@/"); idx != -1 {
+		number = number[:idx]
+	}
+	return number
+}
+
+// NewCaller creates the channel of the simulated caller. It is answered once
+// the flow answers it.
+func (c *Client) NewCaller(from string) *ari.ChannelHandle {
+	state := c.addChannel("", "SIP/"+from)
+	c.mu.Lock()
+	state.caller = true
+	c.mu.Unlock()
+	return c.Channel().Get(ari.NewKey(ari.ChannelKey, state.id))
+}
+
+// SendDTMF simulates the caller pressing a key.
+func (c *Client) SendDTMF(channelId string, digit string) {
+	c.record(EVENT_DTMF, channelId, digit)
+	c.bus.Send(&ari.ChannelDtmfReceived{
+		EventData:  c.eventData(ari.Events.ChannelDtmfReceived),
+		Channel:    c.channelData(channelId),
+		Digit:      digit,
+		DurationMs: 100})
+	for _, name := range c.channelRecordings(channelId) {
+		c.mu.Lock()
+		terminate := c.recordings[name].terminate
+		c.mu.Unlock()
+		if terminate == "any" || terminate == digit {
+			c.finishRecording(name, "finished "+name+" on "+digit)
+		}
+	}
+}
+
+// Say simulates the caller speaking. What they say is heard by the
+// recordings of the channel, which finish once they were silent for the
+// silence of the recording.
+func (c *Client) Say(channelId string, text string) {
+	c.record(EVENT_SPEECH, channelId, text)
+	for _, name := range c.channelRecordings(channelId) {
+		c.mu.Lock()
+		recording := c.recordings[name]
+		if recording.speech != "" {
+			recording.speech += " "
+		}
+		recording.speech += text
+		silence := recording.maxSilence
+		c.mu.Unlock()
+		if silence > 0 {
+			name := name
+			time.AfterFunc(silence, func() {
+				c.finishRecording(name, "finished "+name+" on silence")
+			})
+		}
+	}
+}
+
+// scrapRecording ends a recording without finishing it.
+func (c *Client) scrapRecording(name string) {
+	c.mu.Lock()
+	recording, ok := c.recordings[name]
+	if !ok || recording.done {
+		c.mu.Unlock()
+		return
+	}
+	recording.done = true
+	c.mu.Unlock()
+	c.record(EVENT_RECORD, recording.target, "scrapped "+name)
+}
+
+// recordedSpeech returns what the caller said during a recording.
+func (c *Client) recordedSpeech(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	recording, ok := c.recordings[name]
+	if !ok {
+		return "", false
+	}
+	return recording.speech, true
+}
+
+// Hangup ends a channel as if the other party hung up.
+func (c *Client) Hangup(channelId string, reason string) {
+	c.mu.Lock()
+	state, ok := c.channels[channelId]
+	if !ok || state.hungUp {
+		c.mu.Unlock()
+		return
+	}
+	state.hungUp = true
+	bridgeId := state.bridge
+	c.mu.Unlock()
+
+	c.record(EVENT_HANGUP, channelId, reason)
+	data := c.channelData(channelId)
+	if bridgeId != "" {
+		c.removeFromBridge(bridgeId, channelId)
+	}
+	c.bus.Send(&ari.ChannelHangupRequest{EventData: c.eventData(ari.Events.ChannelHangupRequest), Channel: data})
+	c.bus.Send(&ari.StasisEnd{EventData: c.eventData(ari.Events.StasisEnd), Channel: data})
+	c.bus.Send(&ari.ChannelDestroyed{EventData: c.eventData(ari.Events.ChannelDestroyed), Channel: data, CauseTxt: reason})
+	for _, name := range c.channelRecordings(channelId) {
+		c.finishRecording(name, "finished "+name+" on hangup")
+	}
+	c.hangups <- channelId
+}
+
+// answer simulates a dialed party answering the call.
+func (c *Client) answer(channelId string) {
+	c.mu.Lock()
+	state, ok := c.channels[channelId]
+	if !ok || state.hungUp {
+		c.mu.Unlock()
+		return
+	}
+	state.answered = true
+	c.mu.Unlock()
+	c.record(EVENT_ANSWER, channelId, "")
+	c.bus.Send(&ari.StasisStart{EventData: c.eventData(ari.Events.StasisStart), Channel: c.channelData(channelId)})
+}
+
+// dial applies the behaviour the script sets for the dialed number.
+func (c *Client) dial(channelId string) {
+	c.mu.Lock()
+	state := c.channels[channelId]
+	c.mu.Unlock()
+	behaviour := c.script.DialBehaviour(state.number)
+	c.record(EVENT_DIAL, channelId, behaviour)
+
+	switch behaviour {
+	case DIAL_ANSWER:
+		time.AfterFunc(c.script.answerDelay(), func() {
+			c.answer(channelId)
+		})
+	case DIAL_BUSY:
+		time.AfterFunc(c.script.answerDelay(), func() {
+			c.Hangup(channelId, "busy")
+		})
+	case DIAL_CONFIRM:
+		time.AfterFunc(c.script.answerDelay(), func() {
+			c.answer(channelId)
+			time.AfterFunc(c.script.answerDelay(), func() {
+				c.SendDTMF(channelId, "1")
+			})
+		})
+	}
+}
+
+// play starts a prompt. The flow managers reuse the channel id as the
+// playback id, so every prompt gets a generation that its timer must still
+// hold when it fires.
+func (c *Client) play(target string, key *ari.Key, id string, uri string) *ari.PlaybackHandle {
+	if id == "" {
+		id = rid.New(rid.Playback)
+	}
+	if key == nil {
+		key = ari.NewKey(ari.PlaybackKey, id)
+	} else {
+		key = key.New(ari.PlaybackKey, id)
+	}
+	c.record(EVENT_PROMPT, target, c.media.Describe(uri))
+	c.mu.Lock()
+	c.plays++
+	generation := c.plays
+	c.playing[id] = generation
+	c.mu.Unlock()
+	time.AfterFunc(c.script.promptDuration(), func() {
+		c.finishPlayback(id, generation)
+	})
+	return ari.NewPlaybackHandle(key, &playback{client: c}, nil)
+}
+
+func (c *Client) stopPlayback(id string) {
+	c.mu.Lock()
+	generation, ok := c.playing[id]
+	c.mu.Unlock()
+	if ok {
+		c.finishPlayback(id, generation)
+	}
+}
+
+func (c *Client) finishPlayback(id string, generation int) {
+	c.mu.Lock()
+	if c.playing[id] != generation {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.playing, id)
+	c.mu.Unlock()
+	c.bus.Send(&ari.PlaybackFinished{
+		EventData: c.eventData(ari.Events.PlaybackFinished),
+		Playback:  ari.PlaybackData{ID: id, State: "done"}})
+}
+
+func (c *Client) addToBridge(bridgeId string, channelId string) {
+	c.mu.Lock()
+	bridge, ok := c.bridges[bridgeId]
+	if !ok {
+		bridge = &bridgeState{id: bridgeId}
+		c.bridges[bridgeId] = bridge
+	}
+	bridge.channels = append(bridge.channels, channelId)
+	if state, ok := c.channels[channelId]; ok {
+		state.bridge = bridgeId
+	}
+	c.mu.Unlock()
+	c.record(EVENT_BRIDGE, channelId, "entered "+bridgeId)
+	c.bus.Send(&ari.ChannelEnteredBridge{
+		EventData: c.eventData(ari.Events.ChannelEnteredBridge),
+		Bridge:    c.bridgeData(bridgeId),
+		Channel:   c.channelData(channelId)})
+}
+
+func (c *Client) removeFromBridge(bridgeId string, channelId string) {
+	c.mu.Lock()
+	bridge, ok := c.bridges[bridgeId]
+	if ok {
+		for i, id := range bridge.channels {
+			if id == channelId {
+				bridge.channels = append(bridge.channels[:i], bridge.channels[i+1:]...)
+				break
+			}
+		}
+	}
+	if state, ok := c.channels[channelId]; ok {
+		state.bridge = ""
+	}
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	c.record(EVENT_BRIDGE, channelId, "left "+bridgeId)
+	c.bus.Send(&ari.ChannelLeftBridge{
+		EventData: c.eventData(ari.Events.ChannelLeftBridge),
+		Bridge:    c.bridgeData(bridgeId),
+		Channel:   c.channelData(channelId)})
+}
+
+func (c *Client) bridgeData(id string) ari.BridgeData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := ari.BridgeData{Key: ari.NewKey(ari.BridgeKey, id), ID: id, Type: "mixing"}
+	if bridge, ok := c.bridges[id]; ok {
+		data.ChannelIDs = append([]string{}, bridge.channels...)
+	}
+	return data
+}
+
+// startRecording starts a recording of a channel or bridge, which runs
+// until it is stopped, reaches its maximum duration or, for channels, the
+// terminating key is pressed or the channel hangs up.
+func (c *Client) startRecording(target string, key *ari.Key, name string, opts *ari.RecordingOptions) *ari.LiveRecordingHandle {
+	if key == nil {
+		key = ari.NewKey(ari.LiveRecordingKey, name)
+	} else {
+		key = key.New(ari.LiveRecordingKey, name)
+	}
+	if opts == nil {
+		opts = &ari.RecordingOptions{}
+	}
+	if opts.Beep {
+		c.record(EVENT_PROMPT, target, "beep")
+	}
+	c.record(EVENT_RECORD, target, "started "+name)
+	c.mu.Lock()
+	c.recordings[name] = &recordingState{name: name, key: key, target: target, started: time.Now(), terminate: opts.Terminate, maxSilence: opts.MaxSilence}
+	c.mu.Unlock()
+	if opts.MaxDuration > 0 {
+		time.AfterFunc(opts.MaxDuration, func() {
+			c.finishRecording(name, "finished "+name+" at max duration")
+		})
+	}
+	return ari.NewLiveRecordingHandle(key, &liveRecording{client: c}, nil)
+}
+
+// channelRecordings returns the names of the recordings running on a
+// channel.
+func (c *Client) channelRecordings(channelId string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0)
+	for name, recording := range c.recordings {
+		if recording.target == channelId && !recording.done {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Client) finishRecording(name string, detail string) {
+	c.mu.Lock()
+	recording, ok := c.recordings[name]
+	if !ok || recording.done {
+		c.mu.Unlock()
+		return
+	}
+	recording.done = true
+	duration := ari.DurationSec(time.Since(recording.started).Truncate(time.Second))
+	c.mu.Unlock()
+	c.record(EVENT_RECORD, recording.target, detail)
+	c.bus.Send(&ari.RecordingFinished{
+		EventData: c.eventData(ari.Events.RecordingFinished),
+		Recording: ari.LiveRecordingData{
+			Key:      recording.key,
+			Name:     name,
+			State:    "done",
+			Format:   "wav",
+			Duration: duration,
+			Talking:  duration}})
+}
+
+func (c *Client) ApplicationName() string {
+	return "lineblocs"
+}
+
+func (c *Client) Bus() ari.Bus {
+	return c.bus
+}
+
+func (c *Client) Connected() bool {
+	return true
+}
+
+func (c *Client) Close() {
+	c.bus.Close()
+}
+
+func (c *Client) Application() ari.Application {
+	return nil
+}
+
+func (c *Client) Asterisk() ari.Asterisk {
+	return nil
+}
+
+func (c *Client) Bridge() ari.Bridge {
+	return &bridge{client: c}
+}
+
+func (c *Client) Channel() ari.Channel {
+	return &channel{client: c}
+}
+
+func (c *Client) DeviceState() ari.DeviceState {
+	return nil
+}
+
+func (c *Client) Endpoint() ari.Endpoint {
+	return nil
+}
+
+func (c *Client) LiveRecording() ari.LiveRecording {
+	return &liveRecording{client: c}
+}
+
+func (c *Client) Mailbox() ari.Mailbox {
+	return nil
+}
+
+func (c *Client) Playback() ari.Playback {
+	return &playback{client: c}
+}
+
+func (c *Client) Sound() ari.Sound {
+	return nil
+}
+
+func (c *Client) StoredRecording() ari.StoredRecording {
+	return &storedRecording{client: c}
+}
+
+func (c *Client) TextMessage() ari.TextMessage {
+	return nil
+}
+
+var errNotSimulated = errors.New("not supported by the simulator")
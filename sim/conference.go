@@ -0,0 +1,106 @@
+package sim
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+	"lineblocs.com/processor/mngrs"
+	"lineblocs.com/processor/types"
+)
+
+// conferenceStore keeps the conferences of a simulation in memory.
+type conferenceStore struct {
+	mu          sync.Mutex
+	conferences map[string]*types.LineConference
+	members     map[string]map[string]string
+	claims      map[string]time.Time
+}
+
+func newConferenceStore() *conferenceStore {
+	return &conferenceStore{
+		conferences: make(map[string]*types.LineConference),
+		members:     make(map[string]map[string]string),
+		claims:      make(map[string]time.Time)}
+}
+
+func (store *conferenceStore) moderators(key string) int {
+	moderators := 0
+	for _, role := range store.members[key] {
+		if role == mngrs.CONFERENCE_MODERATOR {
+			moderators++
+		}
+	}
+	return moderators
+}
+
+func (store *conferenceStore) Get(client ari.Client, user *types.User, name string) (*types.LineConference, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := queueName(user.Workspace.Id, name)
+	conf, ok := store.conferences[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *conf
+	copied.ModeratorInConf = store.moderators(key) > 0
+	return &copied, nil
+}
+
+func (store *conferenceStore) Claim(workspaceId int, name string, ttl time.Duration) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := queueName(workspaceId, name)
+	if expires, ok := store.claims[key]; ok && time.Now().Before(expires) {
+		return false, nil
+	}
+	store.claims[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (store *conferenceStore) Add(client ari.Client, user *types.User, name string, conf *types.LineConference) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	copied := *conf
+	store.conferences[queueName(user.Workspace.Id, name)] = &copied
+	delete(store.claims, queueName(user.Workspace.Id, name))
+	return nil
+}
+
+func (store *conferenceStore) Remove(workspaceId int, name string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.conferences, queueName(workspaceId, name))
+	delete(store.members, queueName(workspaceId, name))
+	return nil
+}
+
+func (store *conferenceStore) Join(workspaceId int, name string, channelId string, role string, maxMembers int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := queueName(workspaceId, name)
+	members, ok := store.members[key]
+	if !ok {
+		members = make(map[string]string)
+		store.members[key] = members
+	}
+	if _, joined := members[channelId]; !joined && maxMembers > 0 && len(members) >= maxMembers {
+		return mngrs.ErrConferenceFull
+	}
+	members[channelId] = role
+	return nil
+}
+
+func (store *conferenceStore) Leave(workspaceId int, name string, channelId string) (int, int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := queueName(workspaceId, name)
+	delete(store.members[key], channelId)
+	return len(store.members[key]), store.moderators(key), nil
+}
+
+func (store *conferenceStore) Moderators(workspaceId int, name string) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.moderators(queueName(workspaceId, name)), nil
+}
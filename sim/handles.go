@@ -0,0 +1,401 @@
+package sim
+
+import (
+	"errors"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+)
+
+// channel simulates the channel namespace.
+type channel struct {
+	client *Client
+}
+
+func (ch *channel) Get(key *ari.Key) *ari.ChannelHandle {
+	return ari.NewChannelHandle(key, ch, nil)
+}
+
+func (ch *channel) GetVariable(key *ari.Key, name string) (string, error) {
+	return "", nil
+}
+
+func (ch *channel) List(filter *ari.Key) ([]*ari.Key, error) {
+	ch.client.mu.Lock()
+	defer ch.client.mu.Unlock()
+	keys := make([]*ari.Key, 0, len(ch.client.channels))
+	for id := range ch.client.channels {
+		keys = append(keys, ari.NewKey(ari.ChannelKey, id))
+	}
+	return keys, nil
+}
+
+// Originate dials the endpoint of the channel. Whether the call is answered is
+// decided by the script.
+func (ch *channel) Originate(key *ari.Key, req ari.OriginateRequest) (*ari.ChannelHandle, error) {
+	id := req.ChannelID
+	if key != nil && key.ID != "" {
+		id = key.ID
+	}
+	state := ch.client.addChannel(id, req.Endpoint)
+	ch.client.dial(state.id)
+	return ch.Get(ari.NewKey(ari.ChannelKey, state.id)), nil
+}
+
+func (ch *channel) StageOriginate(key *ari.Key, req ari.OriginateRequest) (*ari.ChannelHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) Create(key *ari.Key, req ari.ChannelCreateRequest) (*ari.ChannelHandle, error) {
+	id := req.ChannelID
+	if key != nil && key.ID != "" {
+		id = key.ID
+	}
+	state := ch.client.addChannel(id, req.Endpoint)
+	return ch.Get(ari.NewKey(ari.ChannelKey, state.id)), nil
+}
+
+func (ch *channel) Data(key *ari.Key) (*ari.ChannelData, error) {
+	data := ch.client.channelData(key.ID)
+	return &data, nil
+}
+
+func (ch *channel) Continue(key *ari.Key, context, extension string, priority int) error {
+	return nil
+}
+
+func (ch *channel) Busy(key *ari.Key) error {
+	ch.client.Hangup(key.ID, "busy")
+	return nil
+}
+
+func (ch *channel) Congestion(key *ari.Key) error {
+	ch.client.Hangup(key.ID, "congestion")
+	return nil
+}
+
+func (ch *channel) Answer(key *ari.Key) error {
+	ch.client.mu.Lock()
+	defer ch.client.mu.Unlock()
+	if state, ok := ch.client.channels[key.ID]; ok {
+		state.answered = true
+	}
+	return nil
+}
+
+func (ch *channel) Hangup(key *ari.Key, reason string) error {
+	ch.client.Hangup(key.ID, reason)
+	return nil
+}
+
+func (ch *channel) Ring(key *ari.Key) error {
+	ch.client.record(EVENT_RINGING, key.ID, "")
+	return nil
+}
+
+func (ch *channel) StopRing(key *ari.Key) error {
+	return nil
+}
+
+func (ch *channel) SendDTMF(key *ari.Key, dtmf string, opts *ari.DTMFOptions) error {
+	return nil
+}
+
+func (ch *channel) Hold(key *ari.Key) error {
+	return nil
+}
+
+func (ch *channel) StopHold(key *ari.Key) error {
+	return nil
+}
+
+func (ch *channel) Mute(key *ari.Key, dir ari.Direction) error {
+	ch.client.record(EVENT_MUTE, key.ID, string(dir))
+	return nil
+}
+
+func (ch *channel) Unmute(key *ari.Key, dir ari.Direction) error {
+	ch.client.record(EVENT_UNMUTE, key.ID, string(dir))
+	return nil
+}
+
+func (ch *channel) MOH(key *ari.Key, moh string) error {
+	ch.client.record(EVENT_MOH, key.ID, moh)
+	return nil
+}
+
+func (ch *channel) SetVariable(key *ari.Key, name, value string) error {
+	return nil
+}
+
+func (ch *channel) StopMOH(key *ari.Key) error {
+	return nil
+}
+
+func (ch *channel) Silence(key *ari.Key) error {
+	return nil
+}
+
+func (ch *channel) StopSilence(key *ari.Key) error {
+	return nil
+}
+
+func (ch *channel) Play(key *ari.Key, playbackID string, mediaURI string) (*ari.PlaybackHandle, error) {
+	return ch.client.play(key.ID, key, playbackID, mediaURI), nil
+}
+
+func (ch *channel) StagePlay(key *ari.Key, playbackID string, mediaURI string) (*ari.PlaybackHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) Record(key *ari.Key, name string, opts *ari.RecordingOptions) (*ari.LiveRecordingHandle, error) {
+	return ch.client.startRecording(key.ID, key, name, opts), nil
+}
+
+func (ch *channel) StageRecord(key *ari.Key, name string, opts *ari.RecordingOptions) (*ari.LiveRecordingHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) Dial(key *ari.Key, caller string, timeout time.Duration) error {
+	ch.client.dial(key.ID)
+	return nil
+}
+
+func (ch *channel) Snoop(key *ari.Key, snoopID string, opts *ari.SnoopOptions) (*ari.ChannelHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) StageSnoop(key *ari.Key, snoopID string, opts *ari.SnoopOptions) (*ari.ChannelHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) StageExternalMedia(key *ari.Key, opts ari.ExternalMediaOptions) (*ari.ChannelHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) ExternalMedia(key *ari.Key, opts ari.ExternalMediaOptions) (*ari.ChannelHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (ch *channel) Subscribe(key *ari.Key, n ...string) ari.Subscription {
+	return ch.client.bus.Subscribe(key, n...)
+}
+
+func (ch *channel) Unsubscribe(key *ari.Key, n ...string) {
+	ch.client.bus.Unsubscribe(key, n...)
+}
+
+// bridge simulates the bridge namespace.
+type bridge struct {
+	client *Client
+}
+
+func (br *bridge) Create(key *ari.Key, btype string, name string) (*ari.BridgeHandle, error) {
+	br.client.mu.Lock()
+	if _, ok := br.client.bridges[key.ID]; !ok {
+		br.client.bridges[key.ID] = &bridgeState{id: key.ID}
+	}
+	br.client.mu.Unlock()
+	return br.Get(key), nil
+}
+
+func (br *bridge) StageCreate(key *ari.Key, btype string, name string) (*ari.BridgeHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (br *bridge) Get(key *ari.Key) *ari.BridgeHandle {
+	return ari.NewBridgeHandle(key, br, nil)
+}
+
+func (br *bridge) List(filter *ari.Key) ([]*ari.Key, error) {
+	br.client.mu.Lock()
+	defer br.client.mu.Unlock()
+	keys := make([]*ari.Key, 0, len(br.client.bridges))
+	for id := range br.client.bridges {
+		keys = append(keys, ari.NewKey(ari.BridgeKey, id))
+	}
+	return keys, nil
+}
+
+func (br *bridge) Data(key *ari.Key) (*ari.BridgeData, error) {
+	data := br.client.bridgeData(key.ID)
+	return &data, nil
+}
+
+func (br *bridge) AddChannel(key *ari.Key, channelID string) error {
+	br.client.addToBridge(key.ID, channelID)
+	return nil
+}
+
+func (br *bridge) AddChannelWithOptions(key *ari.Key, channelID string, options *ari.BridgeAddChannelOptions) error {
+	return br.AddChannel(key, channelID)
+}
+
+func (br *bridge) RemoveChannel(key *ari.Key, channelID string) error {
+	br.client.removeFromBridge(key.ID, channelID)
+	return nil
+}
+
+func (br *bridge) Delete(key *ari.Key) error {
+	br.client.mu.Lock()
+	_, ok := br.client.bridges[key.ID]
+	br.client.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	data := br.client.bridgeData(key.ID)
+	br.client.mu.Lock()
+	delete(br.client.bridges, key.ID)
+	br.client.mu.Unlock()
+	br.client.bus.Send(&ari.BridgeDestroyed{
+		EventData: br.client.eventData(ari.Events.BridgeDestroyed),
+		Bridge:    data})
+	return nil
+}
+
+func (br *bridge) MOH(key *ari.Key, moh string) error {
+	br.client.record(EVENT_MOH, key.ID, moh)
+	return nil
+}
+
+func (br *bridge) StopMOH(key *ari.Key) error {
+	return nil
+}
+
+func (br *bridge) Play(key *ari.Key, playbackID string, mediaURI string) (*ari.PlaybackHandle, error) {
+	return br.client.play(key.ID, key, playbackID, mediaURI), nil
+}
+
+func (br *bridge) StagePlay(key *ari.Key, playbackID string, mediaURI string) (*ari.PlaybackHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (br *bridge) Record(key *ari.Key, name string, opts *ari.RecordingOptions) (*ari.LiveRecordingHandle, error) {
+	return br.client.startRecording(key.ID, key, name, opts), nil
+}
+
+func (br *bridge) StageRecord(key *ari.Key, name string, opts *ari.RecordingOptions) (*ari.LiveRecordingHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (br *bridge) Subscribe(key *ari.Key, n ...string) ari.Subscription {
+	return br.client.bus.Subscribe(key, n...)
+}
+
+func (br *bridge) VideoSource(key *ari.Key, channelID string) error {
+	return nil
+}
+
+func (br *bridge) VideoSourceDelete(key *ari.Key) error {
+	return nil
+}
+
+// playback simulates the playback namespace. Playbacks finish after the
+// prompt duration of the script or when they are stopped.
+type playback struct {
+	client *Client
+}
+
+func (pb *playback) Get(key *ari.Key) *ari.PlaybackHandle {
+	return ari.NewPlaybackHandle(key, pb, nil)
+}
+
+func (pb *playback) Data(key *ari.Key) (*ari.PlaybackData, error) {
+	return &ari.PlaybackData{Key: key, ID: key.ID, State: "playing"}, nil
+}
+
+func (pb *playback) Control(key *ari.Key, op string) error {
+	return nil
+}
+
+func (pb *playback) Stop(key *ari.Key) error {
+	pb.client.stopPlayback(key.ID)
+	return nil
+}
+
+func (pb *playback) Subscribe(key *ari.Key, n ...string) ari.Subscription {
+	return pb.client.bus.Subscribe(key, n...)
+}
+
+// liveRecording simulates the live recording namespace.
+type liveRecording struct {
+	client *Client
+}
+
+func (lr *liveRecording) Get(key *ari.Key) *ari.LiveRecordingHandle {
+	return ari.NewLiveRecordingHandle(key, lr, nil)
+}
+
+func (lr *liveRecording) Data(key *ari.Key) (*ari.LiveRecordingData, error) {
+	return &ari.LiveRecordingData{Key: key, Name: key.ID, State: "recording"}, nil
+}
+
+func (lr *liveRecording) Stop(key *ari.Key) error {
+	lr.client.finishRecording(key.ID, "stopped "+key.ID)
+	return nil
+}
+
+func (lr *liveRecording) Pause(key *ari.Key) error {
+	return nil
+}
+
+func (lr *liveRecording) Resume(key *ari.Key) error {
+	return nil
+}
+
+func (lr *liveRecording) Mute(key *ari.Key) error {
+	return nil
+}
+
+func (lr *liveRecording) Unmute(key *ari.Key) error {
+	return nil
+}
+
+func (lr *liveRecording) Scrap(key *ari.Key) error {
+	lr.client.scrapRecording(key.ID)
+	return nil
+}
+
+func (lr *liveRecording) Stored(key *ari.Key) *ari.StoredRecordingHandle {
+	return lr.client.StoredRecording().Get(key.New(ari.StoredRecordingKey, key.ID))
+}
+
+func (lr *liveRecording) Subscribe(key *ari.Key, n ...string) ari.Subscription {
+	return lr.client.bus.Subscribe(key, n...)
+}
+
+// storedRecording simulates the stored recording namespace. Recordings are
+// not kept, only their deletion is recorded. Their file is what the caller
+// said during the recording.
+type storedRecording struct {
+	client *Client
+}
+
+func (sr *storedRecording) List(filter *ari.Key) ([]*ari.Key, error) {
+	return []*ari.Key{}, nil
+}
+
+func (sr *storedRecording) Get(key *ari.Key) *ari.StoredRecordingHandle {
+	return ari.NewStoredRecordingHandle(key, sr, nil)
+}
+
+func (sr *storedRecording) Data(key *ari.Key) (*ari.StoredRecordingData, error) {
+	return &ari.StoredRecordingData{Key: key, Name: key.ID, Format: "wav"}, nil
+}
+
+func (sr *storedRecording) Copy(key *ari.Key, dest string) (*ari.StoredRecordingHandle, error) {
+	return nil, errNotSimulated
+}
+
+func (sr *storedRecording) Delete(key *ari.Key) error {
+	sr.client.record(EVENT_RECORD, "", "deleted "+key.ID)
+	return nil
+}
+
+func (sr *storedRecording) File(key *ari.Key) ([]byte, error) {
+	speech, ok := sr.client.recordedSpeech(key.ID)
+	if !ok {
+		return nil, errors.New("no recording " + key.ID)
+	}
+	return []byte(speech), nil
+}
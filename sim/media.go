@@ -0,0 +1,61 @@
+package sim
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+// Media replaces text to speech, speech recognition and file downloads. It hands out fake file
+// names and remembers what each one contains so that prompts can be
+// reported as the text that was said or the URL that was played.
+type Media struct {
+	mu    sync.Mutex
+	next  int
+	files map[string]string
+}
+
+func NewMedia() *Media {
+	return &Media{files: make(map[string]string)}
+}
+
+func (media *Media) add(prefix string, description string) string {
+	media.mu.Lock()
+	defer media.mu.Unlock()
+	media.next++
+	file := prefix + strconv.Itoa(media.next)
+	media.files[file] = description
+	return file
+}
+
+func (media *Media) TTS(say string, gender string, voice string, lang string) (string, error) {
+	return media.add("sim-tts-", "say \""+say+"\""), nil
+}
+
+func (media *Media) Download(flow *types.Flow, url string) (string, error) {
+	return media.add("sim-file-", "play "+url), nil
+}
+
+// Describe returns what a media URI such as "sound:sim-tts-1" plays.
+func (media *Media) Describe(uri string) string {
+	file := strings.TrimPrefix(uri, "sound:")
+	media.mu.Lock()
+	defer media.mu.Unlock()
+	if description, ok := media.files[file]; ok {
+		return description
+	}
+	return "play " + uri
+}
+
+// Transcribe recognizes the text the caller said in a simulated recording,
+// whose audio is that text.
+func (media *Media) Transcribe(ctx context.Context, audio []byte, opts *utils.SpeechOptions) (*utils.Transcript, error) {
+	if len(audio) == 0 {
+		return &utils.Transcript{}, nil
+	}
+	return &utils.Transcript{Text: string(audio), Confidence: 0.9}, nil
+}
@@ -0,0 +1,157 @@
+package sim
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"lineblocs.com/processor/mngrs"
+)
+
+type queueEntry struct {
+	channelId string
+	joined    time.Time
+}
+
+// queueStore keeps the queues of a simulation in memory.
+type queueStore struct {
+	mu     sync.Mutex
+	queues map[string][]queueEntry
+	busy   map[string]bool
+	calls  map[string]int
+	last   map[string]time.Time
+	turns  map[string]int
+	waits  map[string][]time.Duration
+}
+
+func newQueueStore() *queueStore {
+	return &queueStore{
+		queues: make(map[string][]queueEntry),
+		busy:   make(map[string]bool),
+		calls:  make(map[string]int),
+		last:   make(map[string]time.Time),
+		turns:  make(map[string]int),
+		waits:  make(map[string][]time.Duration)}
+}
+
+func queueName(workspaceId int, name string) string {
+	return strconv.Itoa(workspaceId) + ":" + name
+}
+
+func (store *queueStore) position(key string, channelId string) int {
+	for i, entry := range store.queues[key] {
+		if entry.channelId == channelId {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (store *queueStore) Join(workspaceId int, queue string, channelId string, joined time.Time, maxLength int) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := queueName(workspaceId, queue)
+	if position := store.position(key, channelId); position > 0 {
+		return position, nil
+	}
+	if maxLength > 0 && len(store.queues[key]) >= maxLength {
+		return 0, mngrs.ErrQueueFull
+	}
+	entries := append(store.queues[key], queueEntry{channelId: channelId, joined: joined})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].joined.Before(entries[j].joined)
+	})
+	store.queues[key] = entries
+	return store.position(key, channelId), nil
+}
+
+func (store *queueStore) Leave(workspaceId int, queue string, channelId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	key := queueName(workspaceId, queue)
+	if position := store.position(key, channelId); position > 0 {
+		entries := store.queues[key]
+		store.queues[key] = append(entries[:position-1], entries[position:]...)
+	}
+	return nil
+}
+
+func (store *queueStore) Position(workspaceId int, queue string, channelId string) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.position(queueName(workspaceId, queue), channelId), nil
+}
+
+func (store *queueStore) Agents(workspaceId int, queue string, extensions []string) ([]*mngrs.QueueAgent, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	agents := make([]*mngrs.QueueAgent, len(extensions))
+	for i, extension := range extensions {
+		agents[i] = &mngrs.QueueAgent{
+			Extension: extension,
+			Calls:     store.calls[queueName(workspaceId, queue)+":"+extension],
+			LastCall:  store.last[queueName(workspaceId, queue)+":"+extension],
+			Busy:      store.busy[queueName(workspaceId, extension)]}
+	}
+	return agents, nil
+}
+
+func (store *queueStore) ClaimAgent(workspaceId int, extension string, ttl time.Duration) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.busy[queueName(workspaceId, extension)] {
+		return false, nil
+	}
+	store.busy[queueName(workspaceId, extension)] = true
+	return true, nil
+}
+
+func (store *queueStore) RefreshAgent(workspaceId int, extension string, ttl time.Duration) error {
+	return nil
+}
+
+func (store *queueStore) ReleaseAgent(workspaceId int, queue string, extension string, answered bool) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.busy, queueName(workspaceId, extension))
+	if answered {
+		store.calls[queueName(workspaceId, queue)+":"+extension]++
+		store.last[queueName(workspaceId, queue)+":"+extension] = time.Now()
+	}
+	return nil
+}
+
+func (store *queueStore) Turn(workspaceId int, queue string) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.turns[queueName(workspaceId, queue)], nil
+}
+
+func (store *queueStore) AdvanceTurn(workspaceId int, queue string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.turns[queueName(workspaceId, queue)]++
+	return nil
+}
+
+func (store *queueStore) RecordWait(workspaceId int, queue string, wait time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.waits[queueName(workspaceId, queue)] = append(store.waits[queueName(workspaceId, queue)], wait)
+	return nil
+}
+
+func (store *queueStore) AverageWait(workspaceId int, queue string) (time.Duration, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	waits := store.waits[queueName(workspaceId, queue)]
+	if len(waits) == 0 {
+		return 0, nil
+	}
+	var total time.Duration
+	for _, wait := range waits {
+		total += wait
+	}
+	return total / time.Duration(len(waits)), nil
+}
@@ -0,0 +1,131 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+const (
+	DIAL_ANSWER    = "answer"
+	DIAL_NO_ANSWER = "no-answer"
+	DIAL_BUSY      = "busy"
+	// DIAL_CONFIRM answers and presses 1, like a person accepting a Follow
+	// Me call.
+	DIAL_CONFIRM = "confirm"
+)
+
+// Action is something the caller does at a given time after the call
+// started.
+type Action struct {
+	At     Duration `json:"at"`
+	DTMF   string   `json:"dtmf,omitempty"`
+	Speech string   `json:"speech,omitempty"`
+	Hangup bool     `json:"hangup,omitempty"`
+}
+
+// Script describes the behaviour of the caller and of the parties the flow
+// dials.
+//
+//	{
+//	  "from": "15145550100",
+//	  "to": "15145550199",
+//	  "timeout": "60s",
+//	  "prompt_duration": "1s",
+//	  "actions": [{"at": "2s", "dtmf": "1"}, {"at": "5s", "speech": "sales"}, {"at": "10s", "hangup": true}],
+//	  "dial": {"1001": "answer", "*": "no-answer"}
+//	}
+type Script struct {
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	Timeout        Duration          `json:"timeout"`
+	PromptDuration Duration          `json:"prompt_duration"`
+	AnswerDelay    Duration          `json:"answer_delay"`
+	Actions        []Action          `json:"actions"`
+	Dial           map[string]string `json:"dial"`
+}
+
+// Duration is a time.Duration read from strings such as "1.5s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var script Script
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, err
+	}
+	if err := script.validate(); err != nil {
+		return nil, err
+	}
+	return &script, nil
+}
+
+func (script *Script) validate() error {
+	for number, behaviour := range script.Dial {
+		switch behaviour {
+		case DIAL_ANSWER, DIAL_NO_ANSWER, DIAL_BUSY, DIAL_CONFIRM:
+		default:
+			return errors.New("unknown dial behaviour \"" + behaviour + "\" for " + number)
+		}
+	}
+	for _, action := range script.Actions {
+		if action.DTMF == "" && action.Speech == "" && !action.Hangup {
+			return errors.New("action at " + time.Duration(action.At).String() + " has no dtmf, speech or hangup")
+		}
+	}
+	return nil
+}
+
+// DialBehaviour returns how the number answers when the flow dials it.
+// Numbers without an entry use the "*" entry and otherwise answer.
+func (script *Script) DialBehaviour(number string) string {
+	if behaviour, ok := script.Dial[number]; ok {
+		return behaviour
+	}
+	if behaviour, ok := script.Dial["*"]; ok {
+		return behaviour
+	}
+	return DIAL_ANSWER
+}
+
+func (script *Script) timeout() time.Duration {
+	if script.Timeout == 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(script.Timeout)
+}
+
+func (script *Script) promptDuration() time.Duration {
+	if script.PromptDuration == 0 {
+		return time.Second
+	}
+	return time.Duration(script.PromptDuration)
+}
+
+func (script *Script) answerDelay() time.Duration {
+	if script.AnswerDelay == 0 {
+		return 500 * time.Millisecond
+	}
+	return time.Duration(script.AnswerDelay)
+}
@@ -0,0 +1,199 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/mngrs"
+	"lineblocs.com/processor/types"
+	"lineblocs.com/processor/utils"
+)
+
+const (
+	OUTCOME_FLOW_HANGUP   = "flow hung up"
+	OUTCOME_CALLER_HANGUP = "caller hung up"
+	OUTCOME_TIMEOUT       = "timed out"
+	OUTCOME_INVALID       = "invalid flow"
+)
+
+// grace is how long the simulation keeps running after the caller hangs up
+// so that the cell that was running can finish.
+const grace = 200 * time.Millisecond
+
+// Result is what happened during a simulated call.
+type Result struct {
+	Problems mngrs.FlowProblems  `json:"problems,omitempty"`
+	Cells    []*types.TraceEntry `json:"cells"`
+	Events   []Event             `json:"events"`
+	Outcome  string              `json:"outcome"`
+	Duration time.Duration       `json:"duration"`
+	started  time.Time
+}
+
+// Run executes a flow against the in-memory ARI client and a fake internals
+// API until the call ends or the script times out. It replaces the process
+// wide API URL, media and speech providers, queue store and conference store,
+// so only one simulation can run at a time.
+func Run(vars *types.FlowVars, script *Script) (*Result, error) {
+	media := NewMedia()
+	client := NewClient(script, media)
+	defer client.Close()
+	fake := newFakeAPI(script.From, client)
+	defer fake.Close()
+	api.SetBaseUrl(fake.URL())
+	utils.SetMediaProvider(media)
+	defer utils.SetMediaProvider(nil)
+	utils.SetSpeechProvider(media)
+	defer utils.SetSpeechProvider(nil)
+	mngrs.SetQueueStore(newQueueStore())
+	defer mngrs.SetQueueStore(nil)
+	mngrs.SetConferenceStore(newConferenceStore())
+	defer mngrs.SetConferenceStore(nil)
+
+	result := &Result{Problems: mngrs.ValidateFlow(vars), started: client.started}
+	caller := client.NewCaller(script.From)
+	lineChannel := &types.LineChannel{Channel: caller}
+	if err := result.Problems.Err(); err != nil {
+		user := types.NewUser(1, 1, "simulator")
+		mngrs.RouteToFallback(client, user, lineChannel, script.From, err.Error())
+		result.Outcome = OUTCOME_INVALID
+		result.finish(client, nil)
+		return result, nil
+	}
+
+	user := types.NewUser(1, 1, "simulator")
+	flow := types.NewFlow(1, user, vars, lineChannel, nil, client)
+	if len(flow.Cells) == 0 {
+		return nil, fmt.Errorf("flow has no cells")
+	}
+	flow.RootCall = &types.Call{
+		CallId:  1,
+		UserId:  user.Id,
+		Channel: lineChannel,
+		Started: time.Now(),
+		Params: &types.CallParams{
+			From:        script.From,
+			To:          script.To,
+			Status:      "start",
+			Direction:   "inbound",
+			UserId:      user.Id,
+			WorkspaceId: user.Workspace.Id,
+			ChannelId:   caller.ID()}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), script.timeout())
+	defer cancel()
+
+	lineChannel.Answer()
+	callCtx := mngrs.NewCallContext(ctx, lineChannel)
+	flow.Go(func() {
+		mngrs.ProcessFlow(client, callCtx, flow, lineChannel, make(map[string]string), flow.Cells[0])
+	})
+
+	var callerHungUp int32
+	timers := make([]*time.Timer, 0)
+	for _, action := range script.Actions {
+		action := action
+		at := time.Duration(action.At)
+		for i, digit := range action.DTMF {
+			digit := string(digit)
+			timers = append(timers, time.AfterFunc(at+time.Duration(i)*100*time.Millisecond, func() {
+				client.SendDTMF(caller.ID(), digit)
+			}))
+		}
+		if action.Speech != "" {
+			speech := action.Speech
+			timers = append(timers, time.AfterFunc(at, func() {
+				client.Say(caller.ID(), speech)
+			}))
+		}
+		if action.Hangup {
+			timers = append(timers, time.AfterFunc(at+time.Duration(len(action.DTMF))*100*time.Millisecond, func() {
+				atomic.StoreInt32(&callerHungUp, 1)
+				client.Hangup(caller.ID(), "by script")
+			}))
+		}
+	}
+	defer func() {
+		for _, timer := range timers {
+			timer.Stop()
+		}
+	}()
+
+	for result.Outcome == "" {
+		select {
+		case id := <-client.Hangups():
+			if id != caller.ID() {
+				continue
+			}
+			if atomic.LoadInt32(&callerHungUp) == 1 {
+				result.Outcome = OUTCOME_CALLER_HANGUP
+			} else {
+				result.Outcome = OUTCOME_FLOW_HANGUP
+			}
+			time.Sleep(grace)
+		case <-ctx.Done():
+			result.Outcome = OUTCOME_TIMEOUT
+		}
+	}
+	// the client and the fake API are closed once nothing of the call runs
+	cancel()
+	mngrs.WaitForCall(callCtx, flow)
+	result.finish(client, flow)
+	return result, nil
+}
+
+func (result *Result) finish(client *Client, flow *types.Flow) {
+	result.Duration = time.Since(result.started)
+	result.Events = client.Events()
+	result.Cells = make([]*types.TraceEntry, 0)
+	if flow != nil && flow.Trace != nil {
+		result.Cells = flow.Trace.Snapshot().Entries
+	}
+}
+
+// Prompts returns the prompts that were played in order.
+func (result *Result) Prompts() []string {
+	prompts := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_PROMPT {
+			prompts = append(prompts, event.Detail)
+		}
+	}
+	return prompts
+}
+
+// Write prints the result for people reading a terminal or a CI log.
+func (result *Result) Write(w io.Writer) {
+	for _, problem := range result.Problems {
+		fmt.Fprintf(w, "problem: %s\n", problem.String())
+	}
+	fmt.Fprintln(w, "cells:")
+	for _, entry := range result.Cells {
+		line := fmt.Sprintf("  %8s  %s (%s)", formatOffset(entry.EnteredAt.Sub(result.started)), entry.CellName, entry.CellType)
+		if entry.Port != "" {
+			line += " -> " + entry.Port
+		}
+		if entry.NextCell != "" {
+			line += " -> " + entry.NextCell
+		}
+		if entry.Error != "" {
+			line += " error: " + entry.Error
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, "events:")
+	for _, event := range result.Events {
+		line := fmt.Sprintf("  %8s  %-7s %-8s %s", formatOffset(event.At), event.Kind, event.Channel, event.Detail)
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintf(w, "outcome: %s after %s\n", result.Outcome, formatOffset(result.Duration))
+}
+
+func formatOffset(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
@@ -0,0 +1,479 @@
+package sim
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/processor/mngrs"
+	"lineblocs.com/processor/types"
+)
+
+func TestMain(m *testing.M) {
+	helpers.InitLogrus("")
+	os.Exit(m.Run())
+}
+
+func loadTestFlow(t *testing.T, name string) *types.FlowVars {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	var vars types.FlowVars
+	require.NoError(t, json.Unmarshal(data, &vars))
+	return &vars
+}
+
+func TestRunFlowHangup(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		To:             "15145550199",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond)}
+	result, err := Run(loadTestFlow(t, "greeting.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{
+		"say \"Welcome, you are calling from 15145550100\"",
+		"play https://example.com/goodbye.wav"}, result.Prompts())
+	names := make([]string, 0)
+	for _, entry := range result.Cells {
+		names = append(names, entry.CellName)
+	}
+	require.Equal(t, []string{"Launch", "SetVars1", "Welcome", "Language", "Goodbye"}, names)
+	require.Equal(t, "No Match", result.Cells[3].Port)
+}
+
+func TestRunCallerHangup(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(10 * time.Second),
+		Actions:        []Action{{At: Duration(100 * time.Millisecond), Hangup: true}}}
+	result, err := Run(loadTestFlow(t, "greeting.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	require.Len(t, result.Prompts(), 1)
+	require.Less(t, result.Duration, 5*time.Second)
+}
+
+func TestRunMenu(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(500 * time.Millisecond),
+		Actions:        []Action{{At: Duration(200 * time.Millisecond), DTMF: "1"}}}
+	result, err := Run(loadTestFlow(t, "menu.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{
+		"say \"Press 1 for sales\"",
+		"play https://example.com/sales.wav"}, result.Prompts())
+	require.Equal(t, "Digits Received", result.Cells[1].Port)
+	require.Equal(t, "1", result.Cells[1].Vars["digits"])
+}
+
+func TestRunLoopLimit(t *testing.T) {
+	t.Setenv("FLOW_MAX_CELL_VISITS", "3")
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond)}
+	result, err := Run(loadTestFlow(t, "loop.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{"play sound:an-error-has-occured"}, result.Prompts())
+	// launch, three visits of both cells and the fourth visit that failed
+	require.Len(t, result.Cells, 8)
+	last := result.Cells[len(result.Cells)-1]
+	require.Equal(t, "SetVars1", last.CellName)
+	require.Contains(t, last.Error, types.LIMIT_CELL_VISITS)
+}
+
+func TestScriptDialBehaviour(t *testing.T) {
+	script := &Script{Dial: map[string]string{"1001": DIAL_NO_ANSWER, "*": DIAL_BUSY}}
+	require.NoError(t, script.validate())
+	require.Equal(t, DIAL_NO_ANSWER, script.DialBehaviour("1001"))
+	require.Equal(t, DIAL_BUSY, script.DialBehaviour("1002"))
+	require.Equal(t, DIAL_ANSWER, (&Script{}).DialBehaviour("1002"))
+
+	script.Dial["1003"] = "maybe"
+	require.Error(t, script.validate())
+}
+
+// dials returns the numbers that were dialed and how they answered.
+func dials(result *Result) []string {
+	items := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_DIAL {
+			items = append(items, event.Channel+" "+event.Detail)
+		}
+	}
+	return items
+}
+
+func TestRunQueueAnswered(t *testing.T) {
+	t.Setenv("QUEUE_POLL_INTERVAL", "50ms")
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		AnswerDelay:    Duration(100 * time.Millisecond),
+		Actions:        []Action{{At: Duration(1500 * time.Millisecond), Hangup: true}},
+		Dial:           map[string]string{"1001": DIAL_NO_ANSWER, "1002": DIAL_ANSWER}}
+	result, err := Run(loadTestFlow(t, "queue.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	require.Equal(t, []string{"say \"You are number 1 in the queue.\""}, result.Prompts())
+	// round robin offers the call to 1001 first, which rings for a second
+	require.Equal(t, []string{"1001 no-answer", "1002 answer"}, dials(result))
+	require.Equal(t, "Support", result.Cells[1].CellName)
+	require.Equal(t, "1002", result.Cells[1].Vars["agent"])
+	require.Contains(t, kinds(result), EVENT_MOH)
+}
+
+func TestRunQueueMaxWait(t *testing.T) {
+	t.Setenv("QUEUE_POLL_INTERVAL", "50ms")
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Dial:           map[string]string{"*": DIAL_NO_ANSWER}}
+	result, err := Run(loadTestFlow(t, "queue.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{
+		"say \"You are number 1 in the queue.\"",
+		"play https://example.com/sorry.wav"}, result.Prompts())
+	require.Equal(t, []string{"1001 no-answer", "1002 no-answer"}, dials(result))
+	require.Equal(t, "Max Wait Exceeded", result.Cells[1].Port)
+}
+
+func TestRunHandlersInput(t *testing.T) {
+	t.Setenv("QUEUE_POLL_INTERVAL", "50ms")
+	// the prompt lasts longer than the silence handler waits, and the
+	// account number contains the digit of the dtmf handler, which the cell
+	// turned off
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(500 * time.Millisecond),
+		Actions: []Action{
+			{At: Duration(600 * time.Millisecond), DTMF: "1"},
+			{At: Duration(650 * time.Millisecond), DTMF: "0"},
+			{At: Duration(700 * time.Millisecond), DTMF: "5"}},
+		Dial: map[string]string{"*": DIAL_NO_ANSWER}}
+	result, err := Run(loadTestFlow(t, "handlers.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, "Account", result.Cells[1].CellName)
+	require.Equal(t, "Digits Received", result.Cells[1].Port)
+	require.Equal(t, "105", result.Cells[1].Vars["digits"])
+	require.Equal(t, "Support", result.Cells[2].CellName)
+}
+
+func TestRunHandlersMenu(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(500 * time.Millisecond),
+		Actions:        []Action{{At: Duration(200 * time.Millisecond), DTMF: "0"}}}
+	result, err := Run(loadTestFlow(t, "operator_menu.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	// the caller pressed 0 for the operator during the menu prompt
+	require.Equal(t, []string{
+		"say \"Press 1 for sales or 0 for the operator\"",
+		"play https://example.com/operator.wav"}, result.Prompts())
+	require.Equal(t, "Menu", result.Cells[1].CellName)
+	require.Equal(t, "cancelled", result.Cells[1].Error)
+	require.Equal(t, "Operator", result.Cells[2].CellName)
+}
+
+func TestRunHandlersQueue(t *testing.T) {
+	t.Setenv("QUEUE_POLL_INTERVAL", "50ms")
+	// the caller waits in silence and presses 0 while in the queue
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions: []Action{
+			{At: Duration(100 * time.Millisecond), DTMF: "4"},
+			{At: Duration(150 * time.Millisecond), DTMF: "2"},
+			{At: Duration(200 * time.Millisecond), DTMF: "1"},
+			{At: Duration(1200 * time.Millisecond), DTMF: "0"}},
+		Dial: map[string]string{"*": DIAL_NO_ANSWER}}
+	result, err := Run(loadTestFlow(t, "handlers.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"1001 no-answer", "1002 no-answer"}, dials(result))
+	require.Equal(t, "Support", result.Cells[2].CellName)
+	require.Equal(t, "Max Wait Exceeded", result.Cells[2].Port)
+	// the handlers are back once the queue is done
+	require.Equal(t, "Wait1", result.Cells[3].CellName)
+	require.Equal(t, "cancelled", result.Cells[3].Error)
+	require.Equal(t, "Operator", result.Cells[4].CellName)
+	require.Equal(t, []string{
+		"say \"Enter your account number\"",
+		"say \"You are number 1 in the queue.\"",
+		"play https://example.com/operator.wav"}, result.Prompts())
+}
+
+func kinds(result *Result) []string {
+	items := make([]string, 0)
+	for _, event := range result.Events {
+		items = append(items, event.Kind)
+	}
+	return items
+}
+
+func TestRunFollowMe(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		AnswerDelay:    Duration(100 * time.Millisecond),
+		Actions:        []Action{{At: Duration(2 * time.Second), Hangup: true}},
+		// the mobile goes to voicemail, which does not accept the call
+		Dial: map[string]string{"1001": DIAL_NO_ANSWER, "1002": DIAL_CONFIRM, "15145550111": DIAL_ANSWER}}
+	result, err := Run(loadTestFlow(t, "follow_me.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	require.ElementsMatch(t, []string{"1001 no-answer", "1002 confirm", "15145550111 answer"}, dials(result))
+	require.Equal(t, "1002", result.Cells[1].Vars["answered_by"])
+	require.Equal(t, "2", result.Cells[1].Vars["step"])
+	hangups := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_HANGUP && event.At < 2*time.Second {
+			hangups = append(hangups, event.Channel)
+		}
+	}
+	require.Equal(t, []string{"1001", "15145550111"}, hangups)
+}
+
+func TestRunFollowMeNoAnswer(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Dial:           map[string]string{"*": DIAL_NO_ANSWER}}
+	result, err := Run(loadTestFlow(t, "follow_me.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{"1001 no-answer", "1002 no-answer", "15145550111 no-answer"}, dials(result))
+	require.Equal(t, []string{"play https://example.com/away.wav"}, result.Prompts())
+	require.Equal(t, "No Answer", result.Cells[1].Port)
+}
+
+func records(result *Result) []string {
+	items := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_RECORD {
+			// recordings are named after a UUID
+			fields := strings.Fields(event.Detail)
+			fields[1] = "<id>"
+			items = append(items, strings.Join(fields, " "))
+		}
+	}
+	return items
+}
+
+func TestRunVoicemail(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(1500 * time.Millisecond), DTMF: "#"}}}
+	result, err := Run(loadTestFlow(t, "voicemail.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{
+		"say \"" + mngrs.VoicemailDefaultGreeting + "\"",
+		"beep",
+		"say \"Your message was saved.\""}, result.Prompts())
+	require.Equal(t, []string{"started <id>", "finished <id> on #", "saved <id> as voicemail in mailbox 200"}, records(result))
+	require.Equal(t, "Completed", result.Cells[1].Port)
+	require.Equal(t, "1", result.Cells[1].Vars["duration"])
+	require.Equal(t, "200", result.Cells[1].Vars["mailbox"])
+	require.NotEmpty(t, result.Cells[1].Vars["recording_id"])
+}
+
+func TestRunVoicemailNoMessage(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(300 * time.Millisecond), DTMF: "#"}}}
+	result, err := Run(loadTestFlow(t, "voicemail.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, "No Message", result.Cells[1].Port)
+	require.Equal(t, []string{"started <id>", "finished <id> on #", "deleted <id>"}, records(result))
+	require.Equal(t, "play https://example.com/no-message.wav", result.Prompts()[2])
+}
+
+func TestRunVoicemailHangup(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(1500 * time.Millisecond), Hangup: true}}}
+	result, err := Run(loadTestFlow(t, "voicemail.json"), script)
+	require.NoError(t, err)
+
+	// the message is kept when the caller hangs up to end it
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	require.Equal(t, []string{"started <id>", "finished <id> on hangup", "saved <id> as voicemail in mailbox 200"}, records(result))
+}
+
+func TestRunVoicemailMaxLength(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond)}
+	result, err := Run(loadTestFlow(t, "voicemail.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"started <id>", "finished <id> at max duration", "saved <id> as voicemail in mailbox 200"}, records(result))
+	require.Equal(t, "3", result.Cells[1].Vars["duration"])
+}
+
+func TestRunConferenceInvalidPin(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions: []Action{
+			{At: Duration(200 * time.Millisecond), DTMF: "1#"},
+			{At: Duration(800 * time.Millisecond), DTMF: "2#"},
+			{At: Duration(1400 * time.Millisecond), DTMF: "3#"}}}
+	result, err := Run(loadTestFlow(t, "conference.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	prompt := "say \"Please enter the conference PIN followed by the pound key.\""
+	invalid := "say \"That PIN is not valid.\""
+	require.Equal(t, []string{prompt, invalid, prompt, invalid, prompt, invalid, "play https://example.com/denied.wav"}, result.Prompts())
+	require.Equal(t, "Invalid PIN", result.Cells[1].Port)
+}
+
+func TestRunConferenceWaitingRoom(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(200 * time.Millisecond), DTMF: "1234#"}, {At: Duration(1500 * time.Millisecond), Hangup: true}}}
+	result, err := Run(loadTestFlow(t, "conference.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	moh := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_MOH {
+			moh = append(moh, event.Channel+" "+event.Detail)
+		}
+	}
+	// participants wait for the moderator outside of the conference
+	require.Equal(t, []string{"caller jazz"}, moh)
+	require.NotContains(t, kinds(result), EVENT_BRIDGE)
+}
+
+func TestRunConferenceModerator(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(200 * time.Millisecond), DTMF: "9999#"}, {At: Duration(1500 * time.Millisecond), Hangup: true}}}
+	result, err := Run(loadTestFlow(t, "conference.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	bridges := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_BRIDGE {
+			bridges = append(bridges, event.Channel+" "+event.Detail)
+		}
+	}
+	require.Equal(t, []string{"caller entered conference-1-7374616e647570", "caller left conference-1-7374616e647570"}, bridges)
+	require.Contains(t, result.Prompts(), "say \"A participant has joined the conference.\"")
+	require.NotContains(t, kinds(result), EVENT_MOH)
+}
+
+func TestRunConferenceMuteOnEntry(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(200 * time.Millisecond), DTMF: "1234#"}, {At: Duration(1000 * time.Millisecond), Hangup: true}}}
+	result, err := Run(loadTestFlow(t, "conference_muted.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_CALLER_HANGUP, result.Outcome)
+	mutes := make([]string, 0)
+	for _, event := range result.Events {
+		if event.Kind == EVENT_MUTE || event.Kind == EVENT_UNMUTE {
+			mutes = append(mutes, event.Kind+" "+event.Channel+" "+event.Detail)
+		}
+	}
+	// participants leave the conference unmuted
+	require.Equal(t, []string{"mute caller in", "unmute caller in"}, mutes)
+}
+
+func TestRunSpeechInput(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(300 * time.Millisecond), Speech: "sales"}}}
+	result, err := Run(loadTestFlow(t, "speech.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, OUTCOME_FLOW_HANGUP, result.Outcome)
+	require.Equal(t, []string{"started <id>", "finished <id> on silence", "deleted <id>"}, records(result))
+	require.Equal(t, "Digits Received", result.Cells[1].Port)
+	require.Equal(t, "sales", result.Cells[1].Vars["speech"])
+	require.Equal(t, "0.90", result.Cells[1].Vars["confidence"])
+	require.Equal(t, "", result.Cells[1].Vars["digits"])
+	require.Equal(t, "say \"Thank you.\"", result.Prompts()[1])
+}
+
+func TestRunSpeechInputDTMF(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond),
+		Actions:        []Action{{At: Duration(300 * time.Millisecond), DTMF: "2"}}}
+	result, err := Run(loadTestFlow(t, "speech.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"started <id>", "scrapped <id>"}, records(result))
+	require.Equal(t, "Digits Received", result.Cells[1].Port)
+	require.Equal(t, "2", result.Cells[1].Vars["digits"])
+	require.NotContains(t, result.Cells[1].Vars, "speech")
+}
+
+func TestRunSpeechInputNoInput(t *testing.T) {
+	script := &Script{
+		From:           "15145550100",
+		Timeout:        Duration(5 * time.Second),
+		PromptDuration: Duration(50 * time.Millisecond)}
+	result, err := Run(loadTestFlow(t, "speech.json"), script)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"started <id>", "finished <id> at max duration", "deleted <id>"}, records(result))
+	require.Equal(t, "No Input", result.Cells[1].Port)
+	require.Equal(t, "", result.Cells[1].Vars["speech"])
+	require.Equal(t, "play https://example.com/no-input.wav", result.Prompts()[1])
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"lineblocs.com/processor/sim"
+	"lineblocs.com/processor/types"
+)
+
+// runSimulate implements "processor simulate". Logs go to stderr so that the
+// report on stdout can be compared between runs.
+func runSimulate(args []string) int {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flowPath := flags.String("flow", "", "path to the FlowVars JSON of the flow")
+	scriptPath := flags.String("script", "", "path to the JSON script of the caller")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *flowPath == "" || *scriptPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: processor simulate -flow flow.json -script script.json [-json]")
+		return 2
+	}
+
+	data, err := os.ReadFile(*flowPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not read flow: "+err.Error())
+		return 1
+	}
+	var vars types.FlowVars
+	if err := json.Unmarshal(data, &vars); err != nil {
+		fmt.Fprintln(os.Stderr, "could not parse flow: "+err.Error())
+		return 1
+	}
+	script, err := sim.LoadScript(*scriptPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not load script: "+err.Error())
+		return 1
+	}
+
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() {
+		os.Stdout = stdout
+	}()
+	helpers.InitLogrus("")
+
+	result, err := sim.Run(&vars, script)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "simulation failed: "+err.Error())
+		return 1
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	} else {
+		result.Write(stdout)
+	}
+	if result.Outcome == sim.OUTCOME_INVALID {
+		return 1
+	}
+	return 0
+}
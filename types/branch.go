@@ -0,0 +1,229 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	JOIN_ALL = "all"
+	JOIN_ANY = "any"
+)
+
+// Branch is a path of a flow that runs in parallel with the other paths the
+// launch cell started. Every runner has one; subflows run in the branch of
+// their Subflow cell.
+type Branch struct {
+	Index int
+	Name  string
+	// Fork is the group of branches the branch was started in, nil when the
+	// launch cell has a single link.
+	Fork       *Fork
+	mu         sync.Mutex
+	background bool
+	variables  []producedVariable
+}
+
+type producedVariable struct {
+	flow     *Flow
+	variable FlowVariable
+}
+
+func NewBranch(index int, name string) *Branch {
+	return &Branch{Index: index, Name: name}
+}
+
+// Background is true for the branches started next to the first link of the
+// launch cell, until one of them continues after a join.
+func (branch *Branch) Background() bool {
+	if branch == nil {
+		return false
+	}
+	branch.mu.Lock()
+	defer branch.mu.Unlock()
+	return branch.background
+}
+
+// Produced records a variable the branch set in a flow, so that it can be
+// merged when the branches are joined.
+func (branch *Branch) Produced(flow *Flow, variable FlowVariable) {
+	if branch == nil {
+		return
+	}
+	branch.mu.Lock()
+	defer branch.mu.Unlock()
+	branch.variables = append(branch.variables, producedVariable{flow: flow, variable: variable})
+}
+
+// Variables returns the variables the branch set in a flow, in order.
+func (branch *Branch) Variables(flow *Flow) []FlowVariable {
+	branch.mu.Lock()
+	defer branch.mu.Unlock()
+	result := make([]FlowVariable, 0)
+	for _, item := range branch.variables {
+		if item.flow == flow {
+			result = append(result, item.variable)
+		}
+	}
+	return result
+}
+
+// Fork is the group of branches a launch cell started.
+type Fork struct {
+	mu       sync.Mutex
+	changed  chan struct{}
+	Branches []*Branch
+	ended    map[*Branch]bool
+	// arrivals are the branches waiting at each join cell, in order
+	arrivals map[string][]*Branch
+	joined   map[string]bool
+}
+
+// NewFork creates a branch for each name. The first branch owns the call,
+// the others are background branches.
+func NewFork(names []string) *Fork {
+	fork := &Fork{
+		changed:  make(chan struct{}),
+		ended:    make(map[*Branch]bool),
+		arrivals: make(map[string][]*Branch),
+		joined:   make(map[string]bool)}
+	for i, name := range names {
+		branch := NewBranch(i, name)
+		branch.Fork = fork
+		branch.background = i > 0
+		fork.Branches = append(fork.Branches, branch)
+	}
+	return fork
+}
+
+// notify wakes up the branches waiting at a join. The lock must be held.
+func (fork *Fork) notify() {
+	close(fork.changed)
+	fork.changed = make(chan struct{})
+}
+
+// End marks a branch as finished, which a join waiting for all branches no
+// longer waits for.
+func (fork *Fork) End(branch *Branch) {
+	if fork == nil {
+		return
+	}
+	fork.mu.Lock()
+	defer fork.mu.Unlock()
+	if !fork.ended[branch] {
+		fork.ended[branch] = true
+		fork.notify()
+	}
+}
+
+// Join waits at the join cell with the given id until all branches arrived
+// or ended, or, with JOIN_ANY, until the first branch arrived. The joined
+// function is called once with the branches that arrived, before any of them
+// returns. Join returns true for the branch that continues, which is the
+// first one to arrive. The other branches stop at the join.
+func (fork *Fork) Join(ctx context.Context, joinId string, branch *Branch, mode string, joined func(arrived []*Branch)) (bool, error) {
+	fork.mu.Lock()
+	defer fork.mu.Unlock()
+	if fork.joined[joinId] {
+		// the join already continued without this branch
+		return false, nil
+	}
+	fork.arrivals[joinId] = append(fork.arrivals[joinId], branch)
+	fork.notify()
+	for {
+		arrived := fork.arrivals[joinId]
+		if fork.joined[joinId] || fork.complete(arrived, mode) {
+			if !fork.joined[joinId] {
+				fork.joined[joinId] = true
+				first := arrived[0]
+				first.mu.Lock()
+				first.background = false
+				first.mu.Unlock()
+				joined(append([]*Branch{}, arrived...))
+				fork.notify()
+			}
+			return arrived[0] == branch, nil
+		}
+		changed := fork.changed
+		fork.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			fork.mu.Lock()
+			return false, ctx.Err()
+		case <-changed:
+		}
+		fork.mu.Lock()
+	}
+}
+
+// complete is true when a join can continue. The lock must be held.
+func (fork *Fork) complete(arrived []*Branch, mode string) bool {
+	if mode == JOIN_ANY {
+		return len(arrived) > 0
+	}
+	for _, item := range fork.Branches {
+		if !fork.ended[item] && !containsBranch(arrived, item) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsBranch(branches []*Branch, branch *Branch) bool {
+	for _, item := range branches {
+		if item == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// MediaOwner hands the media of a call, its audio and DTMF, to one branch at
+// a time. A branch keeps the media from its first media cell until it ends.
+type MediaOwner struct {
+	mu    sync.Mutex
+	owner *Branch
+	freed chan struct{}
+}
+
+func NewMediaOwner() *MediaOwner {
+	return &MediaOwner{freed: make(chan struct{})}
+}
+
+// Acquire waits until no other branch owns the media and makes the branch
+// its owner. A nil owner or branch does not wait.
+func (media *MediaOwner) Acquire(ctx context.Context, branch *Branch) error {
+	if media == nil || branch == nil {
+		return nil
+	}
+	for {
+		media.mu.Lock()
+		if media.owner == nil || media.owner == branch {
+			media.owner = branch
+			media.mu.Unlock()
+			return nil
+		}
+		freed := media.freed
+		media.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-freed:
+		}
+	}
+}
+
+// Release gives up the media if the branch owns it.
+func (media *MediaOwner) Release(branch *Branch) {
+	if media == nil || branch == nil {
+		return
+	}
+	media.mu.Lock()
+	defer media.mu.Unlock()
+	if media.owner != branch {
+		return
+	}
+	media.owner = nil
+	close(media.freed)
+	media.freed = make(chan struct{})
+}
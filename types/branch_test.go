@@ -0,0 +1,99 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaOwnerIsExclusive(t *testing.T) {
+	media := NewMediaOwner()
+	first, second := NewBranch(0, "first"), NewBranch(1, "second")
+	require.NoError(t, media.Acquire(context.Background(), first))
+	// acquiring again in the same branch does not wait
+	require.NoError(t, media.Acquire(context.Background(), first))
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- media.Acquire(context.Background(), second)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the second branch got the media while the first owned it")
+	case <-time.After(20 * time.Millisecond):
+	}
+	media.Release(second)
+	media.Release(first)
+	require.NoError(t, <-acquired)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, media.Acquire(ctx, first))
+}
+
+func TestForkJoinAll(t *testing.T) {
+	fork := NewFork([]string{"main", "side", "other"})
+	main, side, other := fork.Branches[0], fork.Branches[1], fork.Branches[2]
+	require.False(t, main.Background())
+	require.True(t, side.Background())
+
+	var joined []*Branch
+	results := make(chan bool, 2)
+	for _, branch := range []*Branch{side, main} {
+		go func(branch *Branch) {
+			first, err := fork.Join(context.Background(), "join", branch, JOIN_ALL, func(arrived []*Branch) {
+				joined = arrived
+			})
+			require.NoError(t, err)
+			results <- first
+		}(branch)
+		// keep the order of arrival
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-results:
+		t.Fatal("the join continued before every branch arrived or ended")
+	case <-time.After(20 * time.Millisecond):
+	}
+	fork.End(other)
+
+	firsts := 0
+	for i := 0; i < 2; i++ {
+		if <-results {
+			firsts++
+		}
+	}
+	require.Equal(t, 1, firsts)
+	require.Equal(t, []*Branch{side, main}, joined)
+	// the side branch arrived first and now owns the call
+	require.False(t, side.Background())
+}
+
+func TestForkJoinAny(t *testing.T) {
+	fork := NewFork([]string{"main", "side"})
+	calls := 0
+	first, err := fork.Join(context.Background(), "join", fork.Branches[1], JOIN_ANY, func(arrived []*Branch) {
+		calls++
+		require.Equal(t, []*Branch{fork.Branches[1]}, arrived)
+	})
+	require.NoError(t, err)
+	require.True(t, first)
+
+	// branches arriving later stop at the join
+	first, err = fork.Join(context.Background(), "join", fork.Branches[0], JOIN_ANY, func([]*Branch) {
+		calls++
+	})
+	require.NoError(t, err)
+	require.False(t, first)
+	require.Equal(t, 1, calls)
+}
+
+func TestBranchVariablesOfFlow(t *testing.T) {
+	parent, child := &Flow{}, &Flow{}
+	branch := NewBranch(0, "main")
+	branch.Produced(parent, FlowVariable{Name: "a", Value: "1"})
+	branch.Produced(child, FlowVariable{Name: "b", Value: "2"})
+	require.Equal(t, []FlowVariable{{Name: "a", Value: "1"}}, branch.Variables(parent))
+}
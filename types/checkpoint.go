@@ -0,0 +1,162 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/CyCoreSystems/ari/v5"
+)
+
+// the parts of a checkpoint. They are saved separately so that moving to the
+// next cell does not write the flow again.
+const (
+	// the call and its flow, which do not change while the call runs
+	CHECKPOINT_CALL = "call"
+	// the variables of the flow and of its cells
+	CHECKPOINT_VARIABLES = "variables"
+	// the cell the call is at
+	CHECKPOINT_CELL = "cell"
+)
+
+// CheckpointParts are all the parts of a checkpoint.
+var CheckpointParts = []string{CHECKPOINT_CALL, CHECKPOINT_VARIABLES, CHECKPOINT_CELL}
+
+// Checkpoint is the position of a call in its flow along with everything
+// needed to continue the flow on another instance.
+type Checkpoint struct {
+	Instance      string                       `json:"instance"`
+	ChannelId     string                       `json:"channel_id"`
+	CallId        int                          `json:"call_id"`
+	CallParams    *CallParams                  `json:"call_params,omitempty"`
+	Started       time.Time                    `json:"started"`
+	FlowId        int                          `json:"flow_id"`
+	UserId        int                          `json:"user_id"`
+	WorkspaceId   int                          `json:"workspace_id"`
+	WorkspaceName string                       `json:"workspace_name"`
+	Timezone      string                       `json:"timezone,omitempty"`
+	Vars          *FlowVars                    `json:"vars"`
+	Macros        []*WorkspaceMacro            `json:"macros,omitempty"`
+	CellId        string                       `json:"cell_id"`
+	CellType      string                       `json:"cell_type"`
+	Variables     map[string]FlowVariable      `json:"variables"`
+	EventVars     map[string]map[string]string `json:"event_vars"`
+	CallerKey     string                       `json:"caller_key,omitempty"`
+	SavedAt       time.Time                    `json:"saved_at"`
+}
+
+// NewCheckpoint records that the flow is about to execute the cell.
+func NewCheckpoint(flow *Flow, cell *Cell, instance string) *Checkpoint {
+	checkpoint := &Checkpoint{
+		Instance:  instance,
+		FlowId:    flow.FlowId,
+		Vars:      flow.Vars,
+		Macros:    flow.WorkspaceFns,
+		CellId:    cell.Cell.Id,
+		CellType:  cell.Cell.Type,
+		Variables: flow.Variables(),
+		EventVars: make(map[string]map[string]string),
+		CallerKey: flow.CallerKey(),
+		SavedAt:   time.Now()}
+	if flow.Channel != nil && flow.Channel.Channel != nil {
+		checkpoint.ChannelId = flow.Channel.Channel.ID()
+	}
+	if flow.User != nil {
+		checkpoint.UserId = flow.User.Id
+		checkpoint.WorkspaceId = flow.User.Workspace.Id
+		checkpoint.WorkspaceName = flow.User.Workspace.Name
+		checkpoint.Timezone = flow.User.Workspace.Timezone
+	}
+	if flow.RootCall != nil {
+		checkpoint.CallId = flow.RootCall.CallId
+		checkpoint.CallParams = flow.RootCall.Params
+		checkpoint.Started = flow.RootCall.Started
+	}
+	// background branches set the variables of their cells meanwhile
+	for _, item := range flow.Cells {
+		if vars := item.CopyEventVars(); len(vars) != 0 {
+			checkpoint.EventVars[item.Cell.Id] = vars
+		}
+	}
+	return checkpoint
+}
+
+// Part returns the JSON of one part of the checkpoint. Reading the parts back
+// into the same checkpoint with json.Unmarshal restores it.
+func (checkpoint *Checkpoint) Part(name string) ([]byte, error) {
+	switch name {
+	case CHECKPOINT_CALL:
+		return json.Marshal(&struct {
+			ChannelId     string            `json:"channel_id"`
+			CallId        int               `json:"call_id"`
+			CallParams    *CallParams       `json:"call_params,omitempty"`
+			Started       time.Time         `json:"started"`
+			FlowId        int               `json:"flow_id"`
+			UserId        int               `json:"user_id"`
+			WorkspaceId   int               `json:"workspace_id"`
+			WorkspaceName string            `json:"workspace_name"`
+			Timezone      string            `json:"timezone,omitempty"`
+			Vars          *FlowVars         `json:"vars"`
+			Macros        []*WorkspaceMacro `json:"macros,omitempty"`
+		}{
+			checkpoint.ChannelId,
+			checkpoint.CallId,
+			checkpoint.CallParams,
+			checkpoint.Started,
+			checkpoint.FlowId,
+			checkpoint.UserId,
+			checkpoint.WorkspaceId,
+			checkpoint.WorkspaceName,
+			checkpoint.Timezone,
+			checkpoint.Vars,
+			checkpoint.Macros})
+	case CHECKPOINT_VARIABLES:
+		return json.Marshal(&struct {
+			Variables map[string]FlowVariable      `json:"variables"`
+			EventVars map[string]map[string]string `json:"event_vars"`
+			CallerKey string                       `json:"caller_key,omitempty"`
+		}{checkpoint.Variables, checkpoint.EventVars, checkpoint.CallerKey})
+	case CHECKPOINT_CELL:
+		return json.Marshal(&struct {
+			Instance string    `json:"instance"`
+			CellId   string    `json:"cell_id"`
+			CellType string    `json:"cell_type"`
+			SavedAt  time.Time `json:"saved_at"`
+		}{checkpoint.Instance, checkpoint.CellId, checkpoint.CellType, checkpoint.SavedAt})
+	}
+	return nil, errors.New("unknown checkpoint part " + name)
+}
+
+// Restore creates the flow of the checkpoint on the channel and returns the
+// cell to continue with.
+func (checkpoint *Checkpoint) Restore(client ari.Client, channel *LineChannel) (*Flow, *Cell, error) {
+	if checkpoint.Vars == nil {
+		return nil, nil, errors.New("checkpoint has no flow")
+	}
+	user := NewUser(checkpoint.UserId, checkpoint.WorkspaceId, checkpoint.WorkspaceName)
+	user.Workspace.Timezone = checkpoint.Timezone
+	flow := NewFlow(checkpoint.FlowId, user, checkpoint.Vars, channel, checkpoint.Macros, client)
+	var cell *Cell
+	for _, item := range flow.Cells {
+		if item.Cell.Id == checkpoint.CellId {
+			cell = item
+		}
+		for name, value := range checkpoint.EventVars[item.Cell.Id] {
+			item.SetEventVar(name, value)
+		}
+	}
+	if cell == nil {
+		return nil, nil, errors.New("flow has no cell " + checkpoint.CellId)
+	}
+	for _, variable := range checkpoint.Variables {
+		flow.setVariable(variable)
+	}
+	flow.SetCallerKey(checkpoint.CallerKey)
+	flow.RootCall = &Call{
+		CallId:  checkpoint.CallId,
+		UserId:  checkpoint.UserId,
+		Channel: channel,
+		Started: checkpoint.Started,
+		Params:  checkpoint.CallParams}
+	return flow, cell, nil
+}
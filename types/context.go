@@ -2,7 +2,6 @@ package types
 
 import (
 	"context"
-	"regexp"
 	"strings"
 
 	"github.com/CyCoreSystems/ari/v5"
@@ -17,46 +16,83 @@ type Context struct {
 	Context     context.Context
 	Client      ari.Client
 	RecvChannel chan<- *ManagerResponse
+	// Config is the typed model data of the cell, when its cell type has one.
+	Config interface{}
+	cancel context.CancelFunc
 }
 
 func convertVariableValues(value string, lineFlow *Flow) string {
-	rex := regexp.MustCompile(`\{\{[\w\d\.]+\}\}`)
-	out := rex.FindAllString(value, -1)
-
-	for _, match := range out {
-		value = strings.ReplaceAll(value, match, "")
-	}
-	return value
+	return lineFlow.Interpolate(value)
 }
 
-func processInterpolation(i ModelData, lineFlow *Flow) {
+func processInterpolation(i ModelData, lineFlow *Flow) ModelData {
 	switch value := i.(type) {
 	case ModelDataStr:
-		value.Value = convertVariableValues(value.Value, lineFlow)
+		return ModelDataStr{Value: convertVariableValues(value.Value, lineFlow)}
 	case ModelDataObj:
+		result := make(map[string]string)
 		for k, v := range value.Value {
-			value.Value[k] = convertVariableValues(v, lineFlow)
+			result[k] = convertVariableValues(v, lineFlow)
 		}
+		return ModelDataObj{Value: result}
 	case ModelDataArr:
+		result := make([]string, len(value.Value))
 		for k, v := range value.Value {
-			value.Value[k] = convertVariableValues(v, lineFlow)
+			result[k] = convertVariableValues(v, lineFlow)
+		}
+		return ModelDataArr{Value: result}
+	case ModelDataList:
+		result := make([]map[string]string, len(value.Value))
+		for k, item := range value.Value {
+			obj := make(map[string]string)
+			for field, v := range item {
+				obj[field] = convertVariableValues(v, lineFlow)
+			}
+			result[k] = obj
 		}
+		return ModelDataList{Value: result}
 	}
+	return i
 }
 
+// processAllInterpolations resolves the templates in the model data. The raw
+// template of every value is kept under the "<key>_before_interpolations" key
+// so that running the same cell again resolves against the template and not
+// against the previous result.
 func processAllInterpolations(data map[string]ModelData, lineFlow *Flow) {
-	for key, val := range data {
+	keys := make([]string, 0, len(data))
+	for key := range data {
 		if !strings.HasSuffix(key, "_before_interpolations") {
-			interpolatedKey := key + "_before_interpolations"
-			if before, ok := data[interpolatedKey]; ok {
-				processInterpolation(before, lineFlow)
-			}
-			processInterpolation(val, lineFlow)
+			keys = append(keys, key)
 		}
 	}
+	for _, key := range keys {
+		interpolatedKey := key + "_before_interpolations"
+		before, ok := data[interpolatedKey]
+		if !ok {
+			before = data[key]
+			data[interpolatedKey] = before
+		}
+		data[key] = processInterpolation(before, lineFlow)
+	}
 }
 
+// NewContext creates the context a cell runs in. Its Context is done when the
+// given context is done or when the cell is cancelled.
 func NewContext(cl ari.Client, ctx context.Context, recvChannel chan<- *ManagerResponse, flow *Flow, cell *Cell, runner *Runner, channel *LineChannel) *Context {
 	processAllInterpolations(cell.Model.Data, flow)
-	return &Context{Client: cl, Context: ctx, Channel: channel, Cell: cell, Flow: flow, Runner: runner, RecvChannel: recvChannel}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cellCtx, cancel := context.WithCancel(ctx)
+	return &Context{Client: cl, Context: cellCtx, cancel: cancel, Channel: channel, Cell: cell, Flow: flow, Runner: runner, RecvChannel: recvChannel}
+}
+
+// Cancel stops the cell, e.g. when it ran for too long. Cells that keep
+// working after they resolved, like the leg of a Dial cell, are not cancelled
+// when the flow moves on.
+func (ctx *Context) Cancel() {
+	if ctx.cancel != nil {
+		ctx.cancel()
+	}
 }
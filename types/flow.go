@@ -1,8 +1,11 @@
 package types
 
 import (
+	"context"
 	"fmt"
-	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/CyCoreSystems/ari/v5"
 )
@@ -38,6 +41,38 @@ type Cell struct {
 	TargetLinks  []*Link
 	EventVars    map[string]string
 	AttachedCall *Call
+	// eventVarsMu guards EventVars, which the managers of the cell set while
+	// other branches of the flow read them.
+	eventVarsMu sync.RWMutex
+}
+
+// SetEventVar sets an output variable of the cell.
+func (cell *Cell) SetEventVar(name string, value string) {
+	cell.eventVarsMu.Lock()
+	defer cell.eventVarsMu.Unlock()
+	if cell.EventVars == nil {
+		cell.EventVars = make(map[string]string)
+	}
+	cell.EventVars[name] = value
+}
+
+// GetEventVar returns an output variable of the cell.
+func (cell *Cell) GetEventVar(name string) (string, bool) {
+	cell.eventVarsMu.RLock()
+	defer cell.eventVarsMu.RUnlock()
+	value, ok := cell.EventVars[name]
+	return value, ok
+}
+
+// CopyEventVars returns a copy of the output variables of the cell.
+func (cell *Cell) CopyEventVars() map[string]string {
+	cell.eventVarsMu.RLock()
+	defer cell.eventVarsMu.RUnlock()
+	vars := make(map[string]string, len(cell.EventVars))
+	for name, value := range cell.EventVars {
+		vars[name] = value
+	}
+	return vars
 }
 
 type ModelData interface {
@@ -55,11 +90,18 @@ type ModelDataArr struct {
 type ModelDataObj struct {
 	Value map[string]string
 }
+
+// ModelDataList holds a list of objects, e.g. the variables of a Set
+// Variables cell.
+type ModelDataList struct {
+	Value []map[string]string
+}
 type ModelLink struct {
-	Type      string `json:"type"`
-	Condition string `json:"condition"`
-	Value     string `json:"value"`
-	Cell      string `json:"cell"`
+	Type       string `json:"type"`
+	Condition  string `json:"condition"`
+	Value      string `json:"value"`
+	Cell       string `json:"cell"`
+	IgnoreCase bool   `json:"ignore_case"`
 }
 type Model struct {
 	Id    string
@@ -83,18 +125,20 @@ type FlowVars struct {
 }
 
 type FlowDIDData struct {
-	FlowId        int    `json:"flow_id"`
-	WorkspaceId   int    `json:"workspace_id"`
-	WorkspaceName string `json:"workspace_name"`
-	CreatorId     int    `json:"creator_id"`
-	FlowJSON      string `json:"flow_json"`
-	Plan          string `json:"plan"`
+	FlowId            int    `json:"flow_id"`
+	WorkspaceId       int    `json:"workspace_id"`
+	WorkspaceName     string `json:"workspace_name"`
+	WorkspaceTimezone string `json:"workspace_timezone"`
+	CreatorId         int    `json:"creator_id"`
+	FlowJSON          string `json:"flow_json"`
+	Plan              string `json:"plan"`
 }
 type SIPTrunkData struct {
-	Domain        string `json:"domain"`
-	WorkspaceId   int    `json:"workspace_id"`
-	WorkspaceName string `json:"workspace_name"`
-	CreatorId     int    `json:"creator_id"`
+	Domain            string `json:"domain"`
+	WorkspaceId       int    `json:"workspace_id"`
+	WorkspaceName     string `json:"workspace_name"`
+	WorkspaceTimezone string `json:"workspace_timezone"`
+	CreatorId         int    `json:"creator_id"`
 }
 
 func findCellInFlow(id string, flow *Flow, channel *LineChannel) *Cell {
@@ -107,6 +151,7 @@ func findCellInFlow(id string, flow *Flow, channel *LineChannel) *Cell {
 	}
 	if cellToFind == nil {
 		// could not find
+		return nil
 	}
 	cell := Cell{Channel: channel, Cell: cellToFind, EventVars: make(map[string]string)}
 	if cellToFind.Type == "devs.DialModel" || cellToFind.Type == "devs.BridgeModel" || cellToFind.Type == "devs.ConferenceModel" {
@@ -131,41 +176,10 @@ func createCellData(cell *Cell, flow *Flow, channel *LineChannel) {
 			//json.Unmarshal([]byte(unparsedModel.Data), &modelData)
 
 			for key, v := range modelData {
-				//var item ModelData
-				//item = ModelData{}
-				typeOfValue := reflect.TypeOf(v).String()
-
-				fmt.Printf("parsing type %s\r\n", typeOfValue)
 				fmt.Printf("setting key: %s\r\n", key)
-				switch typeOfValue {
-				case "[]string":
-					// it's an array
-					value := v.([]string)
-					model.Data[key] = ModelDataArr{Value: value}
-					//item.ValueArr = v
-					//item.IsArray = true
-
-				case "map[string]string":
-					// it's an object
-					fmt.Println("converting obj")
-					value := v.(map[string]string)
-					model.Data[key] = ModelDataObj{Value: value}
-					//item.ValueObj = v
-					//item.IsObj = true
-				case "string":
-					// it's something else
-					//item.ValueStr = v.(string)
-					//item.IsStr = true
-					value := v.(string)
-					model.Data[key] = ModelDataStr{Value: value}
-				case "boolean":
-					// it's something else
-					//item.ValueBool = v.(bool)
-					//item.IsBool = true
-					value := v.(bool)
-					model.Data[key] = ModelDataBool{Value: value}
+				if value, ok := decodeModelData(v); ok {
+					model.Data[key] = value
 				}
-
 			}
 		}
 	}
@@ -178,6 +192,10 @@ func createCellData(cell *Cell, flow *Flow, channel *LineChannel) {
 			if item.Source.Id == cell.Cell.Id {
 				fmt.Printf("createCellData adding target link %s\r\n", item.Target.Id)
 				destCell := addCellToFlow(item.Target.Id, flow, channel)
+				if destCell == nil {
+					fmt.Printf("createCellData skipping link to missing cell %s\r\n", item.Target.Id)
+					continue
+				}
 				link := &Link{
 					Link:   item,
 					Source: cell,
@@ -185,7 +203,11 @@ func createCellData(cell *Cell, flow *Flow, channel *LineChannel) {
 				sourceLinks = append(sourceLinks, link)
 			} else if item.Target.Id == cell.Cell.Id {
 				fmt.Printf("createCellData adding source link %s\r\n", item.Target.Id)
-				srcCell := addCellToFlow(item.Target.Id, flow, channel)
+				srcCell := addCellToFlow(item.Source.Id, flow, channel)
+				if srcCell == nil {
+					fmt.Printf("createCellData skipping link from missing cell %s\r\n", item.Source.Id)
+					continue
+				}
 				link := &Link{
 					Link:   item,
 					Source: srcCell,
@@ -199,6 +221,67 @@ func createCellData(cell *Cell, flow *Flow, channel *LineChannel) {
 	cell.TargetLinks = targetLinks
 }
 
+// decodeModelData converts a value decoded from the JSON of the flow editor.
+// Numbers are kept as text, the same way the editor sends most of them, and
+// lists hold either text or objects.
+func decodeModelData(v interface{}) (ModelData, bool) {
+	switch value := v.(type) {
+	case string:
+		return ModelDataStr{Value: value}, true
+	case bool:
+		return ModelDataBool{Value: value}, true
+	case float64:
+		return ModelDataStr{Value: strconv.FormatFloat(value, 'f', -1, 64)}, true
+	case []string:
+		return ModelDataArr{Value: value}, true
+	case map[string]string:
+		return ModelDataObj{Value: value}, true
+	case map[string]interface{}:
+		obj := make(map[string]string)
+		for field, fieldValue := range value {
+			if fieldValue != nil {
+				obj[field] = fmt.Sprint(fieldValue)
+			}
+		}
+		return ModelDataObj{Value: obj}, true
+	case []interface{}:
+		for _, entry := range value {
+			if _, ok := entry.(map[string]interface{}); ok {
+				return decodeModelDataList(value), true
+			}
+		}
+		items := make([]string, 0, len(value))
+		for _, entry := range value {
+			if entry != nil {
+				items = append(items, fmt.Sprint(entry))
+			}
+		}
+		return ModelDataArr{Value: items}, true
+	}
+	fmt.Printf("skipping value of type %T\r\n", v)
+	return nil, false
+}
+
+// decodeModelDataList converts a list of objects, e.g. the variables of a Set
+// Variables cell.
+func decodeModelDataList(value []interface{}) ModelDataList {
+	items := make([]map[string]string, 0)
+	for _, entry := range value {
+		obj, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		item := make(map[string]string)
+		for field, fieldValue := range obj {
+			if fieldValue != nil {
+				item[field] = fmt.Sprint(fieldValue)
+			}
+		}
+		items = append(items, item)
+	}
+	return ModelDataList{Value: items}
+}
+
 func addCellToFlow(id string, flow *Flow, channel *LineChannel) *Cell {
 	for _, cell := range flow.Cells {
 		if cell.Cell.Id == id {
@@ -207,6 +290,9 @@ func addCellToFlow(id string, flow *Flow, channel *LineChannel) *Cell {
 	}
 
 	cellInFlow := findCellInFlow(id, flow, channel)
+	if cellInFlow == nil {
+		return nil
+	}
 
 	fmt.Printf("adding cell %s", cellInFlow.Cell.Id)
 	flow.Cells = append(flow.Cells, cellInFlow)
@@ -215,7 +301,7 @@ func addCellToFlow(id string, flow *Flow, channel *LineChannel) *Cell {
 }
 
 func NewFlow(id int, user *User, vars *FlowVars, channel *LineChannel, fns []*WorkspaceMacro, client ari.Client) *Flow {
-	flow := &Flow{FlowId: id, User: user, Vars: vars, Runners: make([]*Runner, 0), WorkspaceFns: fns}
+	flow := &Flow{FlowId: id, User: user, Vars: vars, Channel: channel, Runners: make([]*Runner, 0), WorkspaceFns: fns, Trace: NewFlowTrace(id), Media: NewMediaOwner(), Routines: new(sync.WaitGroup), variables: make(map[string]FlowVariable)}
 	fmt.Printf("number of cells %d\r\n", len(flow.Vars.Graph.Cells))
 	// create cells from flow.Vars
 	for _, cell := range flow.Vars.Graph.Cells {
@@ -239,11 +325,313 @@ type Flow struct {
 	Cells        []*Cell
 	Models       []*Model
 	Runners      []*Runner
+	runnersMu    sync.Mutex
 	Vars         *FlowVars
 	FlowId       int
 	WorkspaceFns []*WorkspaceMacro
+	Trace        *FlowTrace
+	// Parent is the flow whose Subflow cell started this flow.
+	Parent *Flow
+	// Depth is the number of Subflow cells the flow is nested in.
+	Depth int
+	// Guard enforces the limits of the call the flow runs in.
+	Guard *FlowGuard
+	// Media decides which branch of the call can use its audio and DTMF.
+	Media *MediaOwner
+	// Routines tracks the goroutines of the call the flow runs in.
+	Routines    *sync.WaitGroup
+	exitMu      sync.Mutex
+	exitHandler func(outputs map[string]string, err error)
+	// handlingError is set while the "on error" cell of the flow runs.
+	handlingError bool
+	variablesMu   sync.RWMutex
+	variables     map[string]FlowVariable
+	// callerKey is the key the memory of the caller is kept under when a
+	// Caller Memory cell set one, and callerMemory the values loaded from it.
+	callerMu     sync.Mutex
+	callerKey    string
+	callerMemory map[string]string
+}
+
+const (
+	VARIABLE_TYPE_STRING  = "string"
+	VARIABLE_TYPE_NUMBER  = "number"
+	VARIABLE_TYPE_BOOLEAN = "boolean"
+)
+
+// FlowVariable is a variable that lives for the duration of the flow.
+type FlowVariable struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// GetVariable returns the value of a flow scoped variable.
+func (flow *Flow) GetVariable(name string) (string, bool) {
+	variable, ok := flow.Variable(name)
+	return variable.Value, ok
+}
+
+// Variable returns a flow scoped variable along with its type.
+func (flow *Flow) Variable(name string) (FlowVariable, bool) {
+	flow.variablesMu.RLock()
+	defer flow.variablesMu.RUnlock()
+	variable, ok := flow.variables[name]
+	return variable, ok
 }
 
+// Variables returns a copy of all flow scoped variables.
+func (flow *Flow) Variables() map[string]FlowVariable {
+	flow.variablesMu.RLock()
+	defer flow.variablesMu.RUnlock()
+	result := make(map[string]FlowVariable, len(flow.variables))
+	for name, variable := range flow.variables {
+		result[name] = variable
+	}
+	return result
+}
+
+// SetVariable sets a flow scoped string variable.
+func (flow *Flow) SetVariable(name string, value string) {
+	flow.setVariable(FlowVariable{Name: name, Type: VARIABLE_TYPE_STRING, Value: value})
+}
+
+// SetTypedVariable sets a flow scoped variable after checking that the value
+// is valid for the type. Numbers and booleans are stored in their canonical
+// form so "05" and "5.0" are both stored as "5".
+func (flow *Flow) SetTypedVariable(name string, varType string, value string) error {
+	if name == "" {
+		return fmt.Errorf("variable name is empty")
+	}
+	if varType == "" {
+		varType = VARIABLE_TYPE_STRING
+	}
+	switch varType {
+	case VARIABLE_TYPE_STRING:
+	case VARIABLE_TYPE_NUMBER:
+		num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("variable %s: %q is not a number", name, value)
+		}
+		value = strconv.FormatFloat(num, 'f', -1, 64)
+	case VARIABLE_TYPE_BOOLEAN:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("variable %s: %q is not a boolean", name, value)
+		}
+		value = strconv.FormatBool(parsed)
+	default:
+		return fmt.Errorf("variable %s: unknown type %s", name, varType)
+	}
+	flow.setVariable(FlowVariable{Name: name, Type: varType, Value: value})
+	return nil
+}
+
+func (flow *Flow) setVariable(variable FlowVariable) {
+	flow.variablesMu.Lock()
+	defer flow.variablesMu.Unlock()
+	if flow.variables == nil {
+		flow.variables = make(map[string]FlowVariable)
+	}
+	flow.variables[variable.Name] = variable
+}
+
+// Runner executes the cells of a flow one after the other. Cancelling a
+// runner stops the cell it is running and the cells that would follow it.
 type Runner struct {
 	Cancelled bool
+	// Branch is the branch of the flow the runner executes.
+	Branch *Branch
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewRunner(ctx context.Context) *Runner {
+	runnerCtx, cancel := context.WithCancel(ctx)
+	return &Runner{ctx: runnerCtx, cancel: cancel}
+}
+
+// Context is done once the runner is cancelled.
+func (runner *Runner) Context() context.Context {
+	if runner.ctx == nil {
+		return context.Background()
+	}
+	return runner.ctx
+}
+
+func (runner *Runner) Cancel() {
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	runner.Cancelled = true
+	if runner.cancel != nil {
+		runner.cancel()
+	}
+}
+
+func (runner *Runner) IsCancelled() bool {
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	return runner.Cancelled
+}
+
+// SetExitHandler sets the function that receives the outputs of a subflow
+// when it reaches an exit cell, or the error that ended it.
+func (flow *Flow) SetExitHandler(handler func(outputs map[string]string, err error)) {
+	flow.exitMu.Lock()
+	defer flow.exitMu.Unlock()
+	flow.exitHandler = handler
+}
+
+// Exit returns from a subflow to its parent. It returns false for flows that
+// were not started by a Subflow cell. The handler is only called once.
+func (flow *Flow) Exit(outputs map[string]string, err error) bool {
+	flow.exitMu.Lock()
+	handler := flow.exitHandler
+	flow.exitHandler = nil
+	flow.exitMu.Unlock()
+	if handler == nil {
+		return false
+	}
+	handler(outputs, err)
+	return true
+}
+
+// ErrorHandler returns the "on error" cell of the flow, which is named by the
+// "on_error" field of its launch cell. It is not returned while it handles an
+// error, so an error raised by the handler itself is not handled again.
+func (flow *Flow) ErrorHandler() *Cell {
+	handler := flow.errorHandlerCell()
+	if handler == nil {
+		return nil
+	}
+	flow.exitMu.Lock()
+	defer flow.exitMu.Unlock()
+	if flow.handlingError {
+		return nil
+	}
+	flow.handlingError = true
+	return handler
+}
+
+func (flow *Flow) errorHandlerCell() *Cell {
+	var name string
+	for _, cell := range flow.Cells {
+		if cell.Cell.Type != "devs.LaunchModel" || cell.Model == nil {
+			continue
+		}
+		if value, ok := cell.Model.Data["on_error"].(ModelDataStr); ok {
+			name = strings.TrimSpace(value.Value)
+		}
+		break
+	}
+	if name == "" {
+		return nil
+	}
+	return flow.FindCell(name)
+}
+
+// LeftCell is called when a runner of the flow moves past a cell. Once it
+// moved past the "on error" cell, later errors are handled again.
+func (flow *Flow) LeftCell(cell *Cell) {
+	flow.exitMu.Lock()
+	defer flow.exitMu.Unlock()
+	if flow.handlingError && cell == flow.errorHandlerCell() {
+		flow.handlingError = false
+	}
+}
+
+// root returns the flow of the call, which subflows share the caller with.
+func (flow *Flow) root() *Flow {
+	for flow.Parent != nil {
+		flow = flow.Parent
+	}
+	return flow
+}
+
+// CallerKey returns the key set by SetCallerKey, or "" when the memory of the
+// caller is kept under their number.
+func (flow *Flow) CallerKey() string {
+	root := flow.root()
+	root.callerMu.Lock()
+	defer root.callerMu.Unlock()
+	return root.callerKey
+}
+
+// SetCallerKey keeps the memory of the caller under a custom key for the rest
+// of the call.
+func (flow *Flow) SetCallerKey(key string) {
+	root := flow.root()
+	root.callerMu.Lock()
+	defer root.callerMu.Unlock()
+	root.callerKey = key
+	root.callerMemory = nil
+}
+
+// CallerMemory returns the memory of the caller. It is read with load the
+// first time and again after ForgetCallerMemory.
+func (flow *Flow) CallerMemory(load func() (map[string]string, error)) (map[string]string, error) {
+	root := flow.root()
+	root.callerMu.Lock()
+	defer root.callerMu.Unlock()
+	if root.callerMemory == nil {
+		values, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		root.callerMemory = values
+	}
+	return root.callerMemory, nil
+}
+
+// ForgetCallerMemory is called after the memory of the caller was written.
+func (flow *Flow) ForgetCallerMemory() {
+	root := flow.root()
+	root.callerMu.Lock()
+	defer root.callerMu.Unlock()
+	root.callerMemory = nil
+}
+
+func (flow *Flow) AddRunner(runner *Runner) {
+	flow.runnersMu.Lock()
+	defer flow.runnersMu.Unlock()
+	flow.Runners = append(flow.Runners, runner)
+}
+
+func (flow *Flow) routines() *sync.WaitGroup {
+	flow.runnersMu.Lock()
+	defer flow.runnersMu.Unlock()
+	if flow.Routines == nil {
+		flow.Routines = new(sync.WaitGroup)
+	}
+	return flow.Routines
+}
+
+// Go runs fn in a goroutine of the call, which Wait waits for.
+func (flow *Flow) Go(fn func()) {
+	routines := flow.routines()
+	routines.Add(1)
+	go func() {
+		defer routines.Done()
+		fn()
+	}()
+}
+
+// Wait blocks until the goroutines of the call returned.
+func (flow *Flow) Wait() {
+	flow.routines().Wait()
+}
+
+// CancelRunners cancels every runner of the flow.
+func (flow *Flow) CancelRunners() {
+	flow.runnersMu.Lock()
+	runners := append([]*Runner{}, flow.Runners...)
+	flow.Runners = make([]*Runner, 0)
+	flow.runnersMu.Unlock()
+	for _, runner := range runners {
+		runner.Cancel()
+	}
 }
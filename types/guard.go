@@ -0,0 +1,87 @@
+package types
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	LIMIT_HOPS        = "hops"
+	LIMIT_DURATION    = "duration"
+	LIMIT_CELL_VISITS = "cell_visits"
+)
+
+// FlowLimits stop flows that loop forever. Limits that are zero are not
+// enforced.
+type FlowLimits struct {
+	MaxHops       int
+	MaxDuration   time.Duration
+	MaxCellVisits int
+}
+
+// LimitError is returned for the cell that took a call over one of its
+// limits.
+type LimitError struct {
+	Limit  string
+	CellId string
+	Max    string
+}
+
+func (e *LimitError) Error() string {
+	return "flow exceeded its " + e.Limit + " limit of " + e.Max + " at cell " + e.CellId
+}
+
+// FlowGuard counts the cells executed for a call, across all of its runners
+// and subflows.
+type FlowGuard struct {
+	mu      sync.Mutex
+	limits  FlowLimits
+	started time.Time
+	hops    int
+	visits  map[string]int
+}
+
+func NewFlowGuard(limits FlowLimits) *FlowGuard {
+	return &FlowGuard{limits: limits, started: time.Now(), visits: make(map[string]int)}
+}
+
+// Visit is called before a cell is executed. The key identifies the cell
+// within the call, so cells of subflows must be prefixed with their flow. A
+// nil guard enforces nothing.
+func (guard *FlowGuard) Visit(key string) error {
+	if guard == nil {
+		return nil
+	}
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	guard.hops++
+	guard.visits[key]++
+	limits := guard.limits
+	if limits.MaxHops > 0 && guard.hops > limits.MaxHops {
+		return &LimitError{Limit: LIMIT_HOPS, CellId: key, Max: strconv.Itoa(limits.MaxHops)}
+	}
+	if limits.MaxDuration > 0 && time.Since(guard.started) > limits.MaxDuration {
+		return &LimitError{Limit: LIMIT_DURATION, CellId: key, Max: limits.MaxDuration.String()}
+	}
+	if limits.MaxCellVisits > 0 && guard.visits[key] > limits.MaxCellVisits {
+		return &LimitError{Limit: LIMIT_CELL_VISITS, CellId: key, Max: strconv.Itoa(limits.MaxCellVisits)}
+	}
+	return nil
+}
+
+// Hops returns the number of cells executed so far.
+func (guard *FlowGuard) Hops() int {
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	return guard.hops
+}
+
+// Remaining returns how long the call can still run, and false when its
+// duration is not limited.
+func (guard *FlowGuard) Remaining() (time.Duration, bool) {
+	if guard == nil || guard.limits.MaxDuration <= 0 {
+		return 0, false
+	}
+	return guard.limits.MaxDuration - time.Since(guard.started), true
+}
@@ -0,0 +1,232 @@
+package types
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// VariableResolver resolves the remainder of a template reference whose first
+// segment named a namespace, e.g. "from" for {{call.from}}.
+type VariableResolver func(flow *Flow, path string) (string, bool)
+
+// TemplateFilter transforms a resolved template value. The argument is empty
+// when the filter was used without one.
+type TemplateFilter func(value string, arg string, resolved bool) string
+
+var (
+	namespacesMu sync.RWMutex
+	namespaces   = map[string]VariableResolver{
+		"call":      resolveCallVariable,
+		"channel":   resolveChannelVariable,
+		"flow":      resolveFlowVariable,
+		"workspace": resolveWorkspaceVariable,
+	}
+
+	filters = map[string]TemplateFilter{
+		"default": func(value string, arg string, resolved bool) string {
+			if !resolved || value == "" {
+				return arg
+			}
+			return value
+		},
+		"upper": func(value string, arg string, resolved bool) string {
+			return strings.ToUpper(value)
+		},
+		"lower": func(value string, arg string, resolved bool) string {
+			return strings.ToLower(value)
+		},
+		"trim": func(value string, arg string, resolved bool) string {
+			return strings.TrimSpace(value)
+		},
+		"digits": func(value string, arg string, resolved bool) string {
+			return strings.Map(func(r rune) rune {
+				if unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, value)
+		},
+		// spell separates characters so text to speech reads numbers digit by digit
+		"spell": func(value string, arg string, resolved bool) string {
+			return strings.Join(strings.Split(value, ""), " ")
+		},
+		"truncate": func(value string, arg string, resolved bool) string {
+			size, err := strconv.Atoi(arg)
+			if err != nil || size < 0 || len(value) <= size {
+				return value
+			}
+			return value[:size]
+		},
+	}
+
+	templateRex = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+)
+
+// RegisterVariableNamespace adds a namespace that template references can
+// resolve against, e.g. "caller" for {{caller.lang}}.
+func RegisterVariableNamespace(name string, resolver VariableResolver) {
+	namespacesMu.Lock()
+	defer namespacesMu.Unlock()
+	namespaces[name] = resolver
+}
+
+func lookupNamespace(name string) (VariableResolver, bool) {
+	namespacesMu.RLock()
+	defer namespacesMu.RUnlock()
+	resolver, ok := namespaces[name]
+	return resolver, ok
+}
+
+func resolveCallVariable(flow *Flow, path string) (string, bool) {
+	call := flow.RootCall
+	if call == nil || call.Params == nil {
+		return "", false
+	}
+	switch path {
+	case "from":
+		return call.Params.From, true
+	case "to":
+		return call.Params.To, true
+	case "direction":
+		return call.Params.Direction, true
+	case "id":
+		return strconv.Itoa(call.CallId), true
+	}
+	return "", false
+}
+
+func resolveChannelVariable(flow *Flow, path string) (string, bool) {
+	if path != "id" {
+		return "", false
+	}
+	if flow.Channel != nil && flow.Channel.Channel != nil {
+		return flow.Channel.Channel.ID(), true
+	}
+	if flow.RootCall != nil && flow.RootCall.Params != nil {
+		return flow.RootCall.Params.ChannelId, true
+	}
+	return "", false
+}
+
+func resolveFlowVariable(flow *Flow, path string) (string, bool) {
+	if path == "id" {
+		return strconv.Itoa(flow.FlowId), true
+	}
+	return flow.GetVariable(path)
+}
+
+func resolveWorkspaceVariable(flow *Flow, path string) (string, bool) {
+	if flow.User == nil {
+		return "", false
+	}
+	workspace := flow.User.Workspace
+	switch path {
+	case "id":
+		return strconv.Itoa(workspace.Id), true
+	case "name":
+		return workspace.Name, true
+	case "domain":
+		return workspace.Domain, true
+	}
+	return "", false
+}
+
+// FindCell returns the cell with the given name.
+func (flow *Flow) FindCell(name string) *Cell {
+	for _, cell := range flow.Cells {
+		if cell.Cell.Name == name {
+			return cell
+		}
+		if cell.Model != nil && cell.Model.Name == name {
+			return cell
+		}
+	}
+	return nil
+}
+
+// ResolveVariable resolves a reference such as "Input1.digits", "call.from"
+// or "flow.total". References without a namespace are looked up in the flow
+// variables.
+func (flow *Flow) ResolveVariable(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	splitted := strings.SplitN(ref, ".", 2)
+	if len(splitted) == 1 {
+		return flow.GetVariable(ref)
+	}
+	name, path := splitted[0], splitted[1]
+	if resolver, ok := lookupNamespace(name); ok {
+		if value, ok := resolver(flow, path); ok {
+			return value, true
+		}
+	}
+
+	cell := flow.FindCell(name)
+	if cell == nil {
+		return "", false
+	}
+	if value, ok := cell.GetEventVar(path); ok {
+		return value, true
+	}
+	// the launch cell exposes the call metadata, e.g. {{Launch.call.from}}
+	if cell.Cell.Type == "devs.LaunchModel" {
+		splitted = strings.SplitN(path, ".", 2)
+		if len(splitted) == 2 {
+			if resolver, ok := lookupNamespace(splitted[0]); ok {
+				return resolver(flow, splitted[1])
+			}
+		}
+	}
+	return "", false
+}
+
+// splitFilters splits a template expression on pipes that are not quoted.
+func splitFilters(expr string) []string {
+	parts := make([]string, 0)
+	quoted := false
+	start := 0
+	for i, r := range expr {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case '|':
+			if !quoted {
+				parts = append(parts, strings.TrimSpace(expr[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(expr[start:]))
+}
+
+func applyFilter(spec string, value string, resolved bool) string {
+	name, arg := spec, ""
+	if idx := strings.Index(spec, ":"); idx != -1 {
+		name = strings.TrimSpace(spec[:idx])
+		arg = strings.TrimSpace(spec[idx+1:])
+		if unquoted, err := strconv.Unquote(arg); err == nil {
+			arg = unquoted
+		}
+	}
+	filter, ok := filters[name]
+	if !ok {
+		return value
+	}
+	return filter(value, arg, resolved)
+}
+
+// Interpolate replaces every {{reference | filter:"arg"}} token in the value.
+// Unresolved references without a default become an empty string.
+func (flow *Flow) Interpolate(value string) string {
+	return templateRex.ReplaceAllStringFunc(value, func(match string) string {
+		parts := splitFilters(match[2 : len(match)-2])
+		result, resolved := flow.ResolveVariable(parts[0])
+		for _, spec := range parts[1:] {
+			result = applyFilter(spec, result, resolved)
+			resolved = true
+		}
+		return result
+	})
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newInterpolationFlow() *Flow {
+	flow := &Flow{
+		FlowId: 7,
+		User:   NewUser(1, 2, "acme"),
+		RootCall: &Call{
+			CallId: 99,
+			Params: &CallParams{From: "+15145550100", To: "+15145550199", ChannelId: "chan-1"}},
+	}
+	input := &Cell{
+		Cell:      &GraphCell{Id: "2", Name: "Input1", Type: "devs.ProcessInputModel"},
+		Model:     &Model{Name: "Input1"},
+		EventVars: map[string]string{"digits": "1234"}}
+	launch := &Cell{
+		Cell:      &GraphCell{Id: "1", Name: "Launch", Type: "devs.LaunchModel"},
+		Model:     &Model{Name: "Launch"},
+		EventVars: map[string]string{}}
+	flow.Cells = []*Cell{launch, input}
+	flow.SetVariable("total", "42")
+	return flow
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+	flow := newInterpolationFlow()
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "CellVariable", template: "You entered {{Input1.digits}}.", want: "You entered 1234."},
+		{name: "CallMetadata", template: "{{call.from}} -> {{call.to}}", want: "+15145550100 -> +15145550199"},
+		{name: "LaunchCellMetadata", template: "{{Launch.call.from}}", want: "+15145550100"},
+		{name: "ChannelId", template: "{{channel.id}}", want: "chan-1"},
+		{name: "FlowVariable", template: "{{flow.total}} / {{total}}", want: "42 / 42"},
+		{name: "Workspace", template: "{{workspace.name}}", want: "acme"},
+		{name: "Unresolved", template: "[{{Missing.value}}]", want: "[]"},
+		{name: "Default", template: `{{Missing.value | default:"0"}}`, want: "0"},
+		{name: "DefaultNotUsed", template: `{{Input1.digits | default:"0"}}`, want: "1234"},
+		{name: "Filters", template: `{{ workspace.name | upper }} {{Input1.digits | spell}}`, want: "ACME 1 2 3 4"},
+		{name: "DigitsAndTruncate", template: "{{call.from | digits | truncate:4}}", want: "1514"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, flow.Interpolate(tt.template))
+		})
+	}
+}
+
+func TestProcessAllInterpolations(t *testing.T) {
+	t.Parallel()
+	flow := newInterpolationFlow()
+	data := map[string]ModelData{
+		"text_to_say": ModelDataStr{Value: "Your code is {{Input1.digits}}"},
+	}
+
+	processAllInterpolations(data, flow)
+	require.Equal(t, ModelDataStr{Value: "Your code is 1234"}, data["text_to_say"])
+	require.Equal(t, ModelDataStr{Value: "Your code is {{Input1.digits}}"}, data["text_to_say_before_interpolations"])
+
+	// running the cell again resolves against the raw template
+	flow.Cells[1].EventVars["digits"] = "5678"
+	processAllInterpolations(data, flow)
+	require.Equal(t, ModelDataStr{Value: "Your code is 5678"}, data["text_to_say"])
+}
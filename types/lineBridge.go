@@ -46,14 +46,19 @@ func (b *LineBridge) RemoveChannel(channel *LineChannel) {
 	b.Channels = channels
 }
 
-func (b *LineBridge) StartWaitingForRingTimeout(timeout int, wg *sync.WaitGroup, ringTimeoutChan <-chan bool) {
+// StartWaitingForRingTimeout ends the calls of the bridge when no call was
+// answered within the timeout. It stops when ctx is done.
+func (b *LineBridge) StartWaitingForRingTimeout(ctx context.Context, timeout int, wg *sync.WaitGroup, ringTimeoutChan <-chan bool) {
 	fmt.Println("starting ring timeout checker..")
 	fmt.Println("timeout set for: " + strconv.Itoa(timeout))
 	duration := time.Now().Add(time.Duration(timeout) * time.Second)
 
 	// Create a context that is both manually cancellable and will signal
 	// a cancel at the specified duration.
-	ringCtx, cancel := context.WithDeadline(context.Background(), duration)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ringCtx, cancel := context.WithDeadline(ctx, duration)
 	defer cancel()
 	wg.Done()
 	for {
@@ -62,6 +67,10 @@ func (b *LineBridge) StartWaitingForRingTimeout(timeout int, wg *sync.WaitGroup,
 			fmt.Println("bridge in session. stopping ring timeout")
 			return
 		case <-ringCtx.Done():
+			if ctx.Err() != nil {
+				fmt.Println("call ended. stopping ring timeout")
+				return
+			}
 			fmt.Println("Ring timeout elapsed.. ending all calls")
 			b.EndBridgeCall()
 			return
@@ -56,7 +56,11 @@ func (channel *LineChannel) StartWaitingForRingTimeout(ctx *Context, noAnswer *L
 
 	// Create a context that is both manually cancellable and will signal
 	// a cancel at the specified duration.
-	ringCtx, cancel := context.WithDeadline(context.Background(), duration)
+	parent := ctx.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ringCtx, cancel := context.WithDeadline(parent, duration)
 	defer cancel()
 	if mode == "dial" {
 		wg.Done()
@@ -67,6 +71,10 @@ func (channel *LineChannel) StartWaitingForRingTimeout(ctx *Context, noAnswer *L
 			fmt.Println("bridge in session. stopping ring timeout")
 			return
 		case <-ringCtx.Done():
+			if parent.Err() != nil {
+				fmt.Println("cell cancelled. stopping ring timeout")
+				return
+			}
 			fmt.Println("Ring timeout elapsed.. ending all calls")
 			if mode == "dial" {
 				resp := ManagerResponse{
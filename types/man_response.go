@@ -3,4 +3,10 @@ package types
 type ManagerResponse struct {
 	Link    *Link
 	Channel *LineChannel
+	// Exit ends the runner without hanging up the channel, e.g. when a
+	// subflow returns to its parent.
+	Exit bool
+	// Error fails the cell. The runner follows the "Error" port of the cell,
+	// or routes the call to the fallback when it has none.
+	Error error
 }
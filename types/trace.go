@@ -0,0 +1,103 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// TraceEntry records the execution of a single cell.
+type TraceEntry struct {
+	CellId     string            `json:"cell_id"`
+	CellName   string            `json:"cell_name"`
+	CellType   string            `json:"cell_type"`
+	EnteredAt  time.Time         `json:"entered_at"`
+	ExitedAt   time.Time         `json:"exited_at"`
+	DurationMs int64             `json:"duration_ms"`
+	Port       string            `json:"port,omitempty"`
+	NextCell   string            `json:"next_cell,omitempty"`
+	Vars       map[string]string `json:"vars,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// FlowTrace is the timeline of the cells executed for a call.
+type FlowTrace struct {
+	mu        sync.Mutex
+	CallId    string        `json:"call_id"`
+	ChannelId string        `json:"channel_id"`
+	FlowId    int           `json:"flow_id"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   time.Time     `json:"ended_at"`
+	Entries   []*TraceEntry `json:"entries"`
+}
+
+func NewFlowTrace(flowId int) *FlowTrace {
+	return &FlowTrace{FlowId: flowId, StartedAt: time.Now(), Entries: make([]*TraceEntry, 0)}
+}
+
+// Enter starts the entry of a cell. All methods are safe to call on a nil
+// trace.
+func (trace *FlowTrace) Enter(cell *Cell) *TraceEntry {
+	if trace == nil {
+		return nil
+	}
+	entry := &TraceEntry{
+		CellId:    cell.Cell.Id,
+		CellName:  cell.Cell.Name,
+		CellType:  cell.Cell.Type,
+		EnteredAt: time.Now()}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.Entries = append(trace.Entries, entry)
+	return entry
+}
+
+// Exit completes an entry with the link that was taken, the variables the
+// cell set and the error it ran into.
+func (trace *FlowTrace) Exit(entry *TraceEntry, link *Link, vars map[string]string, err error) {
+	if trace == nil || entry == nil {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	entry.ExitedAt = time.Now()
+	entry.DurationMs = entry.ExitedAt.Sub(entry.EnteredAt).Milliseconds()
+	if link != nil {
+		entry.Port = link.Link.Source.Port
+		entry.NextCell = link.Target.Cell.Name
+	}
+	if len(vars) > 0 {
+		entry.Vars = vars
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+}
+
+// End marks the end of the call.
+func (trace *FlowTrace) End() {
+	if trace == nil {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.EndedAt = time.Now()
+}
+
+// Snapshot returns a copy of the trace that can be read while the call is
+// still running.
+func (trace *FlowTrace) Snapshot() *FlowTrace {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	result := &FlowTrace{
+		CallId:    trace.CallId,
+		ChannelId: trace.ChannelId,
+		FlowId:    trace.FlowId,
+		StartedAt: trace.StartedAt,
+		EndedAt:   trace.EndedAt,
+		Entries:   make([]*TraceEntry, len(trace.Entries))}
+	for i, entry := range trace.Entries {
+		item := *entry
+		result.Entries[i] = &item
+	}
+	return result
+}
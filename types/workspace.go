@@ -9,4 +9,7 @@ type Workspace struct {
 	Id     int
 	Name   string
 	Domain string
+	// Timezone is the IANA name of the timezone of the workspace, e.g.
+	// "America/Toronto". Time conditions use it when a cell has none.
+	Timezone string
 }
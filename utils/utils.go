@@ -35,6 +35,7 @@ import (
 	speechpb "google.golang.org/genproto/googleapis/cloud/speech/v1"
 	texttospeechpb "google.golang.org/genproto/googleapis/cloud/texttospeech/v1"
 	"lineblocs.com/processor/api"
+	"lineblocs.com/processor/internal/config"
 	"lineblocs.com/processor/types"
 )
 
@@ -46,55 +47,21 @@ type ConfCache struct {
 	UserInfo *types.UserInfo `json:"userInfo"`
 }
 
-const (
-	DEFAULT_CALLER_ID = 1
-)
-
 // TODO get the ip
 func GetPublicIp() string {
 	return "0.0.0.0"
 }
 
-func PlaybackLoops(data types.ModelData) int {
-	item, ok := data.(types.ModelDataStr)
-	if !ok {
-		return DEFAULT_CALLER_ID
-	}
-
-	if item.Value == "" {
-		return DEFAULT_CALLER_ID
-	}
-
-	intVar, err := strconv.Atoi(item.Value)
-	if err != nil {
-		return DEFAULT_CALLER_ID
-	}
-
-	return intVar
-}
-
-func DetermineCallerId(call *types.Call, data types.ModelData) string {
-	item, ok := data.(types.ModelDataStr)
-	if !ok {
-		return call.Params.From
-	}
-
-	if item.Value == "" {
-		// default caller id
-		return call.Params.From
-	}
-	return item.Value
-}
-
 func CheckFreeTrial(plan string) bool {
 	return plan == "expired"
 }
 
 func CreateRDB() *redis.Client {
+	cfg := config.NewConfig()
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "", // no password set
-		DB:       0,  // use default DB
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
 	})
 	return rdb
 }
@@ -135,6 +102,11 @@ func GetCellByName(flow *types.Flow, name string) (*types.Cell, error) {
 }
 
 func LookupCellVariable(flow *types.Flow, name string, lookup string) (string, error) {
+	if name == "flow" {
+		if value, ok := flow.GetVariable(lookup); ok {
+			return value, nil
+		}
+	}
 	var cell *types.Cell
 	cell, err := GetCellByName(flow, name)
 	if err != nil {
@@ -147,31 +119,31 @@ func LookupCellVariable(flow *types.Flow, name string, lookup string) (string, e
 	fmt.Println(cell.Cell.Type)
 	if cell.Cell.Type == "devs.LaunchModel" {
 		if lookup == "call.from" {
-			return cell.EventVars["callFrom"], nil
+			return cellVar(cell, "callFrom"), nil
 		} else if lookup == "call.to" {
-			return cell.EventVars["callTo"], nil
+			return cellVar(cell, "callTo"), nil
 		} else if lookup == "channel.id" {
-			return cell.EventVars["channelId"], nil
+			return cellVar(cell, "channelId"), nil
 		}
 	} else if cell.Cell.Type == "devs.DialhModel" {
 		if lookup == "from" {
-			return cell.EventVars["from"], nil
+			return cellVar(cell, "from"), nil
 		} else if lookup == "call.to" {
-			return cell.EventVars["to"], nil
+			return cellVar(cell, "to"), nil
 		} else if lookup == "dial_status" {
-			return cell.EventVars["dial_status"], nil
+			return cellVar(cell, "dial_status"), nil
 		} else if lookup == "channel.id" {
-			return cell.EventVars["channelId"], nil
+			return cellVar(cell, "channelId"), nil
 		}
 	} else if cell.Cell.Type == "devs.BridgehModel" {
 		if lookup == "from" {
-			return cell.EventVars["from"], nil
+			return cellVar(cell, "from"), nil
 		} else if lookup == "call.to" {
-			return cell.EventVars["to"], nil
+			return cellVar(cell, "to"), nil
 		} else if lookup == "dial_status" {
-			return cell.EventVars["dial_status"], nil
+			return cellVar(cell, "dial_status"), nil
 		} else if lookup == "channel.id" {
-			return cell.EventVars["channelId"], nil
+			return cellVar(cell, "channelId"), nil
 		} else if lookup == "started" {
 			call := cell.AttachedCall
 			return strconv.Itoa(call.GetStartTime()), nil
@@ -183,13 +155,21 @@ func LookupCellVariable(flow *types.Flow, name string, lookup string) (string, e
 		fmt.Println("getting input value..\r")
 		if lookup == "digits" {
 			fmt.Println("found:")
-			fmt.Println(cell.EventVars["digits"])
-			return cell.EventVars["digits"], nil
+			fmt.Println(cellVar(cell, "digits"))
+			return cellVar(cell, "digits"), nil
 		}
 	}
+	if value, ok := cell.GetEventVar(lookup); ok {
+		return value, nil
+	}
 	return "", errors.New("Could not find link")
 }
 
+func cellVar(cell *types.Cell, name string) string {
+	value, _ := cell.GetEventVar(name)
+	return value
+}
+
 func GetARIHost() string {
 	return os.Getenv("ARI_HOST")
 }
@@ -224,29 +204,6 @@ func CreateOriginateRequest2(callerId string, numberToCall string) ari.Originate
 		AppArgs:  "DID_DIAL_2,"}
 }
 
-func DetermineNumberToCall(data map[string]types.ModelData) (string, error) {
-	callType, ok := data["call_type"].(types.ModelDataStr)
-	if !ok {
-		return "", errors.New("could not get call type")
-	}
-
-	switch callType.Value {
-	case "Extension":
-		ext, ok := data["extension"].(types.ModelDataStr)
-		if !ok {
-			return "", errors.New("could not get ext")
-		}
-		return ext.Value, nil
-	case "Phone Number":
-		ext, ok := data["number_to_call"].(types.ModelDataStr)
-		if !ok {
-			return "", errors.New("could not get number")
-		}
-		return ext.Value, nil
-	}
-	return "", errors.New("unknown call type")
-}
-
 func sendToAssetServer(path string, filename string) (string, error) {
 	settings, err := api.GetSettings()
 	if err != nil {
@@ -293,7 +250,36 @@ func sendToAssetServer(path string, filename string) (string, error) {
 	return url, nil
 }
 
+// MediaProvider produces the media played to channels. It replaces text to
+// speech and audio downloads, e.g. when flows are simulated.
+type MediaProvider interface {
+	TTS(say string, gender string, voice string, lang string) (string, error)
+	Download(flow *types.Flow, url string) (string, error)
+}
+
+var (
+	mediaProviderMu sync.RWMutex
+	mediaProvider   MediaProvider
+)
+
+// SetMediaProvider overrides StartTTS and DownloadFile. A nil provider
+// restores the default behaviour.
+func SetMediaProvider(provider MediaProvider) {
+	mediaProviderMu.Lock()
+	defer mediaProviderMu.Unlock()
+	mediaProvider = provider
+}
+
+func getMediaProvider() MediaProvider {
+	mediaProviderMu.RLock()
+	defer mediaProviderMu.RUnlock()
+	return mediaProvider
+}
+
 func DownloadFile(flow *types.Flow, url string) (string, error) {
+	if provider := getMediaProvider(); provider != nil {
+		return provider.Download(flow, url)
+	}
 
 	// Get the data
 	resp, err := http.Get(url)
@@ -341,6 +327,9 @@ func DownloadFile(flow *types.Flow, url string) (string, error) {
 }
 
 func StartTTS(say string, gender string, voice string, lang string) (string, error) {
+	if provider := getMediaProvider(); provider != nil {
+		return provider.TTS(say, gender, voice, lang)
+	}
 	// Instantiates a client.
 	ctx := context.Background()
 	settings, err := api.GetSettings()
@@ -424,60 +413,122 @@ func StartTTS(say string, gender string, voice string, lang string) (string, err
 	return link, nil
 }
 
+// Transcript is the text recognized in speech along with the confidence of
+// the recognizer, between 0 and 1.
+type Transcript struct {
+	Text       string
+	Confidence float32
+}
+
+// SpeechOptions are the language of speech and phrases it is likely to
+// contain.
+type SpeechOptions struct {
+	Language string
+	Hints    []string
+}
+
+// SpeechProvider transcribes the speech of callers from 8kHz wav recordings.
+// The context is the one of the call, so a transcription stops when the call
+// ends.
+type SpeechProvider interface {
+	Transcribe(ctx context.Context, audio []byte, opts *SpeechOptions) (*Transcript, error)
+}
+
+// GoogleSpeechProvider transcribes speech with Google Cloud Speech-to-Text.
+type GoogleSpeechProvider struct{}
+
+func (provider *GoogleSpeechProvider) Transcribe(ctx context.Context, audio []byte, opts *SpeechOptions) (*Transcript, error) {
+	return recognizeSpeech(ctx, &speechpb.RecognitionAudio{
+		AudioSource: &speechpb.RecognitionAudio_Content{Content: audio},
+	}, opts)
+}
+
+var (
+	speechProviderMu sync.RWMutex
+	speechProvider   SpeechProvider
+)
+
+// SetSpeechProvider overrides Transcribe. A nil provider restores Google.
+func SetSpeechProvider(provider SpeechProvider) {
+	speechProviderMu.Lock()
+	defer speechProviderMu.Unlock()
+	speechProvider = provider
+}
+
+// Transcribe returns the text spoken in a recording. Speech without any
+// words has an empty transcript.
+func Transcribe(ctx context.Context, audio []byte, opts *SpeechOptions) (*Transcript, error) {
+	speechProviderMu.RLock()
+	provider := speechProvider
+	speechProviderMu.RUnlock()
+	if provider == nil {
+		provider = &GoogleSpeechProvider{}
+	}
+	return provider.Transcribe(ctx, audio, opts)
+}
+
 func StartSTT(fileURI string) (string, error) {
-	ctx := context.Background()
-	settings, err := api.GetSettings()
+	transcript, err := recognizeSpeech(context.Background(), &speechpb.RecognitionAudio{
+		AudioSource: &speechpb.RecognitionAudio_Uri{Uri: fileURI},
+	}, &SpeechOptions{Language: "en-US"})
 	if err != nil {
 		return "", err
 	}
+	return transcript.Text, nil
+}
+
+func recognizeSpeech(ctx context.Context, audio *speechpb.RecognitionAudio, opts *SpeechOptions) (*Transcript, error) {
+	settings, err := api.GetSettings()
+	if err != nil {
+		return nil, err
+	}
 	var serviceAccountKey = []byte(settings.GoogleServiceAccountJson)
 
 	creds, err := google.CredentialsFromJSON(ctx, serviceAccountKey)
 	if err != nil {
 		helpers.Log(logrus.ErrorLevel, err.Error())
-		return "", err
+		return nil, err
 	}
-	ctx2 := context.Background()
-	//client, err := texttospeech.NewClient(ctx)
 	opt := option.WithCredentials(creds)
 
 	// Creates a client.
-	client, err := speech.NewClient(ctx2, opt)
+	client, err := speech.NewClient(ctx, opt)
 	if err != nil {
 		fmt.Printf("Failed to create client: %v", err)
-		return "", err
+		return nil, err
 	}
 	defer client.Close()
 
+	config := &speechpb.RecognitionConfig{
+		Encoding:        speechpb.RecognitionConfig_LINEAR16,
+		SampleRateHertz: 8000,
+		LanguageCode:    opts.Language,
+	}
+	if len(opts.Hints) != 0 {
+		config.SpeechContexts = []*speechpb.SpeechContext{{Phrases: opts.Hints}}
+	}
 	// Detects speech in the audio file.
 	resp, err := client.Recognize(ctx, &speechpb.RecognizeRequest{
-		Config: &speechpb.RecognitionConfig{
-			Encoding:        speechpb.RecognitionConfig_LINEAR16,
-			SampleRateHertz: 8000,
-			LanguageCode:    "en-US",
-		},
-		Audio: &speechpb.RecognitionAudio{
-			AudioSource: &speechpb.RecognitionAudio_Uri{Uri: fileURI},
-		},
+		Config: config,
+		Audio:  audio,
 	})
 	if err != nil {
 		fmt.Printf("failed to recognize: %v", err)
-		return "", err
+		return nil, err
 	}
 
-	// Prints the results.
-	text := ""
-	var highestConfidence float32 = 0.0
+	// Keeps the most confident alternative.
+	transcript := &Transcript{}
 	for _, result := range resp.Results {
 		for _, alt := range result.Alternatives {
 			fmt.Printf("\"%v\" (confidence=%3f)", alt.Transcript, alt.Confidence)
-			if highestConfidence == 0.0 || alt.Confidence > highestConfidence {
-				text = alt.Transcript
-				highestConfidence = alt.Confidence
+			if transcript.Confidence == 0.0 || alt.Confidence > transcript.Confidence {
+				transcript.Text = alt.Transcript
+				transcript.Confidence = alt.Confidence
 			}
 		}
 	}
-	return text, nil
+	return transcript, nil
 }
 
 func SaveLiveRecording(result *record.Result) (string, error) {
@@ -584,6 +635,14 @@ func GetConfBridge(client ari.Client, user *types.User, confName string) (*types
 	return conf, nil
 }
 
+// RemoveConfBridge forgets the bridge of a conference that ended.
+func RemoveConfBridge(workspace string, confName string) error {
+	var ctx = context.Background()
+	key := workspace + "_" + confName
+	rdb := CreateRDB()
+	return rdb.Del(ctx, key).Err()
+}
+
 func EnsureBridge(cl ari.Client, src *ari.Key, user *types.User, lineChannel *types.LineChannel, callerId string, numberToCall string, typeOfCall string, addedHeaders *[]string) error {
 	helpers.Log(logrus.DebugLevel, "ensureBridge called..")
 	var bridge *ari.BridgeHandle
@@ -680,7 +739,7 @@ func EnsureBridge(cl ari.Client, src *ari.Key, user *types.User, lineChannel *ty
 	timeout := 30
 	wg2 := new(sync.WaitGroup)
 	wg2.Add(1)
-	go lineBridge.StartWaitingForRingTimeout(timeout, wg2, stopChannel)
+	go lineBridge.StartWaitingForRingTimeout(context.Background(), timeout, wg2, stopChannel)
 	wg2.Wait()
 
 	return nil
@@ -883,7 +942,7 @@ func ProcessSIPTrunkCall(
 	timeout := 30
 	wg2 := new(sync.WaitGroup)
 	wg2.Add(1)
-	go lineBridge.StartWaitingForRingTimeout(timeout, wg2, stopChannel)
+	go lineBridge.StartWaitingForRingTimeout(context.Background(), timeout, wg2, stopChannel)
 	wg2.Wait()
 
 	return nil